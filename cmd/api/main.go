@@ -12,14 +12,18 @@ import (
 
 	"comic_video/internal/api/routes"
 	"comic_video/internal/config"
-	"comic_video/internal/repository/minio"
 	"comic_video/internal/repository/postgres"
 	"comic_video/internal/repository/redis"
+	"comic_video/internal/repository/storage"
 	"comic_video/internal/service/auth"
+	"comic_video/internal/service/blob"
 	"comic_video/internal/service/material"
 	"comic_video/internal/service/project"
+	"comic_video/internal/service/prompt"
+	"comic_video/internal/service/storyboard"
 	"comic_video/internal/service/render"
 	"comic_video/internal/service/template"
+	"comic_video/internal/service/upload"
 	"comic_video/internal/service/user"
 	"comic_video/internal/service/video"
 	"comic_video/internal/service/ai"
@@ -55,32 +59,65 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	// 初始化MinIO
-	minioClient := minio.NewClient(
-		cfg.MinIO.Endpoint,
-		cfg.MinIO.AccessKeyID,
-		cfg.MinIO.SecretAccessKey,
-		cfg.MinIO.BucketName,
-		"", // publicHost 暂无
-		cfg.MinIO.UseSSL,
-	)
+	// 初始化对象存储：MinIO 或本地目录（STORAGE_DRIVER）
+	blobStorage, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
 
 	// 初始化仓库
 	userRepo := postgres.NewUserRepository(db)
 	var projectRepo = postgres.NewProjectRepository(db)
 	var projectShareRepo = postgres.NewProjectShareRepository(db)
 	videoRepo := postgres.NewVideoRepository(db)
+	transcriptRepo := postgres.NewTranscriptRepository(db)
 	templateRepo := postgres.NewTemplateRepository(db)
 	var materialRepo = postgres.NewMaterialRepository(db)
 	renderRepo := postgres.NewRenderRepository(db)
+	promptRepo := postgres.NewPromptRepository(db)
+	storyboardRepo := postgres.NewStoryboardRepository(db)
+	uploadRepo := postgres.NewUploadRepository(db)
+	blobRepo := postgres.NewBlobRepository(db)
 
 	// 初始化服务
 	authService := auth.NewService(userRepo, redisClient, &cfg.JWT)
-	userService := user.NewService(userRepo)
-	projectService := project.NewService(projectRepo, projectShareRepo)
-	videoService := video.NewService(videoRepo, minioClient)
-	templateService := template.NewService(templateRepo)
-	materialService := material.NewService(materialRepo, minioClient)
+	userService := user.NewService(userRepo, redisClient)
+	// 管理员接口（提示词模板库、用户管理等）需要管理员角色，按配置的邮箱设置首个管理员
+	if err := userService.PromoteAdmins(context.Background(), cfg.Admin.Emails); err != nil {
+		log.Printf("[Init] 设置管理员失败: %v", err)
+	}
+	projectService := project.NewService(projectRepo, projectShareRepo, blobStorage)
+	// 媒体处理：上传探测成功后生成封面、胶片条和波形，视频转码也在此队列执行
+	mediaQueue := render.NewMemoryTaskQueue(100)
+	// 上传文件按内容 SHA-256 去重存储，视频和素材记录引用计数共享同一文件
+	blobStore := blob.NewStore(blobRepo, blobStorage)
+	videoService := video.NewService(videoRepo, transcriptRepo, blobStorage, blobStore, mediaQueue)
+	materialService := material.NewService(materialRepo, blobStorage, blobStore, mediaQueue)
+	// 分片上传：定时取消长时间无活动的上传会话并清理已上传的分片，完成上传的校验和入库在媒体处理队列执行
+	uploadService := upload.NewService(uploadRepo, blobStorage, blobStore, videoService, materialService, mediaQueue, &cfg.Upload)
+	uploadService.StartCleanup(time.Hour)
+	mediaQueue.StartWorker(2, func(task *entity.Task) {
+		switch task.Type {
+		case entity.TaskTypeVideoPreview:
+			_ = videoService.ProcessPreviews(context.Background(), task)
+		case entity.TaskTypeMaterialPreview:
+			_ = materialService.ProcessPreviews(context.Background(), task)
+		case entity.TaskTypeVideoTranscode:
+			_ = videoService.ProcessTranscode(context.Background(), task)
+		case entity.TaskTypeUploadComplete:
+			_ = uploadService.ProcessComplete(context.Background(), task)
+		default:
+			log.Printf("[Media] 不支持的任务类型: id=%v type=%v", task.ID, task.Type)
+		}
+	})
+	if err := videoService.ResumeTranscodes(context.Background()); err != nil {
+		log.Printf("[Media] 重新提交未完成的转码失败: %v", err)
+	}
+	if err := uploadService.ResumeCompleting(context.Background()); err != nil {
+		log.Printf("[Media] 重新提交未完成的上传处理失败: %v", err)
+	}
+	promptService := prompt.NewService(promptRepo)
+	storyboardService := storyboard.NewService(storyboardRepo, materialRepo, projectRepo, blobStorage, blobStore)
 	// 初始化渲染队列
 	queue := render.NewMemoryRenderQueue(100)
 
@@ -88,35 +125,69 @@ func main() {
 		renderRepo,
 		projectRepo,
 		materialRepo,
-		minioClient,
+		blobStorage,
 		cfg.MinIO.BucketName,
 		queue, // 注入队列
+		cfg.JWT.SecretKey,
 	)
 
 	queue.StartWorker(4, renderService)
 
-	// 初始化AI能力
-	sdClient := &ai.SDClient{Endpoint: cfg.AI.SDEndpoint}
-	ollamaClient := &ai.OllamaClient{Endpoint: cfg.AI.OllamaEndpoint, Model: cfg.AI.OllamaModel, ApiKey: cfg.AI.OllamaApiKey}
-	ttsClient := &ai.TTSClient{Endpoint: cfg.AI.TTSEndpoint}
-	// 可根据需要初始化 WhisperClient 等
+	// 模板预览：创建或修改模板配置后用示例素材低分辨率渲染，生成封面和预览动图
+	previewQueue := render.NewMemoryTaskQueue(100)
+	templateService := template.NewService(templateRepo, projectRepo, materialRepo, renderService, blobStorage, previewQueue)
+	previewQueue.StartWorker(1, func(task *entity.Task) {
+		_ = templateService.ProcessPreview(context.Background(), task)
+	})
+
+	// 初始化AI能力：按配置注册提供方，任务执行时从注册表解析
+	ai.InitAIProviders(&cfg.AI)
+	// 提示词模板库：首次启动写入内置提示词，AI任务从模板库渲染提示词
+	if err := promptService.SeedBuiltins(context.Background()); err != nil {
+		log.Printf("[Init] 初始化内置提示词失败: %v", err)
+	}
+	ai.SetPromptStore(promptService)
+	// 分镜稿：小说转视频出图后保存分镜，支持逐格修改、重绘和重新合成
+	ai.SetPanelStore(storyboardService)
+	// AI生成结果缓存：未改动的分镜、图片和配音在重新生成时直接复用，索引过期的缓存对象定时删除
+	if cfg.AI.CacheEnabled {
+		generationCache := ai.NewGenerationCache(redisClient, blobStorage, time.Duration(cfg.AI.CacheTTLHours)*time.Hour)
+		generationCache.StartSweeper(6 * time.Hour)
+		ai.SetGenerationCache(generationCache)
+	}
 
 	// 初始化通用任务队列
 	taskQueue := ai.NewMemoryTaskQueue(100)
 	log.Println("[Init] AI任务队列已创建", taskQueue)
 
-	// 启动 NovelToVideo worker
+	// 写入Postgres历史记录，含解析提供方失败的任务
+	saveTask := func(task *entity.Task) {
+		if err := db.Create(task).Error; err == nil {
+			log.Printf("[Worker] 任务已写入Postgres: id=%v", task.ID)
+		}
+	}
+
+	// 启动 AI 任务 worker
 	taskQueue.StartWorker(4, func(task *entity.Task) {
 		log.Printf("[Worker] 收到任务: id=%v type=%v status=%v", task.ID, task.Type, task.Status)
-		if task.Type == entity.TaskTypeVideo {
+		providers, err := ai.ResolveTaskProviders(task)
+		if err != nil {
+			task.Status = entity.TaskStatusFailed
+			task.Error = err.Error()
+			task.UpdatedAt = time.Now()
+			_ = redisClient.SetTaskStatus(context.Background(), task, 24*time.Hour)
+			log.Printf("[Worker] 解析AI提供方失败: id=%v err=%v", task.ID, err)
+			saveTask(task)
+			return
+		}
+		switch task.Type {
+		case entity.TaskTypeVideo:
 			log.Printf("[Worker] 开始处理视频任务: id=%v", task.ID)
 			err := ai.ProcessNovelToVideo(
 				context.Background(),
 				task,
 				redisClient,
-				sdClient,
-				ollamaClient,
-				ttsClient,
+				providers,
 				cfg.MinIO.BucketName,
 			)
 			if err == nil {
@@ -125,7 +196,7 @@ func main() {
 				var result map[string]interface{}
 				_ = json.Unmarshal([]byte(task.Result), &result)
 				videoPath, _ := result["url"].(string)
-				url, err := ai.UploadVideoToMinio(context.Background(), minioClient, cfg.MinIO.BucketName, videoPath)
+				url, err := ai.UploadVideoToMinio(context.Background(), blobStorage, cfg.MinIO.BucketName, videoPath)
 				if err == nil {
 					result["url"] = url
 					b, _ := json.Marshal(result)
@@ -137,17 +208,36 @@ func main() {
 					log.Printf("[Worker] 视频已上传MinIO: id=%v url=%v", task.ID, url)
 				}
 			}
-			// 新增：写入Postgres历史记录
-			dbIns, err := postgres.NewConnection(&cfg.Database)
-			if err == nil {
-				dbIns.Create(task)
-				log.Printf("[Worker] 任务已写入Postgres: id=%v", task.ID)
+		case entity.TaskTypeNovel:
+			log.Printf("[Worker] 开始处理小说生成任务: id=%v", task.ID)
+			if err := ai.ProcessGenerateNovel(context.Background(), task, redisClient, providers); err == nil {
+				log.Printf("[Worker] 小说生成任务处理完成: id=%v", task.ID)
+			}
+		case entity.TaskTypeTranscribe:
+			log.Printf("[Worker] 开始处理语音识别任务: id=%v", task.ID)
+			if err := videoService.ProcessTranscribe(context.Background(), task, redisClient, providers); err == nil {
+				log.Printf("[Worker] 语音识别任务处理完成: id=%v", task.ID)
+			}
+		case entity.TaskTypePanelImage:
+			log.Printf("[Worker] 开始处理分镜重绘任务: id=%v", task.ID)
+			if err := storyboardService.ProcessRegenerate(context.Background(), task, redisClient, providers); err == nil {
+				log.Printf("[Worker] 分镜重绘任务处理完成: id=%v", task.ID)
 			}
-		} else {
+		case entity.TaskTypeStoryboardCompose:
+			log.Printf("[Worker] 开始处理分镜合成任务: id=%v", task.ID)
+			if err := storyboardService.ProcessCompose(context.Background(), task, redisClient, providers); err == nil {
+				log.Printf("[Worker] 分镜合成任务处理完成: id=%v", task.ID)
+			}
+		default:
 			log.Printf("[Worker] 不支持的任务类型: id=%v type=%v", task.ID, task.Type)
+			return
 		}
+		saveTask(task)
 	})
 
+	// 本地存储的文件由 API 自身提供下载和直传
+	localStorage, _ := blobStorage.(*storage.Local)
+
 	// 设置路由
 	router := routes.SetupRoutes(
 		userService,
@@ -157,6 +247,10 @@ func main() {
 		renderService,
 		projectService,
 		materialService,
+		promptService,
+		storyboardService,
+		uploadService,
+		localStorage,
 		redisClient,
 		taskQueue, // 新增参数
 	)
@@ -169,7 +263,7 @@ func main() {
 
 	// 启动服务器
 	go func() {
-		log.Printf("Starting server on port %d", cfg.Server.Port)
+		log.Printf("Starting server on port %s", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -0,0 +1,174 @@
+// dedup 将早期按时间戳命名上传的视频和素材文件迁移到内容寻址存储：
+// 逐个下载计算 SHA-256，相同内容只保留一份，记录改为引用 blobs/sha256 下的文件并计入引用计数
+//
+//	go run ./cmd/dedup            执行迁移
+//	go run ./cmd/dedup -dry-run   只统计可节省的空间
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"comic_video/internal/config"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/blob"
+	"comic_video/internal/service/media"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// record 待迁移的视频或素材记录
+type record struct {
+	ID       uuid.UUID
+	FilePath string
+}
+
+type migrator struct {
+	db      *gorm.DB
+	storage storage.BlobStore
+	blobs   *blob.Store
+	dryRun  bool
+	batch   int
+
+	seen    map[string]int64 // 试运行时已出现的内容哈希及大小
+	files   int
+	dupes   int
+	saved   int64
+	skipped int
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "只统计重复文件，不做修改")
+	batch := flag.Int("batch", 100, "每批处理的记录数")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := postgres.NewConnection(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := postgres.AutoMigrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	blobStorage, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+
+	m := &migrator{
+		db:      db,
+		storage: blobStorage,
+		blobs:   blob.NewStore(postgres.NewBlobRepository(db), blobStorage),
+		dryRun:  *dryRun,
+		batch:   *batch,
+		seen:    make(map[string]int64),
+	}
+	ctx := context.Background()
+	m.run(ctx, &entity.Video{})
+	m.run(ctx, &entity.Material{})
+
+	if m.dryRun {
+		log.Printf("[Dedup] 试运行：共 %d 个文件，重复 %d 个，可节省 %.1f MB，跳过 %d 个", m.files, m.dupes, float64(m.saved)/(1<<20), m.skipped)
+		return
+	}
+	log.Printf("[Dedup] 完成：迁移 %d 个文件，其中重复 %d 个，节省 %.1f MB，跳过 %d 个", m.files, m.dupes, float64(m.saved)/(1<<20), m.skipped)
+}
+
+// run 按 ID 顺序分批迁移一张表中尚未计算内容哈希的记录
+func (m *migrator) run(ctx context.Context, model interface{}) {
+	lastID := uuid.Nil
+	for {
+		var records []record
+		err := m.db.WithContext(ctx).Model(model).
+			Select("id, file_path").
+			Where("(content_hash IS NULL OR content_hash = '') AND id > ?", lastID).
+			Order("id ASC").
+			Limit(m.batch).
+			Scan(&records).Error
+		if err != nil {
+			log.Fatalf("[Dedup] 查询记录失败: %v", err)
+		}
+		for _, r := range records {
+			if err := m.migrate(ctx, model, r); err != nil {
+				log.Printf("[Dedup] 跳过 %s: %v", r.FilePath, err)
+				m.skipped++
+			}
+			lastID = r.ID
+		}
+		if len(records) < m.batch {
+			return
+		}
+	}
+}
+
+// migrate 计算文件哈希并转存，记录改为引用内容寻址的文件后删除原文件
+func (m *migrator) migrate(ctx context.Context, model interface{}, r record) error {
+	object, err := m.storage.StatObject(ctx, r.FilePath)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "dedup_*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := media.Download(ctx, m.storage, r.FilePath, tmp.Name()); err != nil {
+		return err
+	}
+	hash, err := media.FileSHA256(tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	m.files++
+	if m.dryRun {
+		if _, ok := m.seen[hash]; ok {
+			m.dupes++
+			m.saved += object.Size
+		}
+		m.seen[hash] = object.Size
+		return nil
+	}
+
+	stored, err := m.blobs.Adopt(ctx, r.FilePath, hash, object.Size, object.ContentType)
+	if err != nil {
+		return err
+	}
+	if stored.RefCount > 1 {
+		m.dupes++
+		m.saved += object.Size
+	}
+	err = m.db.WithContext(ctx).Model(model).Where("id = ?", r.ID).Updates(map[string]interface{}{
+		"file_path":    stored.ObjectName,
+		"content_hash": hash,
+	}).Error
+	if err != nil {
+		_ = m.blobs.Release(ctx, hash)
+		return err
+	}
+
+	// 原文件不再被任何记录引用时删除
+	if r.FilePath != stored.ObjectName && !m.referenced(ctx, r.FilePath) {
+		if err := m.storage.Delete(ctx, r.FilePath); err != nil {
+			log.Printf("[Dedup] 删除原文件失败: %s: %v", r.FilePath, err)
+		}
+	}
+	return nil
+}
+
+// referenced 是否仍有视频或素材记录直接引用该对象
+func (m *migrator) referenced(ctx context.Context, objectName string) bool {
+	for _, model := range []interface{}{&entity.Video{}, &entity.Material{}} {
+		var count int64
+		if err := m.db.WithContext(ctx).Model(model).Where("file_path = ?", objectName).Count(&count).Error; err != nil || count > 0 {
+			return true
+		}
+	}
+	return false
+}
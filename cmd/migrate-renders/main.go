@@ -0,0 +1,98 @@
+// migrate-renders 将旧版本保存在单独 renders 存储桶中的渲染成片和播放文件复制到默认存储桶的 renders/ 目录下，
+// 记录中的路径相对于该目录，无需修改数据库。目标已存在的对象跳过，可重复执行
+//
+//	go run ./cmd/migrate-renders            执行复制
+//	go run ./cmd/migrate-renders -dry-run   只统计待复制的文件
+//	go run ./cmd/migrate-renders -delete    复制后删除旧存储桶中的对象
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"comic_video/internal/config"
+	"comic_video/internal/repository/storage"
+)
+
+// rendersPrefix 与渲染服务保存成片的对象名前缀一致
+const rendersPrefix = "renders/"
+
+func main() {
+	legacyBucket := flag.String("bucket", "renders", "旧版本保存渲染结果的存储桶")
+	dryRun := flag.Bool("dry-run", false, "只统计待复制的文件，不做修改")
+	remove := flag.Bool("delete", false, "复制成功后删除旧存储桶中的对象")
+	flag.Parse()
+
+	cfg := config.Load()
+	if *legacyBucket == cfg.MinIO.BucketName {
+		log.Fatalf("旧存储桶与默认存储桶相同: %s", *legacyBucket)
+	}
+	target, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	legacyCfg := *cfg
+	legacyCfg.MinIO.BucketName = *legacyBucket
+	legacy, err := storage.Open(&legacyCfg)
+	if err != nil {
+		log.Fatalf("Failed to open legacy storage: %v", err)
+	}
+
+	ctx := context.Background()
+	objects, err := legacy.ListObjects(ctx, "")
+	if err != nil {
+		log.Fatalf("[Renders] 列出旧存储桶对象失败: %v", err)
+	}
+
+	var copied, existed, failed int
+	var size int64
+	for _, object := range objects {
+		dst := rendersPrefix + object.Key
+		_, err := target.StatObject(ctx, dst)
+		switch {
+		case err == nil:
+			existed++
+		case !errors.Is(err, storage.ErrObjectNotFound):
+			log.Printf("[Renders] 跳过 %s: %v", object.Key, err)
+			failed++
+			continue
+		case *dryRun:
+			copied++
+			size += object.Size
+			continue
+		default:
+			if err := copyObject(ctx, legacy, target, object, dst); err != nil {
+				log.Printf("[Renders] 复制失败 %s: %v", object.Key, err)
+				failed++
+				continue
+			}
+			copied++
+			size += object.Size
+		}
+
+		if *remove && !*dryRun {
+			if err := legacy.Delete(ctx, object.Key); err != nil {
+				log.Printf("[Renders] 删除旧对象失败 %s: %v", object.Key, err)
+			}
+		}
+	}
+
+	if *dryRun {
+		log.Printf("[Renders] 试运行：待复制 %d 个文件 %.1f MB，已存在 %d 个，失败 %d 个", copied, float64(size)/(1<<20), existed, failed)
+		return
+	}
+	log.Printf("[Renders] 完成：复制 %d 个文件 %.1f MB，已存在 %d 个，失败 %d 个", copied, float64(size)/(1<<20), existed, failed)
+}
+
+// copyObject 从旧存储桶读取对象并写入默认存储桶的目标路径
+func copyObject(ctx context.Context, src, dst storage.BlobStore, object storage.ObjectInfo, dstName string) error {
+	reader, err := src.Open(ctx, object.Key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = dst.Upload(ctx, dstName, reader, object.Size, object.ContentType)
+	return err
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -23,13 +24,42 @@ func NewAIHandler(redisClient *redis.Client, queue ai.TaskQueue) *AIHandler {
 }
 
 // NovelToVideo 提交一键生成动漫视频任务
+// 可直接传入 novel，也可通过 novel_task_id 引用已完成的小说生成任务（chapter 指定章节，0 表示全文）
+// providers 可选，按能力指定提供方和模型，如 {"image_gen":{"provider":"openai"}}
+// voices 可选，为旁白和角色分配音色，如 {"narrator":{"voice":"alloy"},"characters":{"小明":{"voice":"echo"}}}
+// no_cache 可选，为 true 时不复用已缓存的分镜、图片和配音，全部重新生成
+// 登录后提交的任务记录提交者，只有提交者和管理员可以修改生成的分镜稿
 func (h *AIHandler) NovelToVideo(c *gin.Context) {
-	var req struct{ Novel string `json:"novel"` }
-	if err := c.ShouldBindJSON(&req); err != nil || req.Novel == "" {
+	var req struct {
+		Novel       string               `json:"novel"`
+		NovelTaskID string               `json:"novel_task_id"`
+		Chapter     int                  `json:"chapter"`
+		Providers   ai.ProviderOverrides `json:"providers"`
+		Voices      *ai.VoiceCast        `json:"voices"`
+		NoCache     bool                 `json:"no_cache"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Novel == "" && req.NovelTaskID == "") {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
 		return
 	}
-	params, _ := json.Marshal(req)
+	providers, err := ai.ResolveProviders(req.Providers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if err := req.Voices.Validate(providers.Names.TTS.Provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if req.Novel == "" {
+		novel, err := h.novelFromTask(c, req.NovelTaskID, req.Chapter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+			return
+		}
+		req.Novel = novel
+	}
+	params, _ := json.Marshal(map[string]interface{}{"novel": req.Novel, "providers": req.Providers, "voices": req.Voices, "no_cache": req.NoCache, "user_id": c.GetString("user_id")})
 	task := &entity.Task{
 		ID:        uuid.New(),
 		Type:      entity.TaskTypeVideo,
@@ -44,23 +74,60 @@ func (h *AIHandler) NovelToVideo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "任务已提交", "task_id": task.ID})
 }
 
+// novelFromTask 从已完成的小说生成任务中取出全文或指定章节
+func (h *AIHandler) novelFromTask(c *gin.Context, taskID string, chapter int) (string, error) {
+	task, err := h.redisClient.GetTaskStatus(c.Request.Context(), taskID)
+	if err != nil || task.Type != entity.TaskTypeNovel {
+		return "", errors.New("小说任务不存在或已过期")
+	}
+	if task.Status != entity.TaskStatusCompleted {
+		return "", errors.New("小说任务尚未完成")
+	}
+	var result ai.NovelResult
+	if err := json.Unmarshal([]byte(task.Result), &result); err != nil {
+		return "", errors.New("小说任务结果解析失败")
+	}
+	if chapter <= 0 {
+		return result.Novel, nil
+	}
+	if chapter > len(result.Chapters) {
+		return "", errors.New("章节不存在")
+	}
+	return result.Chapters[chapter-1].Content, nil
+}
+
 // GenerateNovel 提交AI生成小说任务
 func (h *AIHandler) GenerateNovel(c *gin.Context) {
 	var req struct {
-		NovelPrompt string `json:"novel_prompt"`
-		Title      string `json:"title"`
+		NovelPrompt   string `json:"novel_prompt"`
+		Title         string `json:"title"`
+		Style         string `json:"style"`
+		Chapters      int    `json:"chapters" binding:"omitempty,min=1,max=30"`
+		ChapterLength int    `json:"chapter_length" binding:"omitempty,min=100,max=5000"`
+
+		Providers ai.ProviderOverrides `json:"providers"`
+		NoCache   bool                 `json:"no_cache"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil || req.NovelPrompt == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
 		return
 	}
-	params, _ := json.Marshal(map[string]interface{}{
-		"novel": req.NovelPrompt,
-		"title": req.Title,
+	if _, err := ai.ResolveProviders(req.Providers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	params, _ := json.Marshal(ai.NovelParams{
+		Novel:         req.NovelPrompt,
+		Title:         req.Title,
+		Style:         req.Style,
+		Chapters:      req.Chapters,
+		ChapterLength: req.ChapterLength,
+		Providers:     req.Providers,
+		NoCache:       req.NoCache,
 	})
 	task := &entity.Task{
 		ID:        uuid.New(),
-		Type:      entity.TaskTypeAI,
+		Type:      entity.TaskTypeNovel,
 		Status:    entity.TaskStatusPending,
 		Progress:  0,
 		Params:    string(params),
@@ -78,14 +145,30 @@ func (h *AIHandler) NovelToAll(c *gin.Context) {
 	var req struct {
 		NovelPrompt string `json:"novel_prompt"`
 		Title      string `json:"title"`
+		Providers   ai.ProviderOverrides `json:"providers"`
+		Voices      *ai.VoiceCast        `json:"voices"`
+		NoCache     bool                 `json:"no_cache"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil || req.NovelPrompt == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
 		return
 	}
+	providers, err := ai.ResolveProviders(req.Providers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if err := req.Voices.Validate(providers.Names.TTS.Provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
 	params, _ := json.Marshal(map[string]interface{}{
-		"novel": req.NovelPrompt,
-		"title": req.Title,
+		"novel":     req.NovelPrompt,
+		"title":     req.Title,
+		"providers": req.Providers,
+		"voices":    req.Voices,
+		"no_cache":  req.NoCache,
+		"user_id":   c.GetString("user_id"),
 	})
 	task := &entity.Task{
 		ID:        uuid.New(),
@@ -100,4 +183,106 @@ func (h *AIHandler) NovelToAll(c *gin.Context) {
 	_ = h.queue.Enqueue(task)
 	log.Printf("[Handler] NovelToAll: 入队任务 id=%v type=%v queue=%p", task.ID, task.Type, h.queue)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "任务已提交", "task_id": task.ID})
-} 
\ No newline at end of file
+}
+
+// 流式对话的输入上限：消息条数和提示词加全部消息的总字符数
+const (
+	maxChatMessages = 50
+	maxChatRunes    = 8000
+)
+
+// StreamChat 流式对话，通过SSE逐段推送模型输出，需登录
+// 事件：token（{"content":片段}）、done（{"content":全文}）、error（{"message":错误}）
+// 客户端断开连接时请求上下文取消，模型生成随之中止
+func (h *AIHandler) StreamChat(c *gin.Context) {
+	var req struct {
+		Prompt   string                 `json:"prompt"`
+		Messages []ai.Message           `json:"messages"`
+		Options  map[string]interface{} `json:"options"`
+		Provider string                 `json:"provider"` // 可选，文本生成提供方
+		Model    string                 `json:"model"`    // 可选，模型
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Prompt == "" && len(req.Messages) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+	runes := len([]rune(req.Prompt))
+	for _, m := range req.Messages {
+		runes += len([]rune(m.Content))
+	}
+	if len(req.Messages) > maxChatMessages || runes > maxChatRunes {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "对话内容过长"})
+		return
+	}
+	providers, err := ai.ResolveProviders(ai.ProviderOverrides{
+		TextGen: ai.ProviderChoice{Provider: req.Provider, Model: req.Model},
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if providers.TextGen == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "文本生成提供方未配置"})
+		return
+	}
+	opts := providers.TextGenOpts
+	for k, v := range req.Options {
+		opts[k] = v
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ctx := c.Request.Context()
+	onToken := func(token string) error {
+		c.SSEvent("token", gin.H{"content": token})
+		c.Writer.Flush()
+		return ctx.Err()
+	}
+	var content string
+	if len(req.Messages) > 0 {
+		content, err = providers.TextGen.ChatStream(ctx, req.Messages, opts, onToken)
+	} else {
+		content, err = providers.TextGen.GenerateStream(ctx, req.Prompt, opts, onToken)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Printf("[Handler] StreamChat: 客户端已断开，生成中止")
+			return
+		}
+		c.SSEvent("error", gin.H{"message": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", gin.H{"content": content})
+	c.Writer.Flush()
+}
+
+// ListProviders 查询AI提供方运行状态（熔断状态、健康检查、并发占用），仅管理员可用
+func (h *AIHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "success", "data": ai.ProviderStatuses()})
+}
+
+// PreviewVoice 音色试听，按音色配置合成一段示例文本并直接返回音频，需登录
+func (h *AIHandler) PreviewVoice(c *gin.Context) {
+	var req struct {
+		Voice ai.VoiceProfile `json:"voice"`
+		Text  string          `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len([]rune(req.Text)) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+	audio, err := ai.PreviewVoice(c.Request.Context(), req.Voice, req.Text)
+	if errors.Is(err, ai.ErrInvalidVoice) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": "试听生成失败: " + err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, http.DetectContentType(audio), audio)
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/vo"
 	"comic_video/internal/service/material"
+	"comic_video/internal/service/media"
 	"comic_video/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -36,7 +38,7 @@ func (h *MaterialHandler) List(c *gin.Context) {
 	if req.PageSize < 1 || req.PageSize > 100 {
 		req.PageSize = 10
 	}
-	materials, total, err := h.service.List(c.Request.Context(), req)
+	materials, total, err := h.service.List(c.Request.Context(), c.GetString("user_id"), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
 			Code:    500,
@@ -51,6 +53,17 @@ func (h *MaterialHandler) List(c *gin.Context) {
 		Page:      req.Page,
 		PageSize:  req.PageSize,
 	}
+	if req.Facets {
+		resp.Facets, err = h.service.Facets(c.Request.Context(), c.GetString("user_id"), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+				Code:    500,
+				Message: "获取素材列表失败",
+				Errors:  err.Error(),
+			})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, vo.SuccessResponse{
 		Code:    200,
 		Message: "获取素材列表成功",
@@ -61,7 +74,7 @@ func (h *MaterialHandler) List(c *gin.Context) {
 // GetByID 获取素材详情
 func (h *MaterialHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
-	material, err := h.service.GetByID(c.Request.Context(), id)
+	material, err := h.service.GetByID(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, vo.ErrorResponse{
 			Code:    404,
@@ -100,8 +113,9 @@ func (h *MaterialHandler) Upload(c *gin.Context) {
 	}
 	material, err := h.service.Upload(c.Request.Context(), userID, req, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := materialErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "上传素材失败",
 			Errors:  err.Error(),
 		})
@@ -126,10 +140,11 @@ func (h *MaterialHandler) Update(c *gin.Context) {
 		})
 		return
 	}
-	material, err := h.service.Update(c.Request.Context(), id, req)
+	material, err := h.service.Update(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := materialErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "更新素材失败",
 			Errors:  err.Error(),
 		})
@@ -145,10 +160,11 @@ func (h *MaterialHandler) Update(c *gin.Context) {
 // Delete 删除素材
 func (h *MaterialHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	err := h.service.Delete(c.Request.Context(), id)
+	err := h.service.Delete(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := materialErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "删除素材失败",
 			Errors:  err.Error(),
 		})
@@ -159,4 +175,39 @@ func (h *MaterialHandler) Delete(c *gin.Context) {
 		Message: "素材删除成功",
 		Data:    nil,
 	})
-} 
\ No newline at end of file
+}
+
+// GeneratePreviews 重新生成素材封面、胶片条和波形
+func (h *MaterialHandler) GeneratePreviews(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+	material, err := h.service.GeneratePreviews(c.Request.Context(), id, userID, c.GetString("user_role"))
+	if err != nil {
+		status := materialErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "生成预览失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "预览生成已开始",
+		Data:    material,
+	})
+}
+
+// materialErrorStatus 无权操作返回 403，文件损坏或格式不支持返回 422，其余返回 500
+func materialErrorStatus(err error) int {
+	if errors.Is(err, material.ErrForbidden) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, media.ErrUnsupported) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/vo"
+	"comic_video/internal/service/prompt"
+	"comic_video/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromptHandler struct {
+	service *prompt.Service
+}
+
+func NewPromptHandler(service *prompt.Service) *PromptHandler {
+	return &PromptHandler{service: service}
+}
+
+// List 获取提示词模板列表，可按 name 过滤
+func (h *PromptHandler) List(c *gin.Context) {
+	prompts, err := h.service.List(c.Request.Context(), c.Query("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Code:    500,
+			Message: "获取提示词列表失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取提示词列表成功",
+		Data:    prompts,
+	})
+}
+
+// GetByID 获取提示词模板详情
+func (h *PromptHandler) GetByID(c *gin.Context) {
+	p, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Code:    404,
+			Message: "提示词不存在",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取提示词成功",
+		Data:    p,
+	})
+}
+
+// Create 新建提示词版本
+func (h *PromptHandler) Create(c *gin.Context) {
+	var req dto.CreatePromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	p, err := h.service.Create(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "创建提示词失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, vo.SuccessResponse{
+		Code:    201,
+		Message: "提示词创建成功",
+		Data:    p,
+	})
+}
+
+// Update 更新提示词
+func (h *PromptHandler) Update(c *gin.Context) {
+	var req dto.UpdatePromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	p, err := h.service.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "更新提示词失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "提示词更新成功",
+		Data:    p,
+	})
+}
+
+// StartABTest 对同名提示词的两个版本开启A/B测试
+func (h *PromptHandler) StartABTest(c *gin.Context) {
+	var req dto.PromptABTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	prompts, err := h.service.StartABTest(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "开启A/B测试失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "A/B测试已开启",
+		Data:    prompts,
+	})
+}
+
+// Stats 各版本使用与任务成败统计
+func (h *PromptHandler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Code:    500,
+			Message: "获取提示词统计失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取提示词统计成功",
+		Data:    stats,
+	})
+}
+
+// Preview 渲染预览
+func (h *PromptHandler) Preview(c *gin.Context) {
+	var req dto.PreviewPromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	rendered, err := h.service.Preview(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "提示词渲染失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "渲染成功",
+		Data:    rendered,
+	})
+}
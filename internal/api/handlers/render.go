@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,18 +11,21 @@ import (
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/vo"
+	"comic_video/internal/service/project"
 	"comic_video/internal/service/render"
 )
 
 // RenderHandler 渲染处理器
 type RenderHandler struct {
-	renderService render.Service
+	renderService  render.Service
+	projectService *project.Service
 }
 
 // NewRenderHandler 创建渲染处理器实例
-func NewRenderHandler(renderService render.Service) *RenderHandler {
+func NewRenderHandler(renderService render.Service, projectService *project.Service) *RenderHandler {
 	return &RenderHandler{
-		renderService: renderService,
+		renderService:  renderService,
+		projectService: projectService,
 	}
 }
 
@@ -367,4 +371,76 @@ func (h *RenderHandler) DownloadRender(c *gin.Context) {
 		Data:      download,
 		Timestamp: time.Now(),
 	})
-} 
\ No newline at end of file
+}
+
+// Playback 获取自适应码率播放列表
+// @Summary 获取自适应码率播放列表
+// @Description 渲染任务所有者或持有项目分享 token 的观看者获取 HLS / DASH 播放列表，分片地址为临时签名地址
+// @Tags 渲染
+// @Produce application/vnd.apple.mpegurl
+// @Produce application/dash+xml
+// @Param id path string true "渲染任务ID"
+// @Param format path string true "播放格式 hls / dash"
+// @Param name path string true "播放列表路径，如 master.m3u8、720p/index.m3u8、manifest.mpd"
+// @Param share query string false "项目分享 token"
+// @Param password query string false "分享密码"
+// @Success 200 {string} string "播放列表"
+// @Failure 400 {object} vo.ErrorResponse
+// @Failure 401 {object} vo.ErrorResponse
+// @Failure 403 {object} vo.ErrorResponse
+// @Failure 404 {object} vo.ErrorResponse
+// @Router /api/v1/renders/{id}/playback/{format}/{name} [get]
+func (h *RenderHandler) Playback(c *gin.Context) {
+	renderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:      400,
+			Message:   "无效的渲染任务ID",
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	var access render.PlaybackAccess
+	if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		access.UserID = id
+	}
+	if token := c.Query("playback_token"); token != "" {
+		// 子播放列表携带主播放列表签发的播放令牌，由渲染服务校验
+		access.Token = token
+	} else if token := c.Query("share"); token != "" {
+		share, err := h.projectService.CheckShareToken(c.Request.Context(), token, c.Query("password"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+				Code:      401,
+				Message:   "分享校验失败",
+				Errors:    err.Error(),
+				Timestamp: time.Now(),
+			})
+			return
+		}
+		access.SharedProjectID = &share.ProjectID
+	}
+
+	file, err := h.renderService.Playback(c.Request.Context(), renderID, access, c.Param("format"), c.Param("name"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, render.ErrPlaybackForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, render.ErrPlaybackNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, vo.ErrorResponse{
+			Code:      status,
+			Message:   "获取播放列表失败",
+			Errors:    err.Error(),
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	// 分片签名地址有时效，播放列表不允许缓存
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, file.ContentType, file.Body)
+}
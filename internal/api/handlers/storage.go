@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"comic_video/internal/domain/vo"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageHandler 本地存储的对象下载和直传接口，使用 MinIO 时不注册
+type StorageHandler struct {
+	store *storage.Local
+}
+
+func NewStorageHandler(store *storage.Local) *StorageHandler {
+	return &StorageHandler{store: store}
+}
+
+// Get 校验签名后返回对象内容，支持 Range 请求
+func (h *StorageHandler) Get(c *gin.Context) {
+	objectName := strings.TrimPrefix(c.Param("object"), "/")
+	file, err := h.store.OpenSigned(c.Param("bucket"), objectName, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		status := storageErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "读取文件失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Code:    500,
+			Message: "读取文件失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	// 播放列表和分片按扩展名设置类型，其余由 ServeContent 按扩展名或文件头推断
+	if contentType := media.ContentType(objectName); contentType != "application/octet-stream" {
+		c.Header("Content-Type", contentType)
+	}
+	c.Header("Cache-Control", "private")
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
+
+// Post 接收直传表单，表单字段由上传会话的 direct 给出，文件字段 file 放在最后
+func (h *StorageHandler) Post(c *gin.Context) {
+	form, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	if err := h.store.ReceivePost(c.Param("bucket"), form); err != nil {
+		status := storageErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "上传文件失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func storageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrInvalidSignature):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrEntityTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, storage.ErrInvalidForm):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrChecksumMismatch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/vo"
+	"comic_video/internal/repository/redis"
+	"comic_video/internal/service/ai"
+	"comic_video/internal/service/storyboard"
+	"comic_video/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StoryboardHandler 小说转视频任务的分镜审阅：修改、重绘、排序后只重新合成视频
+type StoryboardHandler struct {
+	service     *storyboard.Service
+	redisClient *redis.Client
+	queue       ai.TaskQueue
+}
+
+func NewStoryboardHandler(service *storyboard.Service, redisClient *redis.Client, queue ai.TaskQueue) *StoryboardHandler {
+	return &StoryboardHandler{service: service, redisClient: redisClient, queue: queue}
+}
+
+// Get 获取任务的分镜列表
+func (h *StoryboardHandler) Get(c *gin.Context) {
+	sb, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Code:    404,
+			Message: "分镜稿不存在",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取分镜成功",
+		Data:    sb,
+	})
+}
+
+// UpdatePanel 修改单格分镜
+func (h *StoryboardHandler) UpdatePanel(c *gin.Context) {
+	var req dto.UpdatePanelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	panel, err := h.service.UpdatePanel(c.Request.Context(), c.Param("id"), c.Param("panel_id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "修改分镜失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "分镜已更新",
+		Data:    panel,
+	})
+}
+
+// DeletePanel 删除单格分镜
+func (h *StoryboardHandler) DeletePanel(c *gin.Context) {
+	if err := h.service.DeletePanel(c.Request.Context(), c.Param("id"), c.Param("panel_id"), c.GetString("user_id"), c.GetString("user_role")); err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "删除分镜失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "分镜已删除",
+	})
+}
+
+// Reorder 调整分镜顺序
+func (h *StoryboardHandler) Reorder(c *gin.Context) {
+	var req dto.ReorderPanelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	sb, err := h.service.Reorder(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "调整分镜顺序失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "分镜顺序已更新",
+		Data:    sb,
+	})
+}
+
+// Regenerate 提交单格重绘任务
+func (h *StoryboardHandler) Regenerate(c *gin.Context) {
+	var req dto.RegeneratePanelRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	task, err := h.service.NewRegenerateTask(c.Request.Context(), c.Param("id"), c.Param("panel_id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "提交重绘失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	_ = h.redisClient.SetTaskStatus(c.Request.Context(), task, 24*time.Hour)
+	_ = h.queue.Enqueue(task)
+
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "重绘任务已提交",
+		Data:    gin.H{"task_id": task.ID},
+	})
+}
+
+// Compose 按当前分镜重新合成视频，不重新生成分镜和图片
+func (h *StoryboardHandler) Compose(c *gin.Context) {
+	var req dto.ComposeStoryboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	task, err := h.service.NewComposeTask(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "提交合成失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	_ = h.redisClient.SetTaskStatus(c.Request.Context(), task, 24*time.Hour)
+	_ = h.queue.Enqueue(task)
+
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "合成任务已提交",
+		Data:    gin.H{"task_id": task.ID},
+	})
+}
+
+// ToProject 将分镜稿转存为可编辑项目
+func (h *StoryboardHandler) ToProject(c *gin.Context) {
+	var req dto.StoryboardProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	project, err := h.service.ToProject(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := storyboardErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "转存项目失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, vo.SuccessResponse{
+		Code:    201,
+		Message: "已转存为项目",
+		Data:    project,
+	})
+}
+
+// storyboardErrorStatus 无权修改返回 403，其余返回 400
+func storyboardErrorStatus(err error) int {
+	if errors.Is(err, storyboard.ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
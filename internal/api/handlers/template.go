@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/vo"
@@ -56,10 +57,18 @@ func (h *TemplateHandler) List(c *gin.Context) {
 	})
 }
 
+// Trending 按近期使用热度和评分排序的模板列表
+func (h *TemplateHandler) Trending(c *gin.Context) {
+	query := c.Request.URL.Query()
+	query.Set("sort", "trending")
+	c.Request.URL.RawQuery = query.Encode()
+	h.List(c)
+}
+
 // GetByID 获取模板详情
 func (h *TemplateHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
-	template, err := h.service.GetByID(c.Request.Context(), id)
+	template, err := h.service.GetByID(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, vo.ErrorResponse{
 			Code:    404,
@@ -86,7 +95,7 @@ func (h *TemplateHandler) Create(c *gin.Context) {
 		})
 		return
 	}
-	template, err := h.service.Create(c.Request.Context(), req)
+	template, err := h.service.Create(c.Request.Context(), c.GetString("user_id"), c.GetString("user_role"), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
 			Code:    500,
@@ -114,10 +123,11 @@ func (h *TemplateHandler) Update(c *gin.Context) {
 		})
 		return
 	}
-	template, err := h.service.Update(c.Request.Context(), id, req)
+	template, err := h.service.Update(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := templateErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "更新模板失败",
 			Errors: err.Error(),
 		})
@@ -133,10 +143,11 @@ func (h *TemplateHandler) Update(c *gin.Context) {
 // Delete 删除模板
 func (h *TemplateHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	err := h.service.Delete(c.Request.Context(), id)
+	err := h.service.Delete(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := templateErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "删除模板失败",
 			Errors: err.Error(),
 		})
@@ -149,6 +160,25 @@ func (h *TemplateHandler) Delete(c *gin.Context) {
 	})
 }
 
+// RenderPreview 重新渲染模板预览
+func (h *TemplateHandler) RenderPreview(c *gin.Context) {
+	resp, err := h.service.RenderPreview(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"))
+	if err != nil {
+		status := templateErrorStatus(err, http.StatusBadRequest)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "提交预览渲染失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "预览渲染已提交",
+		Data:    resp,
+	})
+}
+
 // Apply 应用模板
 func (h *TemplateHandler) Apply(c *gin.Context) {
 	id := c.Param("id")
@@ -161,10 +191,19 @@ func (h *TemplateHandler) Apply(c *gin.Context) {
 		})
 		return
 	}
-	resp, err := h.service.Apply(c.Request.Context(), id, req)
+	resp, err := h.service.Apply(c.Request.Context(), id, c.GetString("user_id"), c.GetString("user_role"), req)
+	if errors.Is(err, template.ErrInvalidValues) {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "应用模板失败",
+			Errors: err.Error(),
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := templateErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "应用模板失败",
 			Errors: err.Error(),
 		})
@@ -175,4 +214,212 @@ func (h *TemplateHandler) Apply(c *gin.Context) {
 		Message: "模板应用成功",
 		Data:    resp,
 	})
-} 
\ No newline at end of file
+}
+
+// Rate 对模板评分和评价
+func (h *TemplateHandler) Rate(c *gin.Context) {
+	var req dto.RateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	rating, err := h.service.Rate(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "评分失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "评分成功",
+		Data:    rating,
+	})
+}
+
+// GetMyRating 获取当前用户对模板的评分
+func (h *TemplateHandler) GetMyRating(c *gin.Context) {
+	rating, err := h.service.GetMyRating(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Code:    404,
+			Message: "获取评分失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取评分成功",
+		Data:    rating,
+	})
+}
+
+// DeleteRating 撤销当前用户的评分
+func (h *TemplateHandler) DeleteRating(c *gin.Context) {
+	if err := h.service.DeleteRating(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "撤销评分失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "评分已撤销",
+	})
+}
+
+// ListRatings 获取模板评价列表
+func (h *TemplateHandler) ListRatings(c *gin.Context) {
+	var req dto.ListTemplateRatingsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	ratings, total, err := h.service.ListRatings(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"), req)
+	if err != nil {
+		status := templateErrorStatus(err, http.StatusInternalServerError)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "获取评价列表失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取评价列表成功",
+		Data: dto.ListTemplateRatingsResponse{
+			Ratings:  ratings,
+			Total:    total,
+			Page:     req.Page,
+			PageSize: req.PageSize,
+		},
+	})
+}
+
+// ListMine 我创建的模板
+func (h *TemplateHandler) ListMine(c *gin.Context) {
+	h.listByStatus(c, func(req dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
+		return h.service.ListMine(c.Request.Context(), c.GetString("user_id"), req)
+	})
+}
+
+// ListForReview 管理员查看待审核模板
+func (h *TemplateHandler) ListForReview(c *gin.Context) {
+	h.listByStatus(c, func(req dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
+		return h.service.ListForReview(c.Request.Context(), req)
+	})
+}
+
+func (h *TemplateHandler) listByStatus(c *gin.Context, list func(dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error)) {
+	var req dto.ListMyTemplatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	templates, total, err := list(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Code:    500,
+			Message: "获取模板列表失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取模板列表成功",
+		Data: dto.ListTemplatesResponse{
+			Templates: templates,
+			Total:     total,
+			Page:      req.Page,
+			PageSize:  req.PageSize,
+		},
+	})
+}
+
+// Submit 提交模板审核
+func (h *TemplateHandler) Submit(c *gin.Context) {
+	template, err := h.service.Submit(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("user_role"))
+	if err != nil {
+		status := templateErrorStatus(err, http.StatusBadRequest)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "提交审核失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "已提交审核",
+		Data:    template,
+	})
+}
+
+// Review 管理员审核模板
+func (h *TemplateHandler) Review(c *gin.Context) {
+	var req dto.ReviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	template, err := h.service.Review(c.Request.Context(), c.Param("id"), c.GetString("user_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "审核模板失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "审核完成",
+		Data:    template,
+	})
+}
+
+// templateErrorStatus 无权操作返回 403，模板不存在或不可见返回 404，其余按 fallback
+func templateErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, template.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, template.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return fallback
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/vo"
+	"comic_video/internal/service/media"
+	"comic_video/internal/service/upload"
+	"comic_video/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	service *upload.Service
+}
+
+func NewUploadHandler(service *upload.Service) *UploadHandler {
+	return &UploadHandler{service: service}
+}
+
+// Init 发起分片上传
+func (h *UploadHandler) Init(c *gin.Context) {
+	var req dto.InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	session, err := h.service.Init(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "发起上传失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, vo.SuccessResponse{
+		Code:    201,
+		Message: "上传会话已创建",
+		Data:    session,
+	})
+}
+
+// InitDirect 发起直传，返回直传存储的签名表单
+func (h *UploadHandler) InitDirect(c *gin.Context) {
+	var req dto.InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+	session, err := h.service.InitDirect(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "发起直传失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, vo.SuccessResponse{
+		Code:    201,
+		Message: "直传地址已生成",
+		Data:    session,
+	})
+}
+
+// Get 获取上传会话及已上传的分片
+func (h *UploadHandler) Get(c *gin.Context) {
+	session, err := h.service.Get(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "获取上传会话失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取上传会话成功",
+		Data:    session,
+	})
+}
+
+// UploadPart 上传分片，请求体为分片原始字节，可在 X-Content-SHA256 头中携带分片的 SHA-256
+func (h *UploadHandler) UploadPart(c *gin.Context) {
+	partNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "无效的分片编号",
+		})
+		return
+	}
+	if c.Request.ContentLength <= 0 {
+		c.JSON(http.StatusLengthRequired, vo.ErrorResponse{
+			Code:    411,
+			Message: "需要 Content-Length",
+		})
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, c.Request.ContentLength)
+	part, err := h.service.UploadPart(c.Request.Context(), c.GetString("user_id"), c.Param("id"),
+		partNumber, body, c.Request.ContentLength, c.GetHeader("X-Content-SHA256"))
+	if err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "上传分片失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "分片上传成功",
+		Data:    part,
+	})
+}
+
+// Complete 合并分片或确认直传文件，创建视频或素材
+func (h *UploadHandler) Complete(c *gin.Context) {
+	session, err := h.service.Complete(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "完成上传失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "上传处理中",
+		Data:    session,
+	})
+}
+
+// Abort 取消上传
+func (h *UploadHandler) Abort(c *gin.Context) {
+	if err := h.service.Abort(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		status := uploadErrorStatus(err)
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
+			Message: "取消上传失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "上传已取消",
+	})
+}
+
+// uploadErrorStatus 将分片上传错误映射为 HTTP 状态码
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, upload.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, upload.ErrInvalidParams), errors.Is(err, upload.ErrInvalidPart):
+		return http.StatusBadRequest
+	case errors.Is(err, upload.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, upload.ErrTypeNotAllowed):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, upload.ErrIncomplete), errors.Is(err, upload.ErrSessionClosed):
+		return http.StatusConflict
+	case errors.Is(err, upload.ErrChecksumMismatch), errors.Is(err, media.ErrUnsupported):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
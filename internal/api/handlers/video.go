@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/vo"
+	"comic_video/internal/repository/redis"
+	"comic_video/internal/service/ai"
+	"comic_video/internal/service/media"
 	"comic_video/internal/service/video"
 	"comic_video/internal/utils"
 
@@ -12,11 +18,13 @@ import (
 )
 
 type VideoHandler struct {
-	service *video.Service
+	service     *video.Service
+	redisClient *redis.Client
+	queue       ai.TaskQueue
 }
 
-func NewVideoHandler(service *video.Service) *VideoHandler {
-	return &VideoHandler{service: service}
+func NewVideoHandler(service *video.Service, redisClient *redis.Client, queue ai.TaskQueue) *VideoHandler {
+	return &VideoHandler{service: service, redisClient: redisClient, queue: queue}
 }
 
 // List 获取视频列表
@@ -87,8 +95,12 @@ func (h *VideoHandler) Upload(c *gin.Context) {
 
 	video, err := h.service.Upload(c.Request.Context(), userID, req, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		status := http.StatusInternalServerError
+		if errors.Is(err, media.ErrUnsupported) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, vo.ErrorResponse{
+			Code:    status,
 			Message: "上传视频失败",
 			Errors: err.Error(),
 		})
@@ -182,23 +194,55 @@ func (h *VideoHandler) Process(c *gin.Context) {
 	id := c.Param("id")
 	userID := c.GetString("user_id")
 
-	err := h.service.Process(c.Request.Context(), id, userID)
+	var req dto.ProcessVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors:  utils.ValidateErrors(err),
+		})
+		return
+	}
+
+	err := h.service.Process(c.Request.Context(), id, userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
-			Code:    500,
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
 			Message: "处理视频失败",
 			Errors: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, vo.SuccessResponse{
-		Code:    200,
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
 		Message: "视频处理已开始",
 		Data:    nil,
 	})
 }
 
+// GeneratePreviews 重新生成视频封面、胶片条和波形
+func (h *VideoHandler) GeneratePreviews(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	video, err := h.service.GeneratePreviews(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "生成预览失败",
+			Errors:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "预览生成已开始",
+		Data:    video,
+	})
+}
+
 // GetStatus 获取视频处理状态
 func (h *VideoHandler) GetStatus(c *gin.Context) {
 	id := c.Param("id")
@@ -217,6 +261,81 @@ func (h *VideoHandler) GetStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, vo.SuccessResponse{
 		Code:    200,
 		Message: "获取状态成功",
-		Data:    gin.H{"status": status},
+		Data:    status,
+	})
+}
+
+// Transcribe 提交视频语音识别任务，进度通过任务接口查询
+func (h *VideoHandler) Transcribe(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+	var req dto.TranscribeVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "请求参数错误",
+			Errors: utils.ValidateErrors(err),
+		})
+		return
+	}
+
+	task, err := h.service.NewTranscribeTask(c.Request.Context(), id, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Code:    400,
+			Message: "提交语音识别失败",
+			Errors: err.Error(),
+		})
+		return
+	}
+	_ = h.redisClient.SetTaskStatus(c.Request.Context(), task, 24*time.Hour)
+	_ = h.queue.Enqueue(task)
+
+	c.JSON(http.StatusAccepted, vo.SuccessResponse{
+		Code:    202,
+		Message: "语音识别任务已提交",
+		Data:    gin.H{"task_id": task.ID},
+	})
+}
+
+// GetTranscript 获取视频识别结果
+func (h *VideoHandler) GetTranscript(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	transcript, err := h.service.GetTranscript(c.Request.Context(), id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Code:    404,
+			Message: "识别结果不存在",
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, vo.SuccessResponse{
+		Code:    200,
+		Message: "获取识别结果成功",
+		Data:    transcript,
 	})
-} 
\ No newline at end of file
+}
+
+// GetSubtitles 下载字幕文件，format=srt|vtt
+func (h *VideoHandler) GetSubtitles(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+	format := c.DefaultQuery("format", "srt")
+
+	content, contentType, err := h.service.GetSubtitles(c.Request.Context(), id, userID, format)
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Code:    404,
+			Message: "获取字幕失败",
+			Errors: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+id+"."+format)
+	c.Data(http.StatusOK, contentType, []byte(content))
+}
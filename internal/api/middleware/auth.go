@@ -37,7 +37,7 @@ func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// 验证令牌
-		userID, err := authService.ValidateToken(c.Request.Context(), token)
+		userID, role, err := authService.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
@@ -47,8 +47,9 @@ func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 			return
 		}
 
-		// 将用户ID存储到上下文中
+		// 将用户ID和角色存储到上下文中，角色供 RoleMiddleware 及业务层权限判断使用
 		c.Set("user_id", userID)
+		c.Set("user_role", role)
 		c.Next()
 	}
 }
@@ -73,7 +74,7 @@ func OptionalAuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// 验证令牌
-		userID, err := authService.ValidateToken(c.Request.Context(), token)
+		userID, role, err := authService.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.Next()
 			return
@@ -81,6 +82,7 @@ func OptionalAuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 
 		// 将用户ID存储到上下文中
 		c.Set("user_id", userID)
+		c.Set("user_role", role)
 		c.Next()
 	}
 }
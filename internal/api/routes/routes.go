@@ -5,12 +5,16 @@ import (
 	"comic_video/internal/api/middleware"
 	"comic_video/internal/service/auth"
 	"comic_video/internal/service/project"
+	"comic_video/internal/service/prompt"
+	"comic_video/internal/service/storyboard"
 	"comic_video/internal/service/template"
+	"comic_video/internal/service/upload"
 	"comic_video/internal/service/user"
 	"comic_video/internal/service/video"
 	"comic_video/internal/service/render"
 	"comic_video/internal/service/material"
 	"comic_video/internal/repository/redis"
+	"comic_video/internal/repository/storage"
 	"comic_video/internal/service/ai"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +29,10 @@ func SetupRoutes(
 	renderService render.Service,
 	projectService *project.Service,
 	materialService *material.Service,
+	promptService *prompt.Service,
+	storyboardService *storyboard.Service,
+	uploadService *upload.Service,
+	localStorage *storage.Local, // 本地存储时由 API 提供文件下载和直传，使用 MinIO 时为 nil
 	redisClient *redis.Client, // 新增参数
 	taskQueue ai.TaskQueue, // 新增参数
 ) *gin.Engine {
@@ -53,10 +61,11 @@ func SetupRoutes(
 	users := v1.Group("/users")
 	users.Use(middleware.AuthMiddleware(authService))
 	{
-		users.GET("/", userHandler.List)
+		// 列表、修改（含角色和状态）和删除仅管理员可用，本人资料通过 /auth/profile 修改
+		users.GET("/", middleware.RoleMiddleware("admin"), userHandler.List)
 		users.GET("/:id", userHandler.GetByID)
-		users.PUT("/:id", userHandler.Update)
-		users.DELETE("/:id", userHandler.Delete)
+		users.PUT("/:id", middleware.RoleMiddleware("admin"), userHandler.Update)
+		users.DELETE("/:id", middleware.RoleMiddleware("admin"), userHandler.Delete)
 	}
 
 	// 项目相关路由
@@ -77,7 +86,7 @@ func SetupRoutes(
 	v1.POST("/share/cancel/:share_id", middleware.AuthMiddleware(authService), projectHandler.CancelShare) // 取消分享（需登录）
 
 	// 视频相关路由
-	videoHandler := handlers.NewVideoHandler(videoService)
+	videoHandler := handlers.NewVideoHandler(videoService, redisClient, taskQueue)
 	videos := v1.Group("/videos")
 	videos.Use(middleware.AuthMiddleware(authService))
 	{
@@ -88,6 +97,10 @@ func SetupRoutes(
 		videos.DELETE("/:id", videoHandler.Delete)
 		videos.POST("/:id/process", videoHandler.Process)
 		videos.GET("/:id/status", videoHandler.GetStatus)
+		videos.POST("/:id/previews", videoHandler.GeneratePreviews)
+		videos.POST("/:id/transcribe", videoHandler.Transcribe)
+		videos.GET("/:id/transcript", videoHandler.GetTranscript)
+		videos.GET("/:id/subtitles", videoHandler.GetSubtitles)
 	}
 
 	// 模板相关路由
@@ -95,15 +108,31 @@ func SetupRoutes(
 	templates := v1.Group("/templates")
 	{
 		templates.GET("/", templateHandler.List)
-		templates.GET("/:id", templateHandler.GetByID)
+		templates.GET("/trending", templateHandler.Trending)
+		templates.GET("/mine", middleware.AuthMiddleware(authService), templateHandler.ListMine)
+		templates.GET("/:id", middleware.OptionalAuthMiddleware(authService), templateHandler.GetByID)
+		templates.GET("/:id/ratings", middleware.OptionalAuthMiddleware(authService), templateHandler.ListRatings)
+		templates.GET("/:id/ratings/me", middleware.AuthMiddleware(authService), templateHandler.GetMyRating)
+		templates.PUT("/:id/ratings/me", middleware.AuthMiddleware(authService), templateHandler.Rate)
+		templates.DELETE("/:id/ratings/me", middleware.AuthMiddleware(authService), templateHandler.DeleteRating)
 		templates.POST("/", middleware.AuthMiddleware(authService), templateHandler.Create)
 		templates.PUT("/:id", middleware.AuthMiddleware(authService), templateHandler.Update)
 		templates.DELETE("/:id", middleware.AuthMiddleware(authService), templateHandler.Delete)
 		templates.POST("/:id/apply", middleware.AuthMiddleware(authService), templateHandler.Apply)
+		templates.POST("/:id/preview", middleware.AuthMiddleware(authService), templateHandler.RenderPreview)
+		templates.POST("/:id/submit", middleware.AuthMiddleware(authService), templateHandler.Submit)
+	}
+
+	// 模板审核（管理员）
+	adminTemplates := v1.Group("/admin/templates")
+	adminTemplates.Use(middleware.AuthMiddleware(authService), middleware.RoleMiddleware("admin"))
+	{
+		adminTemplates.GET("/", templateHandler.ListForReview)
+		adminTemplates.POST("/:id/review", templateHandler.Review)
 	}
 
 	// 渲染相关路由
-	renderHandler := handlers.NewRenderHandler(renderService)
+	renderHandler := handlers.NewRenderHandler(renderService, projectService)
 	renders := v1.Group("/renders")
 	renders.Use(middleware.AuthMiddleware(authService))
 	{
@@ -114,16 +143,40 @@ func SetupRoutes(
 		renders.GET("/:id/status", renderHandler.GetRenderStatus)
 		renders.GET("/:id/download", renderHandler.DownloadRender)
 	}
+	// 播放列表允许持有项目分享 token 的匿名观看者访问
+	v1.GET("/renders/:id/playback/:format/*name", middleware.OptionalAuthMiddleware(authService), renderHandler.Playback)
 
 	// 素材相关路由
 	materialHandler := handlers.NewMaterialHandler(materialService)
 	materials := v1.Group("/materials")
 	{
-		materials.GET("/", materialHandler.List)
-		materials.GET("/:id", materialHandler.GetByID)
+		materials.GET("/", middleware.OptionalAuthMiddleware(authService), materialHandler.List)
+		materials.GET("/:id", middleware.OptionalAuthMiddleware(authService), materialHandler.GetByID)
 		materials.POST("/upload", middleware.AuthMiddleware(authService), materialHandler.Upload)
 		materials.PUT("/:id", middleware.AuthMiddleware(authService), materialHandler.Update)
 		materials.DELETE("/:id", middleware.AuthMiddleware(authService), materialHandler.Delete)
+		materials.POST("/:id/previews", middleware.AuthMiddleware(authService), materialHandler.GeneratePreviews)
+	}
+
+	// 分片上传与直传：大文件断点续传或由客户端直接上传到存储，完成后创建视频或素材
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	uploads := v1.Group("/uploads")
+	uploads.Use(middleware.AuthMiddleware(authService))
+	{
+		uploads.POST("/", uploadHandler.Init)
+		uploads.POST("/direct", uploadHandler.InitDirect)
+		uploads.GET("/:id", uploadHandler.Get)
+		uploads.PUT("/:id/parts/:number", uploadHandler.UploadPart)
+		uploads.POST("/:id/complete", uploadHandler.Complete)
+		uploads.DELETE("/:id", uploadHandler.Abort)
+	}
+
+	// 本地存储：签名地址下载和直传表单，地址前缀见 storage.LocalRoutePrefix
+	if localStorage != nil {
+		storageHandler := handlers.NewStorageHandler(localStorage)
+		v1.GET("/storage/:bucket/*object", storageHandler.Get)
+		v1.HEAD("/storage/:bucket/*object", storageHandler.Get)
+		v1.POST("/storage/:bucket", storageHandler.Post)
 	}
 
 	// 通用任务进度查询API
@@ -132,9 +185,37 @@ func SetupRoutes(
 
 	// AI 相关路由
 	aiHandler := handlers.NewAIHandler(redisClient, taskQueue)
-	v1.POST("/ai/novel-to-video", aiHandler.NovelToVideo)
+	v1.POST("/ai/novel-to-video", middleware.OptionalAuthMiddleware(authService), aiHandler.NovelToVideo)
 	v1.POST("/ai/generate-novel", aiHandler.GenerateNovel)
-	v1.POST("/ai/novel-to-all", aiHandler.NovelToAll)
+	v1.POST("/ai/novel-to-all", middleware.OptionalAuthMiddleware(authService), aiHandler.NovelToAll)
+	v1.POST("/ai/chat/stream", middleware.AuthMiddleware(authService), aiHandler.StreamChat)
+	v1.GET("/ai/providers", middleware.AuthMiddleware(authService), middleware.RoleMiddleware("admin"), aiHandler.ListProviders)
+	v1.POST("/ai/voices/preview", middleware.AuthMiddleware(authService), aiHandler.PreviewVoice)
+
+	// 分镜审阅：逐格修改、重绘、排序后只重新合成视频
+	storyboardHandler := handlers.NewStoryboardHandler(storyboardService, redisClient, taskQueue)
+	v1.GET("/ai/tasks/:id/panels", storyboardHandler.Get)
+	// 修改分镜稿需登录，只有提交生成任务的用户和管理员可以操作
+	v1.PUT("/ai/tasks/:id/panels/order", middleware.AuthMiddleware(authService), storyboardHandler.Reorder)
+	v1.PUT("/ai/tasks/:id/panels/:panel_id", middleware.AuthMiddleware(authService), storyboardHandler.UpdatePanel)
+	v1.DELETE("/ai/tasks/:id/panels/:panel_id", middleware.AuthMiddleware(authService), storyboardHandler.DeletePanel)
+	v1.POST("/ai/tasks/:id/panels/:panel_id/regenerate", middleware.AuthMiddleware(authService), storyboardHandler.Regenerate)
+	v1.POST("/ai/tasks/:id/compose", middleware.AuthMiddleware(authService), storyboardHandler.Compose)
+	v1.POST("/ai/tasks/:id/project", middleware.AuthMiddleware(authService), storyboardHandler.ToProject)
+
+	// 提示词模板管理（管理员）
+	promptHandler := handlers.NewPromptHandler(promptService)
+	prompts := v1.Group("/admin/prompts")
+	prompts.Use(middleware.AuthMiddleware(authService), middleware.RoleMiddleware("admin"))
+	{
+		prompts.GET("/", promptHandler.List)
+		prompts.POST("/", promptHandler.Create)
+		prompts.POST("/preview", promptHandler.Preview)
+		prompts.GET("/:id", promptHandler.GetByID)
+		prompts.PUT("/:id", promptHandler.Update)
+		prompts.POST("/by-name/:name/ab-test", promptHandler.StartABTest)
+		prompts.GET("/by-name/:name/stats", promptHandler.Stats)
+	}
 
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -14,8 +15,11 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
 	MinIO    MinIOConfig    `mapstructure:"minio"`
+	Storage  StorageConfig  `mapstructure:"storage"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	Admin    AdminConfig    `mapstructure:"admin"`
 	AI       AIConfig       `mapstructure:"ai"`
+	Upload   UploadConfig   `mapstructure:"upload"`
 }
 
 // ServerConfig 服务器配置
@@ -49,6 +53,24 @@ type MinIOConfig struct {
 	SecretAccessKey string `mapstructure:"secret_access_key"`
 	UseSSL          bool   `mapstructure:"use_ssl"`
 	BucketName      string `mapstructure:"bucket_name"`
+	PublicHost      string `mapstructure:"public_host"` // 外部可访问地址，如 https://cdn.example.com，用于文件链接和直传
+}
+
+// StorageConfig 对象存储配置，Driver 为 minio 时使用 MinIOConfig
+// 为 local 时文件保存在本地目录，由 API 通过签名地址提供下载和直传，便于单机开发和集成测试
+type StorageConfig struct {
+	Driver     string `mapstructure:"driver"`      // minio / local
+	LocalRoot  string `mapstructure:"local_root"`  // 本地存储根目录
+	PublicURL  string `mapstructure:"public_url"`  // API 外部可访问地址，用于拼接本地存储的签名地址
+	SigningKey string `mapstructure:"signing_key"` // 本地存储签名密钥，为空时使用 JWT 密钥
+}
+
+// UploadConfig 分片上传配置
+type UploadConfig struct {
+	MaxVideoSizeMB    int // 视频单文件上限
+	MaxMaterialSizeMB int // 素材单文件上限
+	ChunkSizeMB       int // 分片大小，除最后一片外不小于 5MB
+	SessionTTLHours   int // 上传会话无活动后的过期时间，过期后由定时任务清理分片
 }
 
 // JWTConfig JWT配置
@@ -57,6 +79,11 @@ type JWTConfig struct {
 	Expire    int    `mapstructure:"expire"`
 }
 
+// AdminConfig 管理员配置
+type AdminConfig struct {
+	Emails []string // 启动时设为管理员的用户邮箱，用于创建首个管理员
+}
+
 // AIConfig AI配置
 type AIConfig struct {
 	SDEndpoint      string
@@ -65,6 +92,38 @@ type AIConfig struct {
 	OllamaApiKey    string // 新增
 	WhisperEndpoint string
 	TTSEndpoint     string
+
+	// OpenAI 兼容服务（OpenAI、vLLM、LocalAI、llama.cpp server 等），Endpoint 为空时不注册
+	OpenAIEndpoint   string
+	OpenAIApiKey     string
+	OpenAIModel      string
+	OpenAIImageModel string
+	OpenAITTSModel   string
+	OpenAITTSVoice   string
+	OpenAIASRModel   string
+
+	// 各项能力默认使用的提供方名称与模型，任务可按需覆盖
+	TextGenProvider    string // ollama/openai
+	TextGenModel       string
+	ImageGenProvider   string // sd/openai
+	ImageGenModel      string
+	TTSProvider        string // edge/openai
+	TTSModel           string
+	Audio2TextProvider string // whisper/openai
+	Audio2TextModel    string
+
+	// 各项能力的默认调用参数（JSON对象），如 {"steps":20,"width":768}
+	TextGenOptions    string
+	ImageGenOptions   string
+	TTSOptions        string
+	Audio2TextOptions string
+
+	// 各提供方的HTTP调用策略（JSON对象，键为提供方名），如 {"sd":{"timeout_sec":300,"max_in_flight":1}}
+	HTTPOptions string
+
+	// 生成结果缓存（文本、图片、配音），索引在Redis，结果在MinIO
+	CacheEnabled  bool
+	CacheTTLHours int
 }
 
 // Load 加载配置
@@ -101,11 +160,21 @@ func Load() *Config {
 			SecretAccessKey: getEnv("MINIO_SECRET_KEY", "minioadmin123"),
 			UseSSL:          getEnvAsBool("MINIO_USE_SSL", false),
 			BucketName:      getEnv("MINIO_BUCKET_NAME", "comic-video"),
+			PublicHost:      getEnv("MINIO_PUBLIC_HOST", ""),
+		},
+		Storage: StorageConfig{
+			Driver:     getEnv("STORAGE_DRIVER", "minio"),
+			LocalRoot:  getEnv("STORAGE_LOCAL_ROOT", "./data/storage"),
+			PublicURL:  getEnv("STORAGE_PUBLIC_URL", "http://localhost:8080"),
+			SigningKey: getEnv("STORAGE_SIGNING_KEY", ""),
 		},
 		JWT: JWTConfig{
 			SecretKey: getEnv("JWT_SECRET_KEY", "your-secret-key"),
 			Expire:    getEnvAsInt("JWT_EXPIRE", 24*60*60), // 24小时
 		},
+		Admin: AdminConfig{
+			Emails: getEnvAsList("ADMIN_EMAILS"),
+		},
 		AI: AIConfig{
 			SDEndpoint:      getEnv("SD_ENDPOINT", "http://127.0.0.1:7860"),
 			OllamaEndpoint:  getEnv("OLLAMA_ENDPOINT", "http://127.0.0.1:11434"),
@@ -113,6 +182,39 @@ func Load() *Config {
 			OllamaApiKey:    getEnv("OLLAMA_API_KEY", ""),
 			WhisperEndpoint: getEnv("WHISPER_ENDPOINT", "http://127.0.0.1:9000"),
 			TTSEndpoint:     getEnv("TTS_ENDPOINT", "http://127.0.0.1:50021"),
+
+			OpenAIEndpoint:   getEnv("OPENAI_ENDPOINT", ""),
+			OpenAIApiKey:     getEnv("OPENAI_API_KEY", ""),
+			OpenAIModel:      getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+			OpenAIImageModel: getEnv("OPENAI_IMAGE_MODEL", "dall-e-3"),
+			OpenAITTSModel:   getEnv("OPENAI_TTS_MODEL", "tts-1"),
+			OpenAITTSVoice:   getEnv("OPENAI_TTS_VOICE", "alloy"),
+			OpenAIASRModel:   getEnv("OPENAI_ASR_MODEL", "whisper-1"),
+
+			TextGenProvider:    getEnv("AI_TEXTGEN_PROVIDER", "ollama"),
+			TextGenModel:       getEnv("AI_TEXTGEN_MODEL", ""),
+			ImageGenProvider:   getEnv("AI_IMAGEGEN_PROVIDER", "sd"),
+			ImageGenModel:      getEnv("AI_IMAGEGEN_MODEL", ""),
+			TTSProvider:        getEnv("AI_TTS_PROVIDER", "edge"),
+			TTSModel:           getEnv("AI_TTS_MODEL", ""),
+			Audio2TextProvider: getEnv("AI_AUDIO2TEXT_PROVIDER", "whisper"),
+			Audio2TextModel:    getEnv("AI_AUDIO2TEXT_MODEL", ""),
+
+			TextGenOptions:    getEnv("AI_TEXTGEN_OPTIONS", ""),
+			ImageGenOptions:   getEnv("AI_IMAGEGEN_OPTIONS", ""),
+			TTSOptions:        getEnv("AI_TTS_OPTIONS", ""),
+			Audio2TextOptions: getEnv("AI_AUDIO2TEXT_OPTIONS", ""),
+
+			HTTPOptions: getEnv("AI_HTTP_OPTIONS", ""),
+
+			CacheEnabled:  getEnvAsBool("AI_CACHE_ENABLED", true),
+			CacheTTLHours: getEnvAsInt("AI_CACHE_TTL_HOURS", 720),
+		},
+		Upload: UploadConfig{
+			MaxVideoSizeMB:    getEnvAsInt("UPLOAD_MAX_VIDEO_MB", 10240),
+			MaxMaterialSizeMB: getEnvAsInt("UPLOAD_MAX_MATERIAL_MB", 2048),
+			ChunkSizeMB:       getEnvAsInt("UPLOAD_CHUNK_MB", 8),
+			SessionTTLHours:   getEnvAsInt("UPLOAD_SESSION_TTL_HOURS", 24),
 		},
 	}
 
@@ -139,6 +241,15 @@ func setDefaults() {
 	viper.SetDefault("ai.ollama_api_key", "")
 	viper.SetDefault("ai.whisper_endpoint", "http://127.0.0.1:9000")
 	viper.SetDefault("ai.tts_endpoint", "http://127.0.0.1:50021")
+	viper.SetDefault("ai.openai_model", "gpt-4o-mini")
+	viper.SetDefault("ai.openai_image_model", "dall-e-3")
+	viper.SetDefault("ai.openai_tts_model", "tts-1")
+	viper.SetDefault("ai.openai_tts_voice", "alloy")
+	viper.SetDefault("ai.openai_asr_model", "whisper-1")
+	viper.SetDefault("ai.textgen_provider", "ollama")
+	viper.SetDefault("ai.imagegen_provider", "sd")
+	viper.SetDefault("ai.tts_provider", "edge")
+	viper.SetDefault("ai.audio2text_provider", "whisper")
 }
 
 // getEnv 获取环境变量，如果不存在则返回默认值
@@ -167,4 +278,15 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
-} 
\ No newline at end of file
+}
+
+// getEnvAsList 获取逗号分隔的环境变量，忽略空项
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
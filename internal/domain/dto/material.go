@@ -36,14 +36,20 @@ type MaterialResponse struct {
 	Type        string    `json:"type"`
 	FileName    string    `json:"file_name"`
 	FilePath    string    `json:"file_path"`
+	ContentHash string    `json:"content_hash"` // 文件内容的 SHA-256
 	FileSize    int64     `json:"file_size"`
 	Duration    float64   `json:"duration"`
 	Width       int       `json:"width"`
 	Height      int       `json:"height"`
 	Format      string    `json:"format"`
 	Thumbnail   string    `json:"thumbnail"`
+	Filmstrip     string  `json:"filmstrip"`
+	FilmstripVTT  string  `json:"filmstrip_vtt"`
+	Waveform      string  `json:"waveform"`
+	PreviewStatus string  `json:"preview_status"`
 	Tags        string    `json:"tags"`
 	IsPublic    bool      `json:"is_public"`
+	OwnerID     *uuid.UUID `json:"owner_id"`
 	IsPremium   bool      `json:"is_premium"`
 	DownloadCount int     `json:"download_count"`
 	Rating      float64   `json:"rating"`
@@ -58,9 +64,16 @@ type ListMaterialsRequest struct {
 	PageSize int    `form:"page_size" binding:"min=1,max=100"`
 	Category string `form:"category"`
 	Type     string `form:"type"`
-	Keyword  string `form:"keyword"`
+	Format   string `form:"format"`
+	Keyword  string `form:"keyword"` // 全文检索名称、描述和标签
+	Tags     string `form:"tags"`    // 逗号分隔，须同时带有全部标签
+	Duration   string `form:"duration" binding:"omitempty,oneof=short medium long extended"`
+	Resolution string `form:"resolution" binding:"omitempty,oneof=sd hd fhd qhd uhd"`
+	Sort       string `form:"sort" binding:"omitempty,oneof=latest rating downloads relevance"`
+	Facets     bool   `form:"facets"` // 同时返回分面统计
 	IsPublic *bool  `form:"is_public"`
 	IsPremium *bool `form:"is_premium"`
+	Mine     bool   `form:"mine"` // 只看自己上传的素材
 }
 
 // ListMaterialsResponse 素材列表响应
@@ -69,4 +82,19 @@ type ListMaterialsResponse struct {
 	Total     int64              `json:"total"`
 	Page      int                `json:"page"`
 	PageSize  int                `json:"page_size"`
+	Facets    *MaterialFacets    `json:"facets,omitempty"`
+}
+
+// MaterialFacets 素材搜索结果的分面统计
+type MaterialFacets struct {
+	Category   []FacetCount `json:"category"`
+	Format     []FacetCount `json:"format"`
+	Duration   []FacetCount `json:"duration"`
+	Resolution []FacetCount `json:"resolution"`
+}
+
+// FacetCount 分面取值及素材数量
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
 } 
\ No newline at end of file
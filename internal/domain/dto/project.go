@@ -27,6 +27,7 @@ type ProjectResponse struct {
 	Description string                 `json:"description"`
 	UserID      uuid.UUID              `json:"user_id"`
 	Status      string                 `json:"status"`
+	Thumbnail   string                 `json:"thumbnail"` // 封面访问地址，记录中保存对象名，构建响应时转换
 	Settings    map[string]interface{} `json:"settings"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatePromptRequest 新建提示词版本请求，版本号自动递增
+type CreatePromptRequest struct {
+	Name        string                 `json:"name" binding:"required,max=64"`
+	Description string                 `json:"description"`
+	System      string                 `json:"system"`
+	Content     string                 `json:"content" binding:"required"`
+	Variables   []string               `json:"variables"`
+	ModelParams map[string]interface{} `json:"model_params"`
+	Status      string                 `json:"status" binding:"omitempty,oneof=draft active"`
+	Weight      *int                   `json:"weight" binding:"omitempty,min=0"`
+}
+
+// UpdatePromptRequest 更新提示词请求，内容仅草稿可改
+type UpdatePromptRequest struct {
+	Description *string                `json:"description"`
+	System      *string                `json:"system"`
+	Content     *string                `json:"content"`
+	Variables   []string               `json:"variables"`
+	ModelParams map[string]interface{} `json:"model_params"`
+	Status      string                 `json:"status" binding:"omitempty,oneof=draft active archived"`
+	Weight      *int                   `json:"weight" binding:"omitempty,min=0"`
+}
+
+// PromptABTestRequest A/B 测试请求，两个版本按权重分流
+type PromptABTestRequest struct {
+	VersionA int `json:"version_a" binding:"required,min=1"`
+	VersionB int `json:"version_b" binding:"required,min=1,nefield=VersionA"`
+	WeightA  int `json:"weight_a" binding:"min=0"`
+	WeightB  int `json:"weight_b" binding:"min=0"`
+}
+
+// PreviewPromptRequest 提示词预览请求
+type PreviewPromptRequest struct {
+	System    string                 `json:"system"`
+	Content   string                 `json:"content" binding:"required"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// PromptResponse 提示词模板响应
+type PromptResponse struct {
+	ID          uuid.UUID              `json:"id"`
+	Name        string                 `json:"name"`
+	Version     int                    `json:"version"`
+	Description string                 `json:"description"`
+	System      string                 `json:"system"`
+	Content     string                 `json:"content"`
+	Variables   []string               `json:"variables"`
+	ModelParams map[string]interface{} `json:"model_params"`
+	Status      string                 `json:"status"`
+	Weight      int                    `json:"weight"`
+	CreatedBy   *uuid.UUID             `json:"created_by"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// PromptVersionStats 单个版本的使用与任务成败统计
+type PromptVersionStats struct {
+	Version     int     `json:"version"` // 0 表示内置提示词
+	Status      string  `json:"status"`
+	Weight      int     `json:"weight"`
+	Uses        int64   `json:"uses"`
+	Completed   int64   `json:"completed"`
+	Failed      int64   `json:"failed"`
+	SuccessRate float64 `json:"success_rate"` // 已结束任务中的成功比例
+}
+
+// PromptStatsResponse 提示词各版本效果统计
+type PromptStatsResponse struct {
+	Name     string               `json:"name"`
+	Versions []PromptVersionStats `json:"versions"`
+}
@@ -13,6 +13,7 @@ type CreateRenderRequest struct {
 	Quality    string    `json:"quality" binding:"oneof=high medium low"`
 	Format     string    `json:"format"`
 	Resolution string    `json:"resolution"`
+	Streaming  []string  `json:"streaming" binding:"omitempty,dive,oneof=hls dash"` // 额外输出的自适应码率格式
 }
 
 // RenderResponse 渲染任务详情响应
@@ -30,6 +31,9 @@ type RenderResponse struct {
 	Format      string     `json:"format"`
 	Quality     string     `json:"quality"`
 	Error       string     `json:"error"`
+	Streaming   []string   `json:"streaming,omitempty"`
+	HLSURL      string     `json:"hls_url,omitempty"`  // HLS 播放地址（经接口鉴权）
+	DASHURL     string     `json:"dash_url,omitempty"` // DASH 播放地址（经接口鉴权）
 	StartedAt   *time.Time `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at"`
 	CreatedAt   time.Time  `json:"created_at"`
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PanelDialogue 分镜台词
+type PanelDialogue struct {
+	Speaker string `json:"speaker"`
+	Line    string `json:"line"`
+}
+
+// UpdatePanelRequest 修改单格分镜，只更新传入的字段
+// 修改画面描述后图片标记为待重绘，需调用重绘接口生成新图
+type UpdatePanelRequest struct {
+	Scene     *string          `json:"scene"`
+	Narration *string          `json:"narration"`
+	Dialogues *[]PanelDialogue `json:"dialogues"`
+}
+
+// ReorderPanelsRequest 调整分镜顺序，需包含全部分镜ID
+type ReorderPanelsRequest struct {
+	PanelIDs []uuid.UUID `json:"panel_ids" binding:"required,min=1"`
+}
+
+// RegeneratePanelRequest 重绘单格分镜图片
+type RegeneratePanelRequest struct {
+	Seed *int64 `json:"seed"` // 为空时随机生成新 seed
+}
+
+// ComposeStoryboardRequest 按当前分镜重新合成视频
+type ComposeStoryboardRequest struct {
+	Voices json.RawMessage `json:"voices"` // 可选，替换角色音色，格式同 novel-to-video 的 voices
+}
+
+// StoryboardProjectRequest 将分镜稿转存为可编辑项目
+type StoryboardProjectRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Subtitles   *bool  `json:"subtitles"` // 是否按配音生成字幕轨道，默认开启
+}
+
+// StoryboardPanelResponse 单格分镜响应
+type StoryboardPanelResponse struct {
+	ID        uuid.UUID       `json:"id"`
+	Position  int             `json:"position"`
+	Scene     string          `json:"scene"`
+	Narration string          `json:"narration"`
+	Dialogues []PanelDialogue `json:"dialogues"`
+	Seed      int64           `json:"seed"`
+	ImageURL  string          `json:"image_url"`
+	Stale     bool            `json:"stale"`
+	Duration  float64         `json:"duration"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// StoryboardResponse 分镜稿响应
+type StoryboardResponse struct {
+	ID         uuid.UUID                  `json:"id"`
+	TaskID     uuid.UUID                  `json:"task_id"`
+	Voices     json.RawMessage            `json:"voices,omitempty"`
+	VideoURL   string                     `json:"video_url"` // 最近一次重新合成的视频，未合成过为空
+	AudioURL   string                     `json:"audio_url"`
+	AudioStale bool                       `json:"audio_stale"` // 为 true 时需重新合成后才能转存为项目
+	Panels     []*StoryboardPanelResponse `json:"panels"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	UpdatedAt  time.Time                  `json:"updated_at"`
+}
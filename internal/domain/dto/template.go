@@ -6,14 +6,12 @@ import (
 	"github.com/google/uuid"
 )
 
-// CreateTemplateRequest 创建模板请求
+// CreateTemplateRequest 创建模板请求，封面和预览动图由平台渲染，不接受客户端传入
 type CreateTemplateRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	Category    string `json:"category" binding:"required"`
 	Tags        string `json:"tags"`
-	Thumbnail   string `json:"thumbnail"`
-	Preview     string `json:"preview"`
 	Config      map[string]interface{} `json:"config" binding:"required"`
 	IsPublic    bool   `json:"is_public"`
 	IsPremium   bool   `json:"is_premium"`
@@ -25,8 +23,6 @@ type UpdateTemplateRequest struct {
 	Description string `json:"description"`
 	Category    string `json:"category"`
 	Tags        string `json:"tags"`
-	Thumbnail   string `json:"thumbnail"`
-	Preview     string `json:"preview"`
 	Config      map[string]interface{} `json:"config"`
 	IsPublic    *bool  `json:"is_public"`
 	IsPremium   *bool  `json:"is_premium"`
@@ -41,12 +37,19 @@ type TemplateResponse struct {
 	Tags        string                 `json:"tags"`
 	Thumbnail   string                 `json:"thumbnail"`
 	Preview     string                 `json:"preview"`
+	PreviewStatus string               `json:"preview_status"`
 	Config      map[string]interface{} `json:"config"`
 	IsPublic    bool                   `json:"is_public"`
 	IsPremium   bool                   `json:"is_premium"`
 	DownloadCount int                  `json:"download_count"`
 	Rating      float64                `json:"rating"`
+	RatingCount int                    `json:"rating_count"`
 	Status      string                 `json:"status"`
+	OwnerID     *uuid.UUID             `json:"owner_id"`
+	ReviewStatus string                `json:"review_status"`
+	ReviewNote  string                 `json:"review_note,omitempty"`
+	SubmittedAt *time.Time             `json:"submitted_at,omitempty"`
+	ReviewedAt  *time.Time             `json:"reviewed_at,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 }
@@ -56,11 +59,24 @@ type ListTemplatesRequest struct {
 	Page     int    `form:"page" binding:"min=1"`
 	PageSize int    `form:"page_size" binding:"min=1,max=100"`
 	Category string `form:"category"`
-	Keyword  string `form:"keyword"`
-	IsPublic *bool  `form:"is_public"`
+	Keyword  string `form:"keyword"` // 匹配名称、描述和标签
+	Sort     string `form:"sort" binding:"omitempty,oneof=latest popular rating trending"`
 	IsPremium *bool `form:"is_premium"`
 }
 
+// ListMyTemplatesRequest 我的模板 / 待审核模板列表请求
+type ListMyTemplatesRequest struct {
+	Page         int    `form:"page"`
+	PageSize     int    `form:"page_size"`
+	ReviewStatus string `form:"review_status" binding:"omitempty,oneof=draft submitted approved rejected"`
+}
+
+// ReviewTemplateRequest 模板审核请求
+type ReviewTemplateRequest struct {
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+	Note   string `json:"note" binding:"max=500"` // 驳回时必填
+}
+
 // ListTemplatesResponse 模板列表响应
 type ListTemplatesResponse struct {
 	Templates []*TemplateResponse `json:"templates"`
@@ -70,13 +86,50 @@ type ListTemplatesResponse struct {
 }
 
 // ApplyTemplateRequest 应用模板请求
+// 未指定 project_id 时新建项目；values 为占位符取值，媒体槽位填素材ID，文本填内容，颜色填 #RRGGBB
 type ApplyTemplateRequest struct {
-	ProjectID uuid.UUID `json:"project_id" binding:"required"`
-	Config    map[string]interface{} `json:"config"`
+	ProjectID   *uuid.UUID             `json:"project_id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Values      map[string]interface{} `json:"values"`
 }
 
 // ApplyTemplateResponse 应用模板响应
 type ApplyTemplateResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-} 
\ No newline at end of file
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Project *ProjectResponse `json:"project,omitempty"`
+}
+
+// RateTemplateRequest 模板评分请求
+type RateTemplateRequest struct {
+	Score  int    `json:"score" binding:"required,min=1,max=5"`
+	Review string `json:"review" binding:"max=2000"`
+}
+
+// TemplateRatingResponse 模板评价响应
+type TemplateRatingResponse struct {
+	ID         uuid.UUID `json:"id"`
+	TemplateID uuid.UUID `json:"template_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Username   string    `json:"username"`
+	Nickname   string    `json:"nickname"`
+	Score      int       `json:"score"`
+	Review     string    `json:"review"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ListTemplateRatingsRequest 模板评价列表请求
+type ListTemplateRatingsRequest struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+// ListTemplateRatingsResponse 模板评价列表响应
+type ListTemplateRatingsResponse struct {
+	Ratings  []*TemplateRatingResponse `json:"ratings"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InitUploadRequest 发起分片上传或直传请求，target 为 video 时填写视频参数，为 material 时填写素材参数
+type InitUploadRequest struct {
+	Target      string `json:"target" binding:"required,oneof=video material"`
+	FileName    string `json:"file_name" binding:"required"`
+	FileSize    int64  `json:"file_size" binding:"required,gt=0"`
+	ContentType string `json:"content_type"`
+	Checksum    string `json:"checksum" binding:"omitempty,len=64,hexadecimal"` // 整个文件的 SHA-256，合并后校验
+
+	// 视频参数
+	ProjectID *uuid.UUID `json:"project_id"`
+	Type      string     `json:"type"` // 视频为 video/audio/image，素材为素材类型
+
+	// 素材参数
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	IsPublic    bool   `json:"is_public"`
+	IsPremium   bool   `json:"is_premium"`
+	Tags        string `json:"tags"`
+}
+
+// UploadPart 已上传的分片
+type UploadPart struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+}
+
+// DirectUpload 直传存储的签名表单：以 multipart/form-data 向 url 提交 fields 中的全部字段，文件字段 file 放在最后
+type DirectUpload struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Fields    map[string]string `json:"fields"`
+	MaxSize   int64             `json:"max_size"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// UploadSessionResponse 上传会话，分片上传的 parts 为已上传的分片，断点续传时只需补传缺失的分片
+type UploadSessionResponse struct {
+	ID         uuid.UUID         `json:"id"`
+	Target     string            `json:"target"`
+	Mode       string            `json:"mode"` // multipart / direct
+	FileName   string            `json:"file_name"`
+	FileSize   int64             `json:"file_size"`
+	ChunkSize  int64             `json:"chunk_size"`
+	TotalParts int               `json:"total_parts"`
+	Checksum   string            `json:"checksum,omitempty"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Parts      []UploadPart      `json:"parts"`
+	Direct     *DirectUpload     `json:"direct,omitempty"` // 直传表单，仅在发起直传时返回
+	ResultID   *uuid.UUID        `json:"result_id,omitempty"`
+	Video      *VideoResponse    `json:"video,omitempty"`    // 完成后创建的视频
+	Material   *MaterialResponse `json:"material,omitempty"` // 完成后创建的素材
+	ExpiresAt  time.Time         `json:"expires_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
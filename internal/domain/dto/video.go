@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +29,7 @@ type VideoResponse struct {
 	FileName     string     `json:"file_name"`
 	OriginalName string     `json:"original_name"`
 	FilePath     string     `json:"file_path"`
+	ContentHash  string     `json:"content_hash"` // 文件内容的 SHA-256
 	FileSize     int64      `json:"file_size"`
 	Duration     float64    `json:"duration"`
 	Width        int        `json:"width"`
@@ -37,6 +39,13 @@ type VideoResponse struct {
 	Bitrate      int        `json:"bitrate"`
 	FPS          float64    `json:"fps"`
 	Thumbnail    string     `json:"thumbnail"`
+	Filmstrip     string    `json:"filmstrip"`
+	FilmstripVTT  string    `json:"filmstrip_vtt"`
+	Waveform      string    `json:"waveform"`
+	PreviewStatus string    `json:"preview_status"`
+	Proxy         string    `json:"proxy"` // 剪辑代理地址
+	HLS           string    `json:"hls"`   // HLS 主播放列表地址
+	Progress      int       `json:"progress"`
 	Status       string     `json:"status"`
 	Type         string     `json:"type"`
 	CreatedAt    time.Time  `json:"created_at"`
@@ -58,4 +67,43 @@ type ListVideosResponse struct {
 	Total    int64            `json:"total"`
 	Page     int              `json:"page"`
 	PageSize int              `json:"page_size"`
-} 
\ No newline at end of file
+}
+
+// ProcessVideoRequest 视频转码请求
+type ProcessVideoRequest struct {
+	HLS bool `json:"hls"` // 是否同时生成 HLS 多码率播放版本
+}
+
+// VideoStatusResponse 视频处理状态响应
+type VideoStatusResponse struct {
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Error    string `json:"error,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	HLS      string `json:"hls,omitempty"`
+}
+
+// TranscribeVideoRequest 视频语音识别请求
+type TranscribeVideoRequest struct {
+	Language       string `json:"language"`        // 为空自动检测
+	WordTimestamps *bool  `json:"word_timestamps"` // 是否返回逐词时间，默认开启
+	Provider       string `json:"provider"`        // 可选，语音识别提供方
+	Model          string `json:"model"`           // 可选，模型
+}
+
+// TranscriptResponse 语音识别结果响应
+// SubtitleTrack 为字幕轨道，可直接加入项目配置的 tracks
+type TranscriptResponse struct {
+	ID            uuid.UUID       `json:"id"`
+	VideoID       uuid.UUID       `json:"video_id"`
+	TaskID        uuid.UUID       `json:"task_id"`
+	Provider      string          `json:"provider"`
+	Language      string          `json:"language"`
+	Duration      float64         `json:"duration"`
+	Text          string          `json:"text"`
+	Segments      json.RawMessage `json:"segments"`
+	SRTURL        string          `json:"srt_url"`
+	VTTURL        string          `json:"vtt_url"`
+	SubtitleTrack interface{}     `json:"subtitle_track"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
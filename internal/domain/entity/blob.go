@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+)
+
+// Blob 按内容 SHA-256 存储的文件，多条视频或素材记录引用同一文件，引用计数归零时删除
+type Blob struct {
+	Hash        string    `json:"hash" gorm:"type:varchar(64);primary_key"`
+	ObjectName  string    `json:"object_name" gorm:"not null"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	RefCount    int       `json:"ref_count" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Blob) TableName() string {
+	return "blobs"
+}
@@ -1,12 +1,23 @@
 package entity
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// 素材状态：上传后探测媒体信息，成功为 ready，文件损坏或格式不支持为 failed
+// 早期素材状态为 active，与 ready 等同
+const (
+	MaterialStatusUploaded = "uploaded"
+	MaterialStatusReady    = "ready"
+	MaterialStatusFailed   = "failed"
+	MaterialStatusActive   = "active"
+)
+
 // Material 素材实体
 type Material struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -16,14 +27,20 @@ type Material struct {
 	Type        string         `json:"type" gorm:"not null"` // 具体类型
 	FileName    string         `json:"file_name" gorm:"not null"`
 	FilePath    string         `json:"file_path" gorm:"not null"`
+	ContentHash string         `json:"content_hash" gorm:"type:varchar(64);index"` // 内容寻址文件的 SHA-256，早期上传的文件为空
 	FileSize    int64          `json:"file_size"`
 	Duration    float64        `json:"duration"` // 音频/视频时长
 	Width       int            `json:"width"` // 图片/视频宽度
 	Height      int            `json:"height"` // 图片/视频高度
 	Format      string         `json:"format"`
 	Thumbnail   string         `json:"thumbnail"`
+	Filmstrip     string       `json:"filmstrip"`      // 胶片条雪碧图
+	FilmstripVTT  string       `json:"filmstrip_vtt"`  // 胶片条 WebVTT 索引
+	Waveform      string       `json:"waveform"`       // 波形峰值 JSON
+	PreviewStatus string       `json:"preview_status"` // 预览生成状态
 	Tags        string         `json:"tags"` // 标签，逗号分隔
 	IsPublic    bool           `json:"is_public" gorm:"default:true"`
+	OwnerID     *uuid.UUID     `json:"owner_id" gorm:"type:uuid;index"` // 上传者，为空表示平台素材
 	IsPremium   bool           `json:"is_premium" gorm:"default:false"`
 	DownloadCount int          `json:"download_count" gorm:"default:0"`
 	Rating      float64        `json:"rating" gorm:"default:0"`
@@ -38,10 +55,60 @@ func (Material) TableName() string {
 	return "materials"
 }
 
+// TagList 解析素材的标签
+func (m *Material) TagList() []string {
+	return ParseTags(m.Tags)
+}
+
+// VisibleTo 公开素材所有人可见可用，私有素材仅所有者可见可用
+func (m *Material) VisibleTo(userID uuid.UUID) bool {
+	if m.IsPublic {
+		return true
+	}
+	return userID != uuid.Nil && m.OwnerID != nil && *m.OwnerID == userID
+}
+
+// Usable 素材已通过探测可用于渲染
+func (m *Material) Usable() bool {
+	return m.Status == MaterialStatusReady || m.Status == MaterialStatusActive
+}
+
 // BeforeCreate 创建前的钩子
 func (m *Material) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
 	return nil
-} 
\ No newline at end of file
+}
+
+// maxTagLength 单个标签的最大字符数
+const maxTagLength = 50
+
+// MaterialTag 素材标签，由素材的 Tags 字段拆分而来，用于按标签筛选
+type MaterialTag struct {
+	MaterialID uuid.UUID `json:"material_id" gorm:"type:uuid;primaryKey"`
+	Tag        string    `json:"tag" gorm:"type:varchar(50);primaryKey;index"`
+}
+
+// TableName 指定表名
+func (MaterialTag) TableName() string {
+	return "material_tags"
+}
+
+// ParseTags 解析逗号（含全角逗号）分隔的标签：去除首尾空白、转为小写、去重，超长的截断
+func ParseTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' }) {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if utf8.RuneCountInString(t) > maxTagLength {
+			t = string([]rune(t)[:maxTagLength])
+		}
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
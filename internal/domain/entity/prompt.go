@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 提示词模板状态
+const (
+	PromptStatusDraft    = "draft"    // 草稿，可编辑，不参与任务
+	PromptStatusActive   = "active"   // 启用，同名多个启用版本按权重分流
+	PromptStatusArchived = "archived" // 归档
+)
+
+// PromptTemplate 提示词模板
+// 同名模板按版本号递增保存；启用后内容不可修改，以保证任务结果可追溯
+type PromptTemplate struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string         `json:"name" gorm:"type:varchar(64);not null;uniqueIndex:idx_prompt_name_version"`
+	Version     int            `json:"version" gorm:"not null;uniqueIndex:idx_prompt_name_version"`
+	Description string         `json:"description"`
+	System      string         `json:"system" gorm:"type:text"`           // 系统提示词，可为空
+	Content     string         `json:"content" gorm:"type:text;not null"` // text/template 语法，变量以 {{.name}} 引用
+	Variables   string         `json:"variables" gorm:"type:text"`        // 变量名列表（JSON数组）
+	ModelParams string         `json:"model_params" gorm:"type:text"`     // 调用参数（JSON对象），如 {"temperature":0.7}
+	Status      string         `json:"status" gorm:"type:varchar(16);default:'draft';index"`
+	Weight      int            `json:"weight" gorm:"default:100"` // A/B 测试分流权重
+	CreatedBy   *uuid.UUID     `json:"created_by" gorm:"type:uuid"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
+
+// BeforeCreate 创建前的钩子
+func (p *PromptTemplate) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// PromptUsage 任务使用的提示词版本，Version 为 0 表示内置提示词
+type PromptUsage struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TaskID     uuid.UUID  `json:"task_id" gorm:"type:uuid;not null;index"`
+	TaskType   string     `json:"task_type" gorm:"type:varchar(32)"`
+	TemplateID *uuid.UUID `json:"template_id" gorm:"type:uuid"`
+	Name       string     `json:"name" gorm:"type:varchar(64);not null;index"`
+	Version    int        `json:"version"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PromptUsage) TableName() string {
+	return "prompt_usages"
+}
+
+// BeforeCreate 创建前的钩子
+func (u *PromptUsage) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
@@ -24,6 +24,9 @@ type Render struct {
 	Format      string         `json:"format"`
 	Quality     string         `json:"quality"` // high, medium, low
 	Error       string         `json:"error"` // 错误信息
+	Streaming   string         `json:"streaming"` // 自适应码率输出，逗号分隔：hls、dash
+	HLSPath     string         `json:"hls_path"` // HLS 主播放列表对象路径（renders 存储桶）
+	DASHPath    string         `json:"dash_path"` // DASH 清单对象路径（renders 存储桶）
 	StartedAt   *time.Time     `json:"started_at"`
 	CompletedAt *time.Time     `json:"completed_at"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Storyboard 小说转视频任务的分镜稿，生成完成后可逐格修改、重绘并重新合成
+type Storyboard struct {
+	ID         uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TaskID     uuid.UUID         `json:"task_id" gorm:"type:uuid;not null;uniqueIndex"` // 生成分镜的小说转视频任务
+	UserID     *uuid.UUID        `json:"user_id" gorm:"type:uuid;index"`                // 提交生成任务的用户，匿名提交时为空，只有管理员可以修改
+	Voices     string            `json:"voices" gorm:"type:text"`                       // 角色音色（JSON字符串）
+	Providers  string            `json:"providers" gorm:"type:text"`                    // 提供方覆盖（JSON字符串）
+	VideoPath  string            `json:"video_path"`                                    // 最近一次重新合成的视频
+	AudioPath  string            `json:"audio_path"`                                    // 最近一次合成的配音音轨
+	Segments   string            `json:"segments" gorm:"type:text"`                     // 配音中每句的时间（JSON字符串）
+	AudioStale bool              `json:"audio_stale" gorm:"default:false"`              // 旁白、台词或分镜顺序已修改，配音需重新合成
+	Panels     []StoryboardPanel `json:"panels" gorm:"foreignKey:StoryboardID"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// StoryboardPanel 单格分镜
+type StoryboardPanel struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StoryboardID uuid.UUID `json:"storyboard_id" gorm:"type:uuid;not null;index"`
+	Position     int       `json:"position"`                   // 从0开始的顺序
+	Scene        string    `json:"scene" gorm:"type:text"`     // 画面描述，即出图提示词
+	Narration    string    `json:"narration" gorm:"type:text"` // 旁白
+	Dialogues    string    `json:"dialogues" gorm:"type:text"` // 台词（JSON字符串）
+	Seed         int64     `json:"seed"`                       // 出图 seed，提供方不支持时为0
+	ImagePath    string    `json:"image_path"`                 // MinIO对象名
+	Stale        bool      `json:"stale" gorm:"default:false"` // 画面描述已修改但尚未重绘
+	Duration     float64   `json:"duration"`                   // 最近一次合成时该格的显示时长（秒）
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Storyboard) TableName() string {
+	return "storyboards"
+}
+
+// TableName 指定表名
+func (StoryboardPanel) TableName() string {
+	return "storyboard_panels"
+}
+
+// BeforeCreate 创建前的钩子
+func (s *Storyboard) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate 创建前的钩子
+func (p *StoryboardPanel) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -7,9 +7,18 @@ import (
 
 // TaskType 定义任务类型
 const (
-	TaskTypeRender = "render"
-	TaskTypeAI     = "ai"
-	TaskTypeVideo  = "video"
+	TaskTypeRender     = "render"
+	TaskTypeAI         = "ai"
+	TaskTypeVideo      = "video"
+	TaskTypeNovel      = "novel"      // AI小说生成
+	TaskTypeTranscribe = "transcribe" // 视频语音识别
+	TaskTypePanelImage = "panel_image" // 分镜单格重绘
+	TaskTypeStoryboardCompose = "storyboard_compose" // 分镜重新合成视频
+	TaskTypeTemplatePreview = "template_preview" // 模板预览渲染
+	TaskTypeVideoPreview    = "video_preview"    // 视频封面、胶片条和波形
+	TaskTypeMaterialPreview = "material_preview" // 素材封面、胶片条和波形
+	TaskTypeVideoTranscode  = "video_transcode"  // 视频剪辑代理与 HLS 转码
+	TaskTypeUploadComplete  = "upload_complete"  // 上传完成后校验、转存并创建记录
 	// 可扩展更多类型
 )
 
@@ -34,6 +43,7 @@ type Task struct {
 	Params    string    `json:"params" gorm:"type:text"`    // 任务参数（JSON字符串）
 	Result    string    `json:"result" gorm:"type:text"`    // 任务结果（JSON字符串）
 	Error     string    `json:"error" gorm:"type:text"`     // 错误信息
+	PromptVersions string `json:"prompt_versions,omitempty" gorm:"type:text"` // 使用的提示词版本（JSON，模板名→版本号）
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 } 
\ No newline at end of file
@@ -7,6 +7,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// 模板审核状态：作者提交后由管理员审核，通过后才出现在公开列表中
+const (
+	TemplateReviewDraft     = "draft"
+	TemplateReviewSubmitted = "submitted"
+	TemplateReviewApproved  = "approved"
+	TemplateReviewRejected  = "rejected"
+)
+
 // Template 模板实体
 type Template struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -14,7 +22,8 @@ type Template struct {
 	Description string         `json:"description"`
 	Category    string         `json:"category" gorm:"not null"`
 	Thumbnail   string         `json:"thumbnail"`
-	Preview     string         `json:"preview"` // 预览视频URL
+	Preview     string         `json:"preview"` // 预览动图，平台渲染生成时为对象名，响应中换成访问地址
+	PreviewStatus string       `json:"preview_status"` // 预览渲染状态：pending/processing/completed/failed
 	Config      string         `json:"config" gorm:"type:text"` // JSON配置
 	Duration    int            `json:"duration"` // 模板时长（秒）
 	Resolution  string         `json:"resolution"` // 分辨率
@@ -22,8 +31,15 @@ type Template struct {
 	IsPublic    bool           `json:"is_public" gorm:"default:true"`
 	IsPremium   bool           `json:"is_premium" gorm:"default:false"`
 	DownloadCount int          `json:"download_count" gorm:"default:0"`
-	Rating      float64        `json:"rating" gorm:"default:0"`
+	Rating      float64        `json:"rating" gorm:"default:0"` // 平均评分，评分变化时重新汇总
+	RatingCount int            `json:"rating_count" gorm:"default:0"`
 	Status      string         `json:"status" gorm:"default:'active'"`
+	OwnerID     *uuid.UUID     `json:"owner_id" gorm:"type:uuid;index"` // 作者，为空表示平台内置模板
+	ReviewStatus string        `json:"review_status" gorm:"type:varchar(16);default:'approved';index"` // 已有模板迁移后视为已审核
+	ReviewNote  string         `json:"review_note"` // 驳回原因
+	ReviewedBy  *uuid.UUID     `json:"reviewed_by" gorm:"type:uuid"`
+	SubmittedAt *time.Time     `json:"submitted_at"`
+	ReviewedAt  *time.Time     `json:"reviewed_at"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
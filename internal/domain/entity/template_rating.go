@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateRating 模板评分与评价，每个用户对同一模板只保留一条，重复评分覆盖旧值
+type TemplateRating struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TemplateID uuid.UUID `json:"template_id" gorm:"type:uuid;not null;uniqueIndex:idx_template_rating_user"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_template_rating_user"`
+	User       User      `json:"user" gorm:"foreignKey:UserID"`
+	Score      int       `json:"score" gorm:"not null"` // 1-5 分
+	Review     string    `json:"review" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (TemplateRating) TableName() string {
+	return "template_ratings"
+}
+
+// BeforeCreate 创建前的钩子
+func (r *TemplateRating) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TemplateApply 模板应用记录，用于按近期使用热度排序
+type TemplateApply struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TemplateID uuid.UUID `json:"template_id" gorm:"type:uuid;not null;index:idx_template_apply_time"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	ProjectID  uuid.UUID `json:"project_id" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_template_apply_time"`
+}
+
+// TableName 指定表名
+func (TemplateApply) TableName() string {
+	return "template_applies"
+}
+
+// BeforeCreate 创建前的钩子
+func (a *TemplateApply) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
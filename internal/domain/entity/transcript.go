@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Transcript 视频语音识别结果
+// 每次识别生成一条记录，字幕文件保存在MinIO
+type Transcript struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	VideoID   uuid.UUID      `json:"video_id" gorm:"type:uuid;not null;index"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	TaskID    uuid.UUID      `json:"task_id" gorm:"type:uuid"`
+	Provider  string         `json:"provider"`
+	Language  string         `json:"language"`
+	Duration  float64        `json:"duration"`
+	Text      string         `json:"text" gorm:"type:text"`
+	Segments  string         `json:"segments" gorm:"type:text"` // 分段及逐词时间（JSON字符串）
+	SRTPath   string         `json:"srt_path"`
+	VTTPath   string         `json:"vtt_path"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (Transcript) TableName() string {
+	return "transcripts"
+}
+
+// BeforeCreate 创建前的钩子
+func (t *Transcript) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 分片上传目标
+const (
+	UploadTargetVideo    = "video"
+	UploadTargetMaterial = "material"
+)
+
+// 上传方式
+const (
+	UploadModeMultipart = "multipart" // 经 API 分片上传
+	UploadModeDirect    = "direct"    // 客户端凭签名表单直传存储
+)
+
+// 上传会话状态
+const (
+	UploadStatusUploading  = "uploading"  // 等待上传，分片上传可断点续传
+	UploadStatusCompleting = "completing" // 正在合并分片或确认直传文件并校验
+	UploadStatusCompleted  = "completed"  // 已创建视频或素材记录
+	UploadStatusAborted    = "aborted"    // 用户取消
+	UploadStatusExpired    = "expired"    // 长时间无活动，已由定时任务清理
+	UploadStatusFailed     = "failed"     // 校验失败或创建记录失败
+)
+
+// UploadSession 上传会话，分片上传对应存储中的一个 multipart upload，直传对应一个签名表单
+type UploadSession struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Target      string     `json:"target" gorm:"type:varchar(16);not null"` // video / material
+	Mode        string     `json:"mode" gorm:"type:varchar(16);default:'multipart'"`
+	FileName    string     `json:"file_name" gorm:"not null"`
+	ContentType string     `json:"content_type"`
+	FileSize    int64      `json:"file_size" gorm:"not null"`
+	ChunkSize   int64      `json:"chunk_size" gorm:"not null"`       // 直传为 0
+	TotalParts  int        `json:"total_parts" gorm:"not null"`      // 直传为 0
+	Checksum    string     `json:"checksum" gorm:"type:varchar(64)"` // 整个文件的 SHA-256，为空时不校验
+	ObjectName  string     `json:"object_name" gorm:"not null"`
+	UploadID    string     `json:"-" gorm:"not null"`          // 存储端 multipart uploadID
+	Params      string     `json:"params" gorm:"type:text"`    // 创建视频或素材记录所需的参数（JSON）
+	ResultID    *uuid.UUID `json:"result_id" gorm:"type:uuid"` // 完成后创建的视频或素材ID
+	Status      string     `json:"status" gorm:"type:varchar(16);index;default:'uploading'"`
+	Error       string     `json:"error" gorm:"type:text"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// BeforeCreate 创建前的钩子
+func (u *UploadSession) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// PartSize 第 n 个分片（从 1 开始）的应有大小，最后一片为剩余字节
+func (u *UploadSession) PartSize(n int) int64 {
+	if n < u.TotalParts {
+		return u.ChunkSize
+	}
+	return u.FileSize - int64(u.TotalParts-1)*u.ChunkSize
+}
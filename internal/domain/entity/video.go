@@ -7,6 +7,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// 视频状态：上传后探测媒体信息，成功为 ready，文件损坏或格式不支持为 failed
+const (
+	VideoStatusUploaded   = "uploaded"
+	VideoStatusProcessing = "processing"
+	VideoStatusReady      = "ready"
+	VideoStatusFailed     = "failed"
+)
+
 // Video 视频实体
 type Video struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -17,6 +25,7 @@ type Video struct {
 	FileName    string         `json:"file_name" gorm:"not null"`
 	OriginalName string        `json:"original_name" gorm:"not null"`
 	FilePath    string         `json:"file_path" gorm:"not null"`
+	ContentHash string         `json:"content_hash" gorm:"type:varchar(64);index"` // 内容寻址文件的 SHA-256，早期上传的文件为空
 	FileSize    int64          `json:"file_size"`
 	Duration    float64        `json:"duration"` // 视频时长（秒）
 	Width       int            `json:"width"`
@@ -26,6 +35,15 @@ type Video struct {
 	Bitrate     int            `json:"bitrate"`
 	FPS         float64        `json:"fps"`
 	Thumbnail   string         `json:"thumbnail"`
+	Filmstrip     string       `json:"filmstrip"`      // 胶片条雪碧图
+	FilmstripVTT  string       `json:"filmstrip_vtt"`  // 胶片条 WebVTT 索引
+	Waveform      string       `json:"waveform"`       // 波形峰值 JSON
+	PreviewStatus string       `json:"preview_status"` // 预览生成状态
+	ProxyPath     string       `json:"proxy_path"`     // 剪辑代理对象路径
+	HLSPath       string       `json:"hls_path"`       // HLS 主播放列表对象路径
+	Progress      int          `json:"progress"`       // 转码进度 0-100
+	ProcessError  string       `json:"process_error"`  // 转码失败原因
+	TranscodeHLS  bool         `json:"transcode_hls"`  // 最近一次转码是否生成 HLS，服务重启后按此重新提交
 	Status      string         `json:"status" gorm:"default:'uploading'"`
 	Type        string         `json:"type" gorm:"default:'video'"` // video, audio, image
 	CreatedAt   time.Time      `json:"created_at"`
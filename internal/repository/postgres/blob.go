@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"comic_video/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlobRepository struct {
+	db *gorm.DB
+}
+
+func NewBlobRepository(db *gorm.DB) *BlobRepository {
+	return &BlobRepository{db: db}
+}
+
+// Acquire 增加内容的引用计数，不存在时创建，blob.RefCount 回填为增加后的值，为 1 表示新建
+func (r *BlobRepository) Acquire(ctx context.Context, blob *entity.Blob) error {
+	blob.RefCount = 1
+	return r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count":  gorm.Expr("blobs.ref_count + 1"),
+				"updated_at": gorm.Expr("NOW()"),
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "ref_count"}, {Name: "object_name"}}},
+	).Create(blob).Error
+}
+
+// Release 引用计数减一；释放最后一个引用时在持有行锁的事务内调用 onLast 删除文件，成功后删除记录
+func (r *BlobRepository) Release(ctx context.Context, hash string, onLast func(*entity.Blob) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var blob entity.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&blob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if blob.RefCount > 1 {
+			return tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+		if err := onLast(&blob); err != nil {
+			return err
+		}
+		return tx.Delete(&blob).Error
+	})
+}
+
+// GetByHash 按内容哈希获取文件
+func (r *BlobRepository) GetByHash(ctx context.Context, hash string) (*entity.Blob, error) {
+	var blob entity.Blob
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
@@ -45,9 +45,31 @@ func AutoMigrate(db *gorm.DB) error {
 		&entity.Video{},
 		&entity.Template{},
 		&entity.Material{},
+		&entity.MaterialTag{},
 		&entity.Render{},
 		&entity.Task{}, // 新增
+		&entity.Transcript{},
+		&entity.PromptTemplate{},
+		&entity.PromptUsage{},
+		&entity.Storyboard{},
+		&entity.StoryboardPanel{},
+		&entity.TemplateRating{},
+		&entity.TemplateApply{},
+		&entity.UploadSession{},
+		&entity.Blob{},
 	}
 
-	return db.AutoMigrate(entities...)
+	// 标签表首次创建时从素材的 tags 字段回填
+	backfillTags := !db.Migrator().HasTable(&entity.MaterialTag{})
+	// 素材所有者字段首次创建时从对象名的用户ID前缀回填
+	backfillOwners := !db.Migrator().HasColumn(&entity.Material{}, "owner_id")
+	if err := db.AutoMigrate(entities...); err != nil {
+		return err
+	}
+	if backfillOwners {
+		if err := backfillMaterialOwners(db); err != nil {
+			return err
+		}
+	}
+	return migrateMaterialSearch(db, backfillTags)
 } 
\ No newline at end of file
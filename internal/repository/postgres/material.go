@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 
 	"comic_video/internal/domain/entity"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MaterialRepository 素材仓库接口
@@ -14,10 +16,80 @@ type MaterialRepository interface {
 	Create(ctx context.Context, material *entity.Material) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.Material, error)
 	List(ctx context.Context, offset, limit int, filter map[string]interface{}) ([]*entity.Material, int64, error)
-	Update(ctx context.Context, material *entity.Material) error
+	Search(ctx context.Context, offset, limit int, search MaterialSearch) ([]*entity.Material, int64, error)
+	Facets(ctx context.Context, search MaterialSearch) (map[string][]FacetCount, error)
+	UpdateFields(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	UpdatePreview(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// 素材列表排序方式
+const (
+	MaterialSortLatest    = "latest"    // 最新上传
+	MaterialSortRating    = "rating"    // 评分
+	MaterialSortDownloads = "downloads" // 下载次数
+	MaterialSortRelevance = "relevance" // 关键词相关度，有关键词时的默认排序
+)
+
+// 素材分面
+const (
+	FacetCategory   = "category"
+	FacetFormat     = "format"
+	FacetDuration   = "duration"
+	FacetResolution = "resolution"
+)
+
+// 时长区间：short 15秒以内，medium 15秒~1分钟，long 1~5分钟，extended 5分钟以上
+const (
+	DurationShort    = "short"
+	DurationMedium   = "medium"
+	DurationLong     = "long"
+	DurationExtended = "extended"
+)
+
+// 分辨率档位按短边划分，竖屏素材与横屏同档
+const (
+	ResolutionSD  = "sd"  // 720 以下
+	ResolutionHD  = "hd"  // 720
+	ResolutionFHD = "fhd" // 1080
+	ResolutionQHD = "qhd" // 1440
+	ResolutionUHD = "uhd" // 2160 及以上
+)
+
+// 全文检索文档：名称、描述和标签，使用 simple 配置不做词干处理
+// 中文没有分词，整段连续的中文只能整体匹配，因此关键词同时按名称子串匹配
+const materialSearchDocument = "to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(description, '') || ' ' || coalesce(tags, ''))"
+
+// 分面取值表达式，不适用的素材（如图片没有时长）取 NULL，不参与统计
+var materialFacetExprs = map[string]string{
+	FacetCategory: "category",
+	FacetFormat:   "NULLIF(format, '')",
+	FacetDuration: fmt.Sprintf("CASE WHEN duration IS NULL OR duration <= 0 THEN NULL "+
+		"WHEN duration < 15 THEN '%s' WHEN duration < 60 THEN '%s' WHEN duration < 300 THEN '%s' ELSE '%s' END",
+		DurationShort, DurationMedium, DurationLong, DurationExtended),
+	FacetResolution: fmt.Sprintf("CASE WHEN LEAST(width, height) >= 2160 THEN '%s' WHEN LEAST(width, height) >= 1440 THEN '%s' "+
+		"WHEN LEAST(width, height) >= 1080 THEN '%s' WHEN LEAST(width, height) >= 720 THEN '%s' "+
+		"WHEN LEAST(width, height) > 0 THEN '%s' END",
+		ResolutionUHD, ResolutionQHD, ResolutionFHD, ResolutionHD, ResolutionSD),
+}
+
+// MaterialSearch 素材搜索条件
+type MaterialSearch struct {
+	Filter     map[string]interface{} // 等值条件，如 category、type、format、owner_id
+	Keyword    string
+	Tags       []string  // 须同时带有的标签，已规范化
+	Duration   string    // 时长区间
+	Resolution string    // 分辨率档位
+	ViewerID   uuid.UUID // 可见性：公开素材和该用户的私有素材，为空时只有公开素材
+	Sort       string
+}
+
+// FacetCount 分面取值及素材数量
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
 // materialRepository 素材仓库实现
 type materialRepository struct {
 	db *gorm.DB
@@ -29,9 +101,20 @@ func NewMaterialRepository(db *gorm.DB) MaterialRepository {
 
 var _ MaterialRepository = (*materialRepository)(nil)
 
-// Create 创建素材
+// Create 创建素材并写入标签
+// is_public 列默认值为 true，创建时零值会被默认值覆盖，私有素材需单独写回
 func (r *materialRepository) Create(ctx context.Context, material *entity.Material) error {
-	return r.db.WithContext(ctx).Create(material).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(material).Error; err != nil {
+			return err
+		}
+		if !material.IsPublic {
+			if err := tx.Model(material).UpdateColumn("is_public", false).Error; err != nil {
+				return err
+			}
+		}
+		return syncTags(tx, material)
+	})
 }
 
 // GetByID 根据ID获取素材
@@ -67,12 +150,173 @@ func (r *materialRepository) List(ctx context.Context, offset, limit int, filter
 	return materials, total, nil
 }
 
-// Update 更新素材
-func (r *materialRepository) Update(ctx context.Context, material *entity.Material) error {
-	return r.db.WithContext(ctx).Save(material).Error
+// Search 按条件搜索 ViewerID 可见的素材，探测失败的素材只有所有者可见
+func (r *materialRepository) Search(ctx context.Context, offset, limit int, search MaterialSearch) ([]*entity.Material, int64, error) {
+	var materials []*entity.Material
+	var total int64
+
+	query := r.searchScope(ctx, search, "")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sort := search.Sort
+	if sort == "" && search.Keyword != "" {
+		sort = MaterialSortRelevance
+	}
+	switch sort {
+	case MaterialSortRating:
+		query = query.Order("rating DESC").Order("download_count DESC")
+	case MaterialSortDownloads:
+		query = query.Order("download_count DESC")
+	case MaterialSortRelevance:
+		if search.Keyword != "" {
+			query = query.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "ts_rank(" + materialSearchDocument + ", websearch_to_tsquery('simple', ?)) DESC",
+				Vars:               []interface{}{search.Keyword},
+				WithoutParentheses: true,
+			}})
+		}
+	}
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&materials).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return materials, total, nil
+}
+
+// Facets 统计搜索结果在各分面上的分布
+// 每个分面统计时忽略该分面自身的筛选条件，已选中某一分类时仍能看到其他分类的数量
+func (r *materialRepository) Facets(ctx context.Context, search MaterialSearch) (map[string][]FacetCount, error) {
+	facets := make(map[string][]FacetCount, len(materialFacetExprs))
+	for name, expr := range materialFacetExprs {
+		counts := []FacetCount{}
+		err := r.searchScope(ctx, search, name).
+			Select(expr + " AS value, COUNT(*) AS count").
+			Where(expr + " IS NOT NULL").
+			Group(expr).
+			Order("count DESC").
+			Order("value").
+			Scan(&counts).Error
+		if err != nil {
+			return nil, err
+		}
+		facets[name] = counts
+	}
+	return facets, nil
+}
+
+// searchScope 构造搜索条件，skip 为需要忽略筛选条件的分面
+func (r *materialRepository) searchScope(ctx context.Context, search MaterialSearch, skip string) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entity.Material{})
+	for k, v := range search.Filter {
+		if k == skip {
+			continue
+		}
+		query = query.Where(k+" = ?", v)
+	}
+	if search.ViewerID == uuid.Nil {
+		query = query.Where("is_public = ? AND status <> ?", true, entity.MaterialStatusFailed)
+	} else {
+		query = query.Where("(is_public = ? AND status <> ?) OR owner_id = ?", true, entity.MaterialStatusFailed, search.ViewerID)
+	}
+	if search.Keyword != "" {
+		query = query.Where("("+materialSearchDocument+" @@ websearch_to_tsquery('simple', ?) OR name ILIKE ?)",
+			search.Keyword, "%"+search.Keyword+"%")
+	}
+	if len(search.Tags) > 0 {
+		tagged := r.db.Model(&entity.MaterialTag{}).
+			Select("material_id").
+			Where("tag IN ?", search.Tags).
+			Group("material_id").
+			Having("COUNT(*) = ?", len(search.Tags))
+		query = query.Where("id IN (?)", tagged)
+	}
+	if search.Duration != "" && skip != FacetDuration {
+		query = query.Where(materialFacetExprs[FacetDuration]+" = ?", search.Duration)
+	}
+	if search.Resolution != "" && skip != FacetResolution {
+		query = query.Where(materialFacetExprs[FacetResolution]+" = ?", search.Resolution)
+	}
+	return query
+}
+
+// UpdateFields 只更新指定字段，避免整行写回覆盖并发的评分、下载次数和预览结果
+// 更新 tags 时同步标签表
+func (r *materialRepository) UpdateFields(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Material{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		tags, ok := updates["tags"].(string)
+		if !ok {
+			return nil
+		}
+		return syncTags(tx, &entity.Material{ID: id, Tags: tags})
+	})
 }
 
-// Delete 删除素材
+// UpdatePreview 只更新预览相关字段，避免覆盖生成期间对素材的其他修改
+func (r *materialRepository) UpdatePreview(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Material{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除素材及其标签
 func (r *materialRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&entity.Material{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("material_id = ?", id).Delete(&entity.MaterialTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Material{}, id).Error
+	})
+}
+
+// syncTags 按素材的 tags 字段重写标签表
+func syncTags(tx *gorm.DB, material *entity.Material) error {
+	if err := tx.Where("material_id = ?", material.ID).Delete(&entity.MaterialTag{}).Error; err != nil {
+		return err
+	}
+	tags := material.TagList()
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]entity.MaterialTag, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, entity.MaterialTag{MaterialID: material.ID, Tag: tag})
+	}
+	return tx.Create(&rows).Error
+}
+
+// backfillMaterialOwners 为已有素材回填所有者
+// 早期上传的对象名为 <userID>/<时间戳>_<文件名>，前缀对应的用户存在时即为上传者，其余素材保持为平台素材
+func backfillMaterialOwners(db *gorm.DB) error {
+	err := db.Exec(`UPDATE materials m SET owner_id = u.id
+		FROM users u
+		WHERE m.owner_id IS NULL
+			AND m.file_path ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}/'
+			AND u.id = left(m.file_path, 36)::uuid`).Error
+	if err != nil {
+		return fmt.Errorf("回填素材所有者失败: %w", err)
+	}
+	return nil
+}
+
+// migrateMaterialSearch 创建全文检索索引，backfillTags 为 true 时从已有素材的 tags 字段回填标签表
+func migrateMaterialSearch(db *gorm.DB, backfillTags bool) error {
+	err := db.Exec("CREATE INDEX IF NOT EXISTS idx_materials_search ON materials USING GIN (" + materialSearchDocument + ")").Error
+	if err != nil {
+		return fmt.Errorf("创建素材全文索引失败: %w", err)
+	}
+	if !backfillTags {
+		return nil
+	}
+	err = db.Exec(`INSERT INTO material_tags (material_id, tag)
+		SELECT DISTINCT m.id, left(lower(btrim(t)), 50)
+		FROM materials m, regexp_split_to_table(m.tags, '[,，]') AS t
+		WHERE m.deleted_at IS NULL AND btrim(t) <> ''
+		ON CONFLICT DO NOTHING`).Error
+	if err != nil {
+		return fmt.Errorf("回填素材标签失败: %w", err)
+	}
+	return nil
 } 
\ No newline at end of file
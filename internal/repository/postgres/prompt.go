@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromptRepository struct {
+	db *gorm.DB
+}
+
+func NewPromptRepository(db *gorm.DB) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+// PromptVersionStats 单个提示词版本的任务统计
+type PromptVersionStats struct {
+	Version   int   `json:"version"`
+	Uses      int64 `json:"uses"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+}
+
+// Create 创建提示词模板
+func (r *PromptRepository) Create(ctx context.Context, prompt *entity.PromptTemplate) error {
+	return r.db.WithContext(ctx).Create(prompt).Error
+}
+
+// GetByID 根据ID获取提示词模板
+func (r *PromptRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.PromptTemplate, error) {
+	var prompt entity.PromptTemplate
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&prompt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// GetByVersion 根据名称和版本号获取提示词模板
+func (r *PromptRepository) GetByVersion(ctx context.Context, name string, version int) (*entity.PromptTemplate, error) {
+	var prompt entity.PromptTemplate
+	err := r.db.WithContext(ctx).Where("name = ? AND version = ?", name, version).First(&prompt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// ListActive 获取启用中的版本
+func (r *PromptRepository) ListActive(ctx context.Context, name string) ([]*entity.PromptTemplate, error) {
+	var prompts []*entity.PromptTemplate
+	err := r.db.WithContext(ctx).Where("name = ? AND status = ?", name, entity.PromptStatusActive).Order("version DESC").Find(&prompts).Error
+	return prompts, err
+}
+
+// List 获取提示词模板列表，name 为空时返回全部
+func (r *PromptRepository) List(ctx context.Context, name string) ([]*entity.PromptTemplate, error) {
+	var prompts []*entity.PromptTemplate
+	query := r.db.WithContext(ctx).Model(&entity.PromptTemplate{})
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	err := query.Order("name ASC, version DESC").Find(&prompts).Error
+	return prompts, err
+}
+
+// MaxVersion 获取当前最大版本号，不存在时返回0
+func (r *PromptRepository) MaxVersion(ctx context.Context, name string) (int, error) {
+	var version int
+	err := r.db.WithContext(ctx).Unscoped().Model(&entity.PromptTemplate{}).Where("name = ?", name).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Update 更新提示词模板
+func (r *PromptRepository) Update(ctx context.Context, prompt *entity.PromptTemplate) error {
+	return r.db.WithContext(ctx).Save(prompt).Error
+}
+
+// SetABTest 启用两个版本并设置分流权重，同名其它启用版本归档
+func (r *PromptRepository) SetABTest(ctx context.Context, name string, weights map[int]int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		versions := make([]int, 0, len(weights))
+		for v := range weights {
+			versions = append(versions, v)
+		}
+		if err := tx.Model(&entity.PromptTemplate{}).
+			Where("name = ? AND status = ? AND version NOT IN ?", name, entity.PromptStatusActive, versions).
+			Update("status", entity.PromptStatusArchived).Error; err != nil {
+			return err
+		}
+		for v, w := range weights {
+			if err := tx.Model(&entity.PromptTemplate{}).
+				Where("name = ? AND version = ?", name, v).
+				Updates(map[string]interface{}{"status": entity.PromptStatusActive, "weight": w}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CountUsages 统计模板版本被任务使用的次数
+func (r *PromptRepository) CountUsages(ctx context.Context, name string, version int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.PromptUsage{}).Where("name = ? AND version = ?", name, version).Count(&count).Error
+	return count, err
+}
+
+// CreateUsage 记录任务使用的提示词版本
+func (r *PromptRepository) CreateUsage(ctx context.Context, usage *entity.PromptUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+// Stats 按版本统计使用次数及任务成败（任务完成后才写入 tasks 表）
+func (r *PromptRepository) Stats(ctx context.Context, name string) ([]PromptVersionStats, error) {
+	var stats []PromptVersionStats
+	err := r.db.WithContext(ctx).Table("prompt_usages AS pu").
+		Select(`pu.version AS version, COUNT(*) AS uses,
+			SUM(CASE WHEN t.status = ? THEN 1 ELSE 0 END) AS completed,
+			SUM(CASE WHEN t.status = ? THEN 1 ELSE 0 END) AS failed`, entity.TaskStatusCompleted, entity.TaskStatusFailed).
+		Joins("LEFT JOIN tasks AS t ON t.id = pu.task_id").
+		Where("pu.name = ?", name).
+		Group("pu.version").
+		Order("pu.version DESC").
+		Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StoryboardRepository struct {
+	db *gorm.DB
+}
+
+func NewStoryboardRepository(db *gorm.DB) *StoryboardRepository {
+	return &StoryboardRepository{db: db}
+}
+
+// Create 创建分镜稿及其分镜
+func (r *StoryboardRepository) Create(ctx context.Context, storyboard *entity.Storyboard) error {
+	return r.db.WithContext(ctx).Create(storyboard).Error
+}
+
+// GetByTask 按任务ID获取分镜稿，分镜按顺序返回
+func (r *StoryboardRepository) GetByTask(ctx context.Context, taskID uuid.UUID) (*entity.Storyboard, error) {
+	var storyboard entity.Storyboard
+	err := r.db.WithContext(ctx).
+		Preload("Panels", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("task_id = ?", taskID).
+		First(&storyboard).Error
+	if err != nil {
+		return nil, err
+	}
+	return &storyboard, nil
+}
+
+// UpdateColumns 只更新分镜稿的指定字段，同时刷新 updated_at
+func (r *StoryboardRepository) UpdateColumns(ctx context.Context, id uuid.UUID, columns map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Storyboard{ID: id}).Updates(columns).Error
+}
+
+// SaveComposition 保存合成的配音和视频；分镜稿在 loadedAt 之后被修改过时保留配音过期标记
+func (r *StoryboardRepository) SaveComposition(ctx context.Context, id uuid.UUID, loadedAt time.Time, columns map[string]interface{}) error {
+	columns["audio_stale"] = gorm.Expr("CASE WHEN updated_at = ? THEN FALSE ELSE audio_stale END", loadedAt)
+	return r.UpdateColumns(ctx, id, columns)
+}
+
+// GetPanel 获取分镜稿中的单格分镜
+func (r *StoryboardRepository) GetPanel(ctx context.Context, storyboardID, panelID uuid.UUID) (*entity.StoryboardPanel, error) {
+	var panel entity.StoryboardPanel
+	err := r.db.WithContext(ctx).Where("id = ? AND storyboard_id = ?", panelID, storyboardID).First(&panel).Error
+	if err != nil {
+		return nil, err
+	}
+	return &panel, nil
+}
+
+// UpdatePanelColumns 只更新单格分镜的指定字段，避免覆盖重绘、合成等并发任务写入的其它字段
+func (r *StoryboardRepository) UpdatePanelColumns(ctx context.Context, panelID uuid.UUID, columns map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.StoryboardPanel{ID: panelID}).Updates(columns).Error
+}
+
+// SetPositions 按给定顺序重排分镜
+func (r *StoryboardRepository) SetPositions(ctx context.Context, storyboardID uuid.UUID, panelIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range panelIDs {
+			err := tx.Model(&entity.StoryboardPanel{}).
+				Where("id = ? AND storyboard_id = ?", id, storyboardID).
+				Update("position", i).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeletePanel 删除单格分镜，后续分镜顺序前移
+func (r *StoryboardRepository) DeletePanel(ctx context.Context, panel *entity.StoryboardPanel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.StoryboardPanel{}, "id = ?", panel.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.StoryboardPanel{}).
+			Where("storyboard_id = ? AND position > ?", panel.StoryboardID, panel.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"comic_video/internal/domain/entity"
 
@@ -32,14 +34,34 @@ func (r *TemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity
 	return &template, nil
 }
 
-// List 获取模板列表
-func (r *TemplateRepository) List(ctx context.Context, offset, limit int, filter map[string]interface{}) ([]*entity.Template, int64, error) {
+// 模板列表排序方式
+const (
+	TemplateSortLatest   = "latest"   // 最新创建
+	TemplateSortPopular  = "popular"  // 累计使用次数
+	TemplateSortRating   = "rating"   // 评分
+	TemplateSortTrending = "trending" // 近期热度
+)
+
+// 热度排序参数：近期应用按天数衰减累加，评分按贝叶斯平均折算后加权
+const (
+	trendingWindowDays  = 30  // 只统计最近30天的应用记录
+	trendingPriorCount  = 5   // 评分先验票数，评分人数少时向先验均值收敛
+	trendingPriorRating = 3.0 // 评分先验均值
+	trendingRatingScale = 2.0 // 评分权重
+)
+
+// List 获取模板列表，keyword 匹配名称、描述和标签
+func (r *TemplateRepository) List(ctx context.Context, offset, limit int, filter map[string]interface{}, keyword, sort string) ([]*entity.Template, int64, error) {
 	var templates []*entity.Template
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&entity.Template{})
 	for k, v := range filter {
-		query = query.Where(k+" = ?", v)
+		query = query.Where("templates."+k+" = ?", v)
+	}
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("templates.name ILIKE ? OR templates.description ILIKE ? OR templates.tags ILIKE ?", like, like, like)
 	}
 
 	err := query.Count(&total).Error
@@ -47,7 +69,26 @@ func (r *TemplateRepository) List(ctx context.Context, offset, limit int, filter
 		return nil, 0, err
 	}
 
-	err = query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&templates).Error
+	switch sort {
+	case TemplateSortPopular:
+		query = query.Order("templates.download_count DESC").Order("templates.created_at DESC")
+	case TemplateSortRating:
+		query = query.Order("templates.rating DESC").Order("templates.rating_count DESC")
+	case TemplateSortTrending:
+		heat := r.db.Model(&entity.TemplateApply{}).
+			Select("template_id, SUM(1.0 / (1 + EXTRACT(EPOCH FROM (NOW() - created_at)) / 86400)) AS score").
+			Where("created_at > ?", time.Now().AddDate(0, 0, -trendingWindowDays)).
+			Group("template_id")
+		query = query.Select("templates.*").
+			Joins("LEFT JOIN (?) AS heat ON heat.template_id = templates.id", heat).
+			Order(fmt.Sprintf("COALESCE(heat.score, 0) + (templates.rating * templates.rating_count + %v) / (templates.rating_count + %d) * %v DESC",
+				trendingPriorRating*trendingPriorCount, trendingPriorCount, trendingRatingScale)).
+			Order("templates.created_at DESC")
+	default:
+		query = query.Order("templates.created_at DESC")
+	}
+
+	err = query.Offset(offset).Limit(limit).Find(&templates).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -55,12 +96,30 @@ func (r *TemplateRepository) List(ctx context.Context, offset, limit int, filter
 	return templates, total, nil
 }
 
-// Update 更新模板
-func (r *TemplateRepository) Update(ctx context.Context, template *entity.Template) error {
-	return r.db.WithContext(ctx).Save(template).Error
+// UpdateFields 只更新指定字段，避免整行写回覆盖并发的评分、使用次数和预览结果
+// 评分汇总只由 SaveRating / DeleteRating 在锁内写入，这里始终排除
+func (r *TemplateRepository) UpdateFields(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Template{}).Where("id = ?", id).
+		Omit("rating", "rating_count", "download_count").Updates(updates).Error
 }
 
 // Delete 删除模板
 func (r *TemplateRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&entity.Template{}, id).Error
-} 
\ No newline at end of file
+}
+
+// UpdatePreview 只更新预览相关字段，避免覆盖渲染期间对模板的其他修改
+func (r *TemplateRepository) UpdatePreview(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Template{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// RecordApply 记录一次模板应用并累加使用次数
+func (r *TemplateRepository) RecordApply(ctx context.Context, apply *entity.TemplateApply) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(apply).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.Template{}).Where("id = ?", apply.TemplateID).
+			UpdateColumn("download_count", gorm.Expr("download_count + 1")).Error
+	})
+}
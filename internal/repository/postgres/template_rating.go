@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveRating 保存用户对模板的评分，已评分时覆盖，并重新汇总模板评分
+func (r *TemplateRepository) SaveRating(ctx context.Context, rating *entity.TemplateRating) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTemplate(tx, rating.TemplateID); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "template_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "review", "updated_at"}),
+		}).Create(rating).Error
+		if err != nil {
+			return err
+		}
+		return refreshRating(tx, rating.TemplateID)
+	})
+}
+
+// DeleteRating 删除用户对模板的评分并重新汇总
+func (r *TemplateRepository) DeleteRating(ctx context.Context, templateID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockTemplate(tx, templateID); err != nil {
+			return err
+		}
+		res := tx.Where("template_id = ? AND user_id = ?", templateID, userID).Delete(&entity.TemplateRating{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshRating(tx, templateID)
+	})
+}
+
+// GetRating 获取用户对模板的评分
+func (r *TemplateRepository) GetRating(ctx context.Context, templateID, userID uuid.UUID) (*entity.TemplateRating, error) {
+	var rating entity.TemplateRating
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("template_id = ? AND user_id = ?", templateID, userID).First(&rating).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// ListRatings 获取模板的评价列表，最新的在前
+func (r *TemplateRepository) ListRatings(ctx context.Context, templateID uuid.UUID, offset, limit int) ([]*entity.TemplateRating, int64, error) {
+	var ratings []*entity.TemplateRating
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&entity.TemplateRating{}).Where("template_id = ?", templateID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("User").Offset(offset).Limit(limit).Order("updated_at DESC").Find(&ratings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return ratings, total, nil
+}
+
+// lockTemplate 锁定模板行，同一模板的评分修改依次执行，后提交的事务汇总时总能看到先提交的评分
+func lockTemplate(tx *gorm.DB, templateID uuid.UUID) error {
+	var template entity.Template
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", templateID).First(&template).Error
+}
+
+// refreshRating 按评分记录重新计算模板的平均分和评分人数
+func refreshRating(tx *gorm.DB, templateID uuid.UUID) error {
+	var agg struct {
+		Avg   float64
+		Count int
+	}
+	err := tx.Model(&entity.TemplateRating{}).
+		Select("COALESCE(AVG(score), 0) AS avg, COUNT(*) AS count").
+		Where("template_id = ?", templateID).
+		Scan(&agg).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&entity.Template{}).Where("id = ?", templateID).
+		UpdateColumns(map[string]interface{}{"rating": agg.Avg, "rating_count": agg.Count}).Error
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TranscriptRepository struct {
+	db *gorm.DB
+}
+
+func NewTranscriptRepository(db *gorm.DB) *TranscriptRepository {
+	return &TranscriptRepository{db: db}
+}
+
+// Create 创建识别记录
+func (r *TranscriptRepository) Create(ctx context.Context, transcript *entity.Transcript) error {
+	return r.db.WithContext(ctx).Create(transcript).Error
+}
+
+// GetLatestByVideo 获取视频最近一次的识别结果
+func (r *TranscriptRepository) GetLatestByVideo(ctx context.Context, videoID uuid.UUID) (*entity.Transcript, error) {
+	var transcript entity.Transcript
+	err := r.db.WithContext(ctx).Where("video_id = ?", videoID).Order("created_at DESC").First(&transcript).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+// DeleteByVideo 删除视频的所有识别记录
+func (r *TranscriptRepository) DeleteByVideo(ctx context.Context, videoID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("video_id = ?", videoID).Delete(&entity.Transcript{}).Error
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Create 创建上传会话
+func (r *UploadRepository) Create(ctx context.Context, session *entity.UploadSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// GetByID 获取上传会话
+func (r *UploadRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.UploadSession, error) {
+	var session entity.UploadSession
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByIDAndUser 获取用户自己的上传会话
+func (r *UploadRepository) GetByIDAndUser(ctx context.Context, id, userID uuid.UUID) (*entity.UploadSession, error) {
+	var session entity.UploadSession
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// UpdateFields 更新上传会话的部分字段
+func (r *UploadRepository) UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.UploadSession{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// Transition 仅当会话处于 from 状态时更新为 to，返回是否更新成功，用于避免重复合并或清理
+func (r *UploadRepository) Transition(ctx context.Context, id uuid.UUID, from, to string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entity.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ListExpired 列出已过期但仍在上传中的会话
+func (r *UploadRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*entity.UploadSession, error) {
+	var sessions []*entity.UploadSession
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", entity.UploadStatusUploading, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// ListByStatus 列出指定状态的全部会话
+func (r *UploadRepository) ListByStatus(ctx context.Context, status string) ([]*entity.UploadSession, error) {
+	var sessions []*entity.UploadSession
+	err := r.db.WithContext(ctx).Where("status = ?", status).Find(&sessions).Error
+	return sessions, err
+}
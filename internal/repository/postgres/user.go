@@ -92,4 +92,19 @@ func (r *UserRepository) GetByStatus(ctx context.Context, status string) ([]*ent
 // UpdateStatus 更新用户状态
 func (r *UserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Update("status", status).Error
-} 
\ No newline at end of file
+}
+
+// PromoteAdmins 将指定邮箱的用户设为管理员，返回角色发生变化的用户ID
+func (r *UserRepository) PromoteAdmins(ctx context.Context, emails []string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.User{}).
+			Where("email IN ? AND role <> ?", emails, entity.RoleAdmin).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&entity.User{}).Where("id IN ?", ids).Update("role", entity.RoleAdmin).Error
+	})
+	return ids, err
+}
@@ -90,6 +90,18 @@ func (r *VideoRepository) Update(ctx context.Context, video *entity.Video) error
 	return r.db.WithContext(ctx).Save(video).Error
 }
 
+// UpdateFields 只更新指定字段，避免覆盖后台任务期间对视频的其他修改
+func (r *VideoRepository) UpdateFields(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&entity.Video{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListByStatus 获取指定状态的全部视频
+func (r *VideoRepository) ListByStatus(ctx context.Context, status string) ([]*entity.Video, error) {
+	var videos []*entity.Video
+	err := r.db.WithContext(ctx).Where("status = ?", status).Find(&videos).Error
+	return videos, err
+}
+
 // Delete 删除视频
 func (r *VideoRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&entity.Video{}, id).Error
@@ -0,0 +1,586 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalRoutePrefix 本地存储对象读写接口的路径前缀，与路由注册保持一致
+const LocalRoutePrefix = "/api/v1/storage"
+
+const (
+	// localURLLifetime GetURL 返回的签名地址有效期，按天对齐过期时间，同一天内地址不变便于客户端缓存
+	localURLLifetime = 7 * 24 * time.Hour
+	// multipartDir 分片上传临时目录，以 . 开头的目录不能作为存储桶
+	multipartDir = ".multipart"
+)
+
+var (
+	// ErrInvalidSignature 签名不匹配或已过期
+	ErrInvalidSignature = errors.New("签名无效或已过期")
+	// ErrEntityTooLarge 直传文件超过表单允许的大小
+	ErrEntityTooLarge = errors.New("文件超过允许的大小")
+	// ErrInvalidForm 直传表单格式错误或缺少文件
+	ErrInvalidForm = errors.New("直传表单无效")
+	// errUploadNotFound 分片上传不存在或已结束
+	errUploadNotFound = errors.New("分片上传不存在")
+)
+
+var _ BlobStore = (*Local)(nil)
+
+// Local 本地磁盘存储实现，对象保存为 root/<存储桶>/<对象名>
+// 下载和直传地址指向 API 自身的签名接口，整个系统可在单机上运行，无需 MinIO
+type Local struct {
+	root    string
+	bucket  string // 默认存储桶
+	baseURL string // API 外部可访问地址
+	key     []byte // 签名密钥
+}
+
+// NewLocal 创建本地存储，root 不存在时创建
+func NewLocal(root, bucket, baseURL, signingKey string) (*Local, error) {
+	if signingKey == "" {
+		return nil, errors.New("本地存储缺少签名密钥")
+	}
+	if err := os.MkdirAll(filepath.Join(root, bucket), 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return &Local{
+		root:    root,
+		bucket:  bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		key:     []byte(signingKey),
+	}, nil
+}
+
+// Upload 上传文件，先写临时文件再改名，读取方不会看到写了一半的文件
+func (l *Local) Upload(ctx context.Context, objectName string, reader io.Reader, objectSize int64, contentType string) (string, error) {
+	target, err := l.objectPath(l.bucket, objectName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writeFile(target, reader); err != nil {
+		return "", err
+	}
+	return l.GetURL(objectName), nil
+}
+
+// Open 读取对象内容，不存在时返回 ErrObjectNotFound
+func (l *Local) Open(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	return l.openFile(l.bucket, objectName)
+}
+
+// Delete 删除文件，不存在时忽略
+func (l *Local) Delete(ctx context.Context, objectName string) error {
+	target, err := l.objectPath(l.bucket, objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix 删除前缀下的所有对象
+func (l *Local) DeletePrefix(ctx context.Context, prefix string) error {
+	bucketDir, err := l.bucketPath(l.bucket)
+	if err != nil {
+		return err
+	}
+	// 只遍历前缀所在的目录
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+	walkRoot := filepath.Join(bucketDir, filepath.FromSlash(path.Clean("/"+dir)))
+	err = filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetURL 获取文件外部可访问URL
+func (l *Local) GetURL(objectName string) string {
+	expires := time.Now().Truncate(24 * time.Hour).Add(localURLLifetime)
+	return l.signedURL(l.bucket, objectName, expires).String()
+}
+
+// PresignedURL 获取带签名的临时访问URL
+func (l *Local) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	if _, err := l.objectPath(l.bucket, objectName); err != nil {
+		return "", err
+	}
+	return l.signedURL(l.bucket, objectName, time.Now().Add(expiry)).String(), nil
+}
+
+// CopyObject 复制对象，同一文件系统内使用硬链接
+func (l *Local) CopyObject(ctx context.Context, srcObject, dstObject string) error {
+	src, err := l.objectPath(l.bucket, srcObject)
+	if err != nil {
+		return err
+	}
+	dst, err := l.objectPath(l.bucket, dstObject)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return ErrObjectNotFound
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	// 先链接到临时名再改名，覆盖已有对象时读者不会看到文件缺失
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".link-%d", time.Now().UnixNano()))
+	if err := os.Link(src, tmp); err == nil {
+		if err := os.Rename(tmp, dst); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = writeFile(dst, file)
+	return err
+}
+
+// NewMultipartUpload 发起分片上传，分片保存在 root/.multipart/<uploadID> 下
+func (l *Local) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	if _, err := l.objectPath(l.bucket, objectName); err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Join(l.root, multipartDir, uploadID), 0755); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// PutObjectPart 上传单个分片，同一分片号重复上传时覆盖，sha256Hex 非空时校验内容
+// 分片文件名为 <分片号>-<MD5>，MD5 作为 ETag
+func (l *Local) PutObjectPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64, sha256Hex string) (*Part, error) {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	md5sum, sha := md5.New(), sha256.New()
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(io.MultiWriter(tmp, md5sum, sha), io.LimitReader(reader, size))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if sha256Hex != "" && hex.EncodeToString(sha.Sum(nil)) != sha256Hex {
+		return nil, ErrChecksumMismatch
+	}
+
+	etag := hex.EncodeToString(md5sum.Sum(nil))
+	old, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%05d-*", partNumber)))
+	for _, p := range old {
+		_ = os.Remove(p)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, partFileName(partNumber, etag))); err != nil {
+		return nil, err
+	}
+	return &Part{PartNumber: partNumber, ETag: etag, Size: n}, nil
+}
+
+// ListObjectParts 列出分片上传中已上传的分片，按分片号升序
+func (l *Local) ListObjectParts(ctx context.Context, objectName, uploadID string) ([]Part, error) {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	// ReadDir 按文件名排序，分片号补零后即为升序
+	var parts []Part
+	for _, e := range entries {
+		number, etag, ok := strings.Cut(e.Name(), "-")
+		if !ok || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		partNumber, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{PartNumber: partNumber, ETag: etag, Size: info.Size()})
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload 按给定顺序拼接分片为完整对象并删除分片
+func (l *Local) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	target, err := l.objectPath(l.bucket, objectName)
+	if err != nil {
+		return err
+	}
+	files := make([]io.Reader, 0, len(parts))
+	for _, p := range parts {
+		file, err := os.Open(filepath.Join(dir, partFileName(p.PartNumber, p.ETag)))
+		if err != nil {
+			return fmt.Errorf("分片 %d 不存在或已被覆盖", p.PartNumber)
+		}
+		defer file.Close()
+		files = append(files, file)
+	}
+	if _, err := writeFile(target, io.MultiReader(files...)); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// AbortMultipartUpload 取消分片上传并删除已上传的分片
+func (l *Local) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// PresignedPostPolicy 生成直传表单，提交到 API 的本地存储接口
+// 签名覆盖对象名、Content-Type、最大字节数和过期时间，表单字段与 MinIO 一样需放在文件之前
+func (l *Local) PresignedPostPolicy(ctx context.Context, objectName, contentType string, maxSize int64, sha256Hex string, expiry time.Duration) (string, map[string]string, error) {
+	if _, err := l.objectPath(l.bucket, objectName); err != nil {
+		return "", nil, err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	size := strconv.FormatInt(maxSize, 10)
+	fields := map[string]string{
+		"key":             objectName,
+		"Content-Type":    contentType,
+		"max-size":        size,
+		"checksum-sha256": sha256Hex,
+		"expires":         expires,
+		"signature":       l.sign("POST", l.bucket, objectName, contentType, size, sha256Hex, expires),
+	}
+	return l.baseURL + LocalRoutePrefix + "/" + url.PathEscape(l.bucket), fields, nil
+}
+
+// StatObject 获取对象的元信息，不存在时返回 ErrObjectNotFound
+func (l *Local) StatObject(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	target, err := l.objectPath(l.bucket, objectName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:          objectName,
+		Size:         info.Size(),
+		ContentType:  detectContentType(target),
+		ETag:         fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// ListObjects 列出前缀下的所有对象
+func (l *Local) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	bucketDir, err := l.bucketPath(l.bucket)
+	if err != nil {
+		return nil, err
+	}
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+	walkRoot := filepath.Join(bucketDir, filepath.FromSlash(path.Clean("/"+dir)))
+	var list []ObjectInfo
+	err = filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			list = append(list, ObjectInfo{
+				Key:          key,
+				Size:         info.Size(),
+				ETag:         fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+				LastModified: info.ModTime(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// OpenSigned 校验下载签名后打开对象，供本地存储的下载接口使用，调用方负责关闭
+func (l *Local) OpenSigned(bucketName, objectName, expires, signature string) (*os.File, error) {
+	if !l.verify(expires, signature, "GET", bucketName, objectName, expires) {
+		return nil, ErrInvalidSignature
+	}
+	return l.openFile(bucketName, objectName)
+}
+
+// openFile 打开对象文件，不存在或为目录时返回 ErrObjectNotFound
+func (l *Local) openFile(bucketName, objectName string) (*os.File, error) {
+	target, err := l.objectPath(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		file.Close()
+		return nil, ErrObjectNotFound
+	}
+	return file, nil
+}
+
+// ReceivePost 接收 PresignedPostPolicy 生成的直传表单：校验签名后保存文件，超过大小限制时返回 ErrEntityTooLarge 且不保留文件
+func (l *Local) ReceivePost(bucketName string, form *multipart.Reader) error {
+	fields := make(map[string]string)
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			return fmt.Errorf("%w: 缺少文件", ErrInvalidForm)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidForm, err)
+		}
+		if part.FormName() != "file" {
+			value, err := io.ReadAll(io.LimitReader(part, 4096))
+			if err != nil {
+				return err
+			}
+			fields[part.FormName()] = string(value)
+			continue
+		}
+
+		key, contentType, checksum := fields["key"], fields["Content-Type"], fields["checksum-sha256"]
+		if !l.verify(fields["expires"], fields["signature"], "POST", bucketName, key, contentType, fields["max-size"], checksum, fields["expires"]) {
+			return ErrInvalidSignature
+		}
+		maxSize, err := strconv.ParseInt(fields["max-size"], 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		target, err := l.objectPath(bucketName, key)
+		if err != nil {
+			return err
+		}
+		var reader io.Reader = &sizeLimitReader{r: part, remaining: maxSize}
+		if checksum != "" {
+			reader = &sha256Reader{r: reader, h: sha256.New(), expected: checksum}
+		}
+		_, err = writeFile(target, reader)
+		return err
+	}
+}
+
+// sha256Reader 读完时校验内容的 SHA-256，不一致时返回 ErrChecksumMismatch，写入中断
+type sha256Reader struct {
+	r        io.Reader
+	h        hash.Hash
+	expected string
+}
+
+func (s *sha256Reader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.h.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(s.h.Sum(nil)) != s.expected {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// sizeLimitReader 读取超过 remaining 字节时返回 ErrEntityTooLarge，写入中断，临时文件不会改名为对象
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (s *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.remaining -= int64(n)
+	if s.remaining < 0 {
+		return n, ErrEntityTooLarge
+	}
+	return n, err
+}
+
+// signedURL 拼接 API 下载地址
+func (l *Local) signedURL(bucketName, objectName string, expiresAt time.Time) *url.URL {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	u, _ := url.Parse(l.baseURL + LocalRoutePrefix)
+	u = u.JoinPath(bucketName, objectName)
+	u.RawQuery = url.Values{
+		"expires":   {expires},
+		"signature": {l.sign("GET", bucketName, objectName, expires)},
+	}.Encode()
+	return u
+}
+
+// sign 对各字段按行拼接后计算 HMAC-SHA256
+func (l *Local) sign(fields ...string) string {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify 校验签名且未过期
+func (l *Local) verify(expires, signature string, fields ...string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(l.sign(fields...)), []byte(signature)) == 1
+}
+
+// bucketPath 存储桶目录，拒绝路径分隔符和以 . 开头的名称
+func (l *Local) bucketPath(bucketName string) (string, error) {
+	if bucketName == "" || strings.HasPrefix(bucketName, ".") || strings.ContainsAny(bucketName, `/\`) {
+		return "", ErrObjectNotFound
+	}
+	return filepath.Join(l.root, bucketName), nil
+}
+
+// objectPath 对象对应的本地路径，对象名中的 .. 不能越出存储桶目录
+func (l *Local) objectPath(bucketName, objectName string) (string, error) {
+	dir, err := l.bucketPath(bucketName)
+	if err != nil {
+		return "", err
+	}
+	name := path.Clean("/" + objectName)
+	if name == "/" {
+		return "", ErrObjectNotFound
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+// uploadDir 分片上传目录
+func (l *Local) uploadDir(uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", errUploadNotFound
+	}
+	dir := filepath.Join(l.root, multipartDir, uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		return "", errUploadNotFound
+	}
+	return dir, nil
+}
+
+func partFileName(partNumber int, etag string) string {
+	return fmt.Sprintf("%05d-%s", partNumber, etag)
+}
+
+// writeFile 写入同目录下的临时文件后改名为 target，返回写入的字节数
+func writeFile(target string, reader io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, reader)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), target)
+}
+
+// detectContentType 按扩展名推断，没有扩展名时（如内容寻址文件）按文件头识别
+func detectContentType(p string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(p)); ct != "" {
+		return ct
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	return http.DetectContentType(head[:n])
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ BlobStore = (*minioStore)(nil)
+
+// minioStore MinIO / S3 存储实现
+type minioStore struct {
+	client     *minio.Client
+	core       *minio.Core
+	bucketName string
+	publicHost string // 用于拼接外部可访问URL，直传地址也替换为该地址
+}
+
+// NewMinIO 连接 MinIO，默认存储桶不存在时创建
+func NewMinIO(endpoint, accessKey, secretKey, bucket, publicHost string, useSSL bool) (BlobStore, error) {
+	cli, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	exists, err := cli.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("连接MinIO失败: %w", err)
+	}
+	if !exists {
+		err = cli.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("创建存储桶失败: %w", err)
+		}
+	}
+
+	return &minioStore{
+		client:     cli,
+		core:       &minio.Core{Client: cli},
+		bucketName: bucket,
+		publicHost: publicHost,
+	}, nil
+}
+
+// Upload 上传文件
+func (c *minioStore) Upload(ctx context.Context, objectName string, reader io.Reader, objectSize int64, contentType string) (string, error) {
+	_, err := c.client.PutObject(ctx, c.bucketName, objectName, reader, objectSize, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", err
+	}
+	return c.GetURL(objectName), nil
+}
+
+// Open 读取对象内容，不存在时返回 ErrObjectNotFound
+func (c *minioStore) Open(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	object, err := c.client.GetObject(ctx, c.bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject 不发请求，先取元信息以便对象不存在时立即返回
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+// Delete 删除文件
+func (c *minioStore) Delete(ctx context.Context, objectName string) error {
+	return c.client.RemoveObject(ctx, c.bucketName, objectName, minio.RemoveObjectOptions{})
+}
+
+// DeletePrefix 删除前缀下的所有对象，用于清理 HLS 分片等成组文件
+func (c *minioStore) DeletePrefix(ctx context.Context, prefix string) error {
+	objects := c.client.ListObjects(ctx, c.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+	for object := range objects {
+		if object.Err != nil {
+			return object.Err
+		}
+		if err := c.client.RemoveObject(ctx, c.bucketName, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetURL 获取文件外部可访问URL
+func (c *minioStore) GetURL(objectName string) string {
+	if c.publicHost != "" {
+		return c.publicHost + "/" + c.bucketName + "/" + objectName
+	}
+	return ""
+}
+
+// PresignedURL 获取带签名的临时访问URL
+func (c *minioStore) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	url, err := c.client.PresignedGetObject(ctx, c.bucketName, objectName, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return url.String(), nil
+}
+
+// CopyObject 复制对象，超过 5GB 的对象由服务端分片复制
+func (c *minioStore) CopyObject(ctx context.Context, srcObject, dstObject string) error {
+	_, err := c.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: c.bucketName, Object: dstObject},
+		minio.CopySrcOptions{Bucket: c.bucketName, Object: srcObject},
+	)
+	return err
+}
+
+// NewMultipartUpload 发起分片上传，返回 uploadID
+func (c *minioStore) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	return c.core.NewMultipartUpload(ctx, c.bucketName, objectName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+}
+
+// PutObjectPart 上传单个分片，sha256Hex 非空时由存储端校验内容
+func (c *minioStore) PutObjectPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64, sha256Hex string) (*Part, error) {
+	part, err := c.core.PutObjectPart(ctx, c.bucketName, objectName, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{
+		Sha256Hex: sha256Hex,
+	})
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "XAmzContentSHA256Mismatch", "BadDigest":
+			return nil, ErrChecksumMismatch
+		}
+		return nil, err
+	}
+	return &Part{PartNumber: part.PartNumber, ETag: part.ETag, Size: part.Size}, nil
+}
+
+// ListObjectParts 列出分片上传中已上传的分片，按分片号升序
+func (c *minioStore) ListObjectParts(ctx context.Context, objectName, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		result, err := c.core.ListObjectParts(ctx, c.bucketName, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result.ObjectParts {
+			parts = append(parts, Part{PartNumber: p.PartNumber, ETag: p.ETag, Size: p.Size})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// CompleteMultipartUpload 按分片号合并分片为完整对象
+func (c *minioStore) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error {
+	complete := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		complete[i] = minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag}
+	}
+	_, err := c.core.CompleteMultipartUpload(ctx, c.bucketName, objectName, uploadID, complete, minio.PutObjectOptions{})
+	return err
+}
+
+// AbortMultipartUpload 取消分片上传并删除已上传的分片
+func (c *minioStore) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	return c.core.AbortMultipartUpload(ctx, c.bucketName, objectName, uploadID)
+}
+
+// PresignedPostPolicy 生成浏览器直传表单：限定对象名、Content-Type、最大字节数和内容的 SHA-256，返回提交地址和需要附带的表单字段
+func (c *minioStore) PresignedPostPolicy(ctx context.Context, objectName, contentType string, maxSize int64, sha256Hex string, expiry time.Duration) (string, map[string]string, error) {
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(c.bucketName); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetKey(objectName); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetContentType(contentType); err != nil {
+		return "", nil, err
+	}
+	if err := policy.SetContentLengthRange(1, maxSize); err != nil {
+		return "", nil, err
+	}
+	if sha256Hex != "" {
+		sum, err := hex.DecodeString(sha256Hex)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := policy.SetChecksum(minio.NewChecksum(minio.ChecksumSHA256, sum)); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := policy.SetExpires(time.Now().UTC().Add(expiry)); err != nil {
+		return "", nil, err
+	}
+	u, formData, err := c.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return "", nil, err
+	}
+	// 表单签名不包含主机名，可直接替换为外部可访问地址
+	if public, err := url.Parse(c.publicHost); err == nil && public.Host != "" {
+		u.Scheme, u.Host = public.Scheme, public.Host
+	}
+	return u.String(), formData, nil
+}
+
+// StatObject 获取对象的元信息，不存在时返回 ErrObjectNotFound
+// 上传时带了 SHA-256 校验和的对象同时返回存储端记录的校验和，分片合并的对象只有组合校验和，不返回
+func (c *minioStore) StatObject(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	info, err := c.client.StatObject(ctx, c.bucketName, objectName, minio.StatObjectOptions{Checksum: true})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	object := &ObjectInfo{Key: info.Key, Size: info.Size, ContentType: info.ContentType, ETag: info.ETag, LastModified: info.LastModified}
+	if sum, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256); err == nil && len(sum) == sha256.Size {
+		object.ChecksumSHA256 = hex.EncodeToString(sum)
+	}
+	return object, nil
+}
+
+// ListObjects 列出前缀下的所有对象
+func (c *minioStore) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var list []ObjectInfo
+	for object := range c.client.ListObjects(ctx, c.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		list = append(list, ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			ContentType:  object.ContentType,
+			ETag:         object.ETag,
+			LastModified: object.LastModified,
+		})
+	}
+	return list, nil
+}
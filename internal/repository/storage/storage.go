@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"comic_video/internal/config"
+)
+
+// 存储驱动
+const (
+	DriverMinIO = "minio"
+	DriverLocal = "local"
+)
+
+// BlobStore 对象存储接口，所有对象都在默认存储桶中，按对象名前缀区分用途
+type BlobStore interface {
+	Upload(ctx context.Context, objectName string, reader io.Reader, objectSize int64, contentType string) (string, error)
+	// Open 读取对象内容，不存在时返回 ErrObjectNotFound，调用方负责关闭
+	Open(ctx context.Context, objectName string) (io.ReadCloser, error)
+	Delete(ctx context.Context, objectName string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+	GetURL(objectName string) string
+	PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	CopyObject(ctx context.Context, srcObject, dstObject string) error
+
+	// 分片上传
+	NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error)
+	PutObjectPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64, sha256Hex string) (*Part, error)
+	ListObjectParts(ctx context.Context, objectName, uploadID string) ([]Part, error)
+	CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error
+	AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error
+
+	// 直传，sha256Hex 为文件的 SHA-256，由存储端在上传时校验
+	PresignedPostPolicy(ctx context.Context, objectName, contentType string, maxSize int64, sha256Hex string, expiry time.Duration) (string, map[string]string, error)
+	StatObject(ctx context.Context, objectName string) (*ObjectInfo, error)
+
+	// ListObjects 列出前缀下的所有对象
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	// ChecksumSHA256 存储端在写入时记录并校验过的整个对象的 SHA-256（十六进制），存储不提供时为空
+	ChecksumSHA256 string
+}
+
+// Part 已上传的分片
+type Part struct {
+	PartNumber int
+	ETag       string
+	Size       int64
+}
+
+var (
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("对象不存在")
+	// ErrChecksumMismatch 分片内容与声明的 SHA-256 不一致
+	ErrChecksumMismatch = errors.New("分片校验和不一致")
+)
+
+// Open 按配置创建对象存储，存储不可用时返回错误
+func Open(cfg *config.Config) (BlobStore, error) {
+	switch cfg.Storage.Driver {
+	case DriverMinIO, "":
+		return NewMinIO(
+			cfg.MinIO.Endpoint,
+			cfg.MinIO.AccessKeyID,
+			cfg.MinIO.SecretAccessKey,
+			cfg.MinIO.BucketName,
+			cfg.MinIO.PublicHost,
+			cfg.MinIO.UseSSL,
+		)
+	case DriverLocal:
+		key := cfg.Storage.SigningKey
+		if key == "" {
+			key = cfg.JWT.SecretKey
+		}
+		return NewLocal(cfg.Storage.LocalRoot, cfg.MinIO.BucketName, cfg.Storage.PublicURL, key)
+	}
+	return nil, fmt.Errorf("不支持的存储驱动: %s", cfg.Storage.Driver)
+}
+
+// ResolveURL 将记录中保存的对象名转换为访问地址，构建响应时调用，不要把结果写回数据库。
+// 外部地址原样返回
+func ResolveURL(store BlobStore, ref string) string {
+	if ref == "" || strings.Contains(ref, "://") || strings.HasPrefix(ref, "/") {
+		return ref
+	}
+	return store.GetURL(ref)
+}
@@ -9,7 +9,7 @@ import (
 
 	"comic_video/internal/domain/entity"
 	"comic_video/internal/repository/redis"
-	"comic_video/internal/repository/minio"
+	"comic_video/internal/repository/storage"
 	"encoding/base64"
 	"io/ioutil"
 	"os"
@@ -19,17 +19,24 @@ import (
 )
 
 // ProcessNovelToVideo: 小说转动漫视频一键生成主流程
-func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *redis.Client, sd *SDClient, ollama *OllamaClient, tts *TTSClient, minioBucket string) error {
+// 依赖的文本、图片、配音能力由 providers 提供，见 ResolveTaskProviders
+func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *redis.Client, providers *Providers, minioBucket string) error {
 	task.Status = entity.TaskStatusProcessing
 	task.Progress = 5
 	task.UpdatedAt = time.Now()
 	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
 
-	log.Printf("[AI] Ollama模型: %s", ollama.Model)
-	log.Printf("[AI] Ollama状态: endpoint=%s apikey=%s", ollama.Endpoint, ollama.ApiKey)
+	if providers.TextGen == nil || providers.ImageGen == nil || providers.TTS == nil {
+		log.Printf("[AI] 任务失败: AI提供方未配置 task=%v providers=%+v", task.ID, providers.Names)
+		return failTask(ctx, task, redisClient, "AI提供方未配置")
+	}
+	log.Printf("[AI] 使用提供方: task=%v providers=%+v", task.ID, providers.Names)
 
 	log.Printf("[AI] 开始分镜生成: task=%v", task.ID)
-	var req struct{ Novel string `json:"novel"` }
+	var req struct {
+		Novel  string     `json:"novel"`
+		Voices *VoiceCast `json:"voices"`
+	}
 	_ = json.Unmarshal([]byte(task.Params), &req)
 	if req.Novel == "" {
 		task.Status = entity.TaskStatusFailed
@@ -40,22 +47,29 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 		return fmt.Errorf("novel empty")
 	}
 
-	// 优化prompt，适配中文和结构化输出
-	ollamaPrompt := fmt.Sprintf(`你是一个漫画分镜脚本专家。请将以下小说内容拆分为分镜，每一镜一句话，直接输出JSON数组（如 [\"镜头1描述\", \"镜头2描述\", ...] ），不要输出其它内容、不要输出<think>、不要输出解释说明。每个分镜要简洁、具体、有画面感。小说内容如下：%s`, req.Novel)
+	// 分镜提示词来自提示词模板库，分镜包含画面、旁白和角色台词
+	prompt, err := RenderPrompt(ctx, task, PromptStoryboard, storyboardVars(req.Novel, req.Voices))
+	if err != nil {
+		return failTask(ctx, task, redisClient, "分镜提示词渲染失败: "+err.Error())
+	}
 
 	var script string
-	var err error
+	var panels []StoryPanel
 	maxRetry := 3
 	for retry := 1; retry <= maxRetry; retry++ {
-		script, err = ollama.Generate(ollamaPrompt, nil)
+		// 重试时跳过缓存，避免再次取到同一份不合法的输出
+		callCtx := ctx
+		if retry > 1 {
+			callCtx = WithCacheRefresh(ctx)
+		}
+		script, err = providers.TextGen.Chat(callCtx, prompt.Messages(), prompt.Options(providers.TextGenOpts))
 		if err != nil {
 			log.Printf("[AI] 分镜生成失败: %v task=%v 第%d次", err, task.ID, retry)
 			continue
 		}
-		// 校验输出是否为JSON数组
-		var panelsTest []string
-		if json.Unmarshal([]byte(script), &panelsTest) == nil && len(panelsTest) > 0 {
-			log.Printf("[AI] 分镜生成成功: task=%v panels=%d 第%d次", task.ID, len(panelsTest), retry)
+		// 校验输出是否为合法分镜
+		if panels, err = parseStoryboard(script); err == nil {
+			log.Printf("[AI] 分镜生成成功: task=%v panels=%d 第%d次", task.ID, len(panels), retry)
 			break
 		}
 		log.Printf("[AI] 分镜输出不合法，第%d次: %s", retry, script)
@@ -71,34 +85,16 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 	}
 	task.Progress = 20
 	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
-	log.Printf("[AI] 分镜生成完成: task=%v script=%s", task.ID, script)
-
-	// 2. 解析分镜
-	var panels []string
-	if err := json.Unmarshal([]byte(script), &panels); err != nil || len(panels) == 0 {
-		// 容错：若不是JSON数组，按换行分割
-		panels = []string{}
-		for _, line := range splitLines(script) {
-			if line != "" {
-				panels = append(panels, line)
-			}
-		}
-		if len(panels) == 0 {
-			task.Status = entity.TaskStatusFailed
-			task.Error = "分镜解析失败"
-			task.UpdatedAt = time.Now()
-			_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
-			log.Printf("[AI] 分镜解析失败: task=%v", task.ID)
-			return fmt.Errorf("panel parse error")
-		}
-	}
 	log.Printf("[AI] 分镜解析完成: task=%v panels=%d", task.ID, len(panels))
 
 	// 3. 生成每格图片（SD）
 	images := make([]string, 0, len(panels))
+	rawImages := make([][]byte, 0, len(panels))
+	seeds := make([]int64, 0, len(panels))
 	for i, panel := range panels {
-		log.Printf("[AI] 开始生成第%d格图片: %s", i+1, panel)
-		img, err := sd.Txt2Img(panel, nil)
+		log.Printf("[AI] 开始生成第%d格图片: %s", i+1, panel.Scene)
+		opts, seed := PanelImageOptions(providers, panel.Scene, 0)
+		img, err := providers.ImageGen.Txt2Img(ctx, panel.Scene, opts)
 		if err != nil {
 			task.Status = entity.TaskStatusFailed
 			task.Error = fmt.Sprintf("第%d格图片生成失败: %v", i+1, err)
@@ -108,20 +104,31 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 			return err
 		}
 		images = append(images, encodeBase64(img.Data))
+		rawImages = append(rawImages, img.Data)
+		seeds = append(seeds, seed)
 		task.Progress = 20 + int(float64(i+1)/float64(len(panels))*40)
 		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
 		log.Printf("[AI] 第%d格图片生成完成: task=%v", i+1, task.ID)
 	}
 
-	// 4. 合成旁白文本
-	narration := ""
-	for _, panel := range panels {
-		narration += panel + "。"
+	// 保存分镜稿，任务完成后可逐格修改、重绘并重新合成；保存失败不影响本次生成
+	if panelStore != nil {
+		if err := panelStore.SavePanels(ctx, task, panels, seeds, rawImages); err != nil {
+			log.Printf("[AI] 保存分镜稿失败: %v task=%v", err, task.ID)
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", "novel2video_")
+	if err != nil {
+		return failTask(ctx, task, redisClient, "创建临时目录失败: "+err.Error())
 	}
 
 	log.Printf("[AI] 开始配音合成: task=%v", task.ID)
-	// 5. 配音（TTS）
-	audio, err := tts.Synthesize(narration, nil)
+	// 4. 配音（TTS）：旁白与台词逐句按说话人音色合成，再按分镜顺序拼接
+	track, err := SynthesizeStoryboard(ctx, providers, req.Voices, panels, tmpDir, func(done, total int) {
+		task.Progress = 60 + int(float64(done)/float64(total)*10)
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	})
 	if err != nil {
 		task.Status = entity.TaskStatusFailed
 		task.Error = "配音生成失败: " + err.Error()
@@ -132,11 +139,16 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 	}
 	task.Progress = 70
 	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
-	log.Printf("[AI] 配音合成完成: task=%v", task.ID)
+	log.Printf("[AI] 配音合成完成: task=%v segments=%d", task.ID, len(track.Segments))
+	if panelStore != nil {
+		if err := panelStore.SaveAudio(ctx, task, track); err != nil {
+			log.Printf("[AI] 保存配音音轨失败: %v task=%v", err, task.ID)
+		}
+	}
 
 	log.Printf("[AI] 开始视频合成: task=%v", task.ID)
-	// 6. 合成动漫视频（FFmpeg）
-	videoPath, err := ComposeVideoFromImagesAndAudio(images, audio)
+	// 5. 合成动漫视频（FFmpeg），每格画面按其配音时长显示
+	videoPath, err := ComposeVideoFromImagesAndAudio(tmpDir, images, track.PanelDurations, track.Path)
 	if err != nil {
 		task.Status = entity.TaskStatusFailed
 		task.Error = "视频合成失败: " + err.Error()
@@ -155,8 +167,9 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 	// 8. 写入最终结果
 	result := map[string]interface{}{
 		"url":    videoURL,
-		"images": images,
-		"panels": panels,
+		"images":   images,
+		"panels":   panels,
+		"segments": track.Segments,
 	}
 	b, _ := json.Marshal(result)
 	task.Status = entity.TaskStatusCompleted
@@ -168,29 +181,13 @@ func ProcessNovelToVideo(ctx context.Context, task *entity.Task, redisClient *re
 	return nil
 }
 
-// splitLines 工具函数
-func splitLines(s string) []string {
-	var lines []string
-	for _, line := range []byte(s) {
-		if line == '\n' || line == '\r' {
-			continue
-		}
-		lines = append(lines, string(line))
-	}
-	return lines
-}
-
 // encodeBase64 工具函数
 func encodeBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
-// ComposeVideoFromImagesAndAudio 伪实现
-func ComposeVideoFromImagesAndAudio(images []string, audio []byte) (string, error) {
-	tmpDir, err := ioutil.TempDir("", "novel2video_")
-	if err != nil {
-		return "", err
-	}
+// ComposeVideoFromImagesAndAudio 将图片按各自时长拼接并配上音轨，输出到 dir
+func ComposeVideoFromImagesAndAudio(dir string, images []string, durations []float64, audioPath string) (string, error) {
 	// 保存图片
 	imgFiles := make([]string, 0, len(images))
 	for i, imgBase64 := range images {
@@ -198,29 +195,30 @@ func ComposeVideoFromImagesAndAudio(images []string, audio []byte) (string, erro
 		if err != nil {
 			return "", err
 		}
-		imgPath := filepath.Join(tmpDir, fmt.Sprintf("img_%03d.png", i+1))
+		imgPath := filepath.Join(dir, fmt.Sprintf("img_%03d.png", i+1))
 		if err := ioutil.WriteFile(imgPath, imgData, 0644); err != nil {
 			return "", err
 		}
 		imgFiles = append(imgFiles, imgPath)
 	}
-	// 保存音频
-	audioPath := filepath.Join(tmpDir, "audio.wav")
-	if err := ioutil.WriteFile(audioPath, audio, 0644); err != nil {
-		return "", err
-	}
-	// 生成图片列表txt
-	listPath := filepath.Join(tmpDir, "images.txt")
+	// 生成图片列表txt，concat 要求末尾重复最后一张图片才会应用其 duration
+	listPath := filepath.Join(dir, "images.txt")
 	listFile, err := os.Create(listPath)
 	if err != nil {
 		return "", err
 	}
-	for _, img := range imgFiles {
+	for i, img := range imgFiles {
 		fmt.Fprintf(listFile, "file '%s'\n", img)
+		if i < len(durations) {
+			fmt.Fprintf(listFile, "duration %.3f\n", durations[i])
+		}
+	}
+	if len(imgFiles) > 0 {
+		fmt.Fprintf(listFile, "file '%s'\n", imgFiles[len(imgFiles)-1])
 	}
 	listFile.Close()
-	// 合成视频（假设每张图片2秒，音频自动对齐）
-	videoPath := filepath.Join(tmpDir, "output.mp4")
+	// 合成视频
+	videoPath := filepath.Join(dir, "output.mp4")
 	cmd := exec.Command("ffmpeg", "-y", "-f", "concat", "-safe", "0", "-i", listPath, "-i", audioPath, "-c:v", "libx264", "-c:a", "aac", "-shortest", "-pix_fmt", "yuv420p", videoPath)
 	err = cmd.Run()
 	if err != nil {
@@ -229,7 +227,7 @@ func ComposeVideoFromImagesAndAudio(images []string, audio []byte) (string, erro
 	return videoPath, nil
 }
 
-func UploadVideoToMinio(ctx context.Context, minioClient minio.MinioClient, bucket, videoPath string) (string, error) {
+func UploadVideoToMinio(ctx context.Context, store storage.BlobStore, bucket, videoPath string) (string, error) {
 	file, err := os.Open(videoPath)
 	if err != nil {
 		return "", err
@@ -244,7 +242,7 @@ func UploadVideoToMinio(ctx context.Context, minioClient minio.MinioClient, buck
 	if contentType == "" {
 		contentType = "video/mp4"
 	}
-	url, err := minioClient.Upload(ctx, objectName, file, stat.Size(), contentType)
+	url, err := store.Upload(ctx, objectName, file, stat.Size(), contentType)
 	if err != nil {
 		return "", err
 	}
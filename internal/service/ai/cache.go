@@ -0,0 +1,304 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"time"
+
+	"comic_video/internal/repository/redis"
+	"comic_video/internal/repository/storage"
+)
+
+// GenerationCache AI生成结果缓存，按提供方、模型、输入和调用参数（含 seed）做内容寻址
+// 结果存放在MinIO的 ai-cache/ 目录下，索引存放在Redis，索引过期即视为未命中，对象由 Sweep 定时删除
+type GenerationCache struct {
+	redis   *redis.Client
+	storage storage.BlobStore
+	ttl     time.Duration
+}
+
+// cacheEntry Redis中的缓存索引
+type cacheEntry struct {
+	Object    string    `json:"object"`
+	Kind      string    `json:"kind"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var generationCache *GenerationCache
+
+// SetGenerationCache 设置生成结果缓存；未设置时不缓存
+func SetGenerationCache(cache *GenerationCache) {
+	generationCache = cache
+}
+
+func NewGenerationCache(redisClient *redis.Client, store storage.BlobStore, ttl time.Duration) *GenerationCache {
+	return &GenerationCache{redis: redisClient, storage: store, ttl: ttl}
+}
+
+// cacheObjectPrefix 缓存对象在存储中的目录
+const cacheObjectPrefix = "ai-cache/"
+
+type cacheRefreshKey struct{}
+
+// WithCacheRefresh 本次调用跳过缓存读取，生成结果仍写入缓存覆盖旧值
+// 用于输出不合法需要重新生成的场景，避免重试时命中同一份结果
+func WithCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+// cacheKey 计算缓存键；model 单独参与计算，不区分是否显式指定了默认模型
+func cacheKey(op, provider, defaultModel string, input interface{}, opts map[string]interface{}) (string, string) {
+	model, rest := modelFromOpts(opts, defaultModel)
+	b, _ := json.Marshal(struct {
+		Op       string                 `json:"op"`
+		Provider string                 `json:"provider"`
+		Model    string                 `json:"model"`
+		Input    interface{}            `json:"input"`
+		Opts     map[string]interface{} `json:"opts"` // map 序列化时键已排序
+	}{op, provider, model, input, rest})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), model
+}
+
+func (c *GenerationCache) redisKey(key string) string {
+	return "ai:cache:" + key
+}
+
+// get 读取缓存，任何错误均按未命中处理
+func (c *GenerationCache) get(ctx context.Context, key string) ([]byte, bool) {
+	if refresh, _ := ctx.Value(cacheRefreshKey{}).(bool); refresh {
+		return nil, false
+	}
+	raw, err := c.redis.Get(ctx, c.redisKey(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		return nil, false
+	}
+	data, err := c.download(ctx, entry.Object)
+	if err != nil {
+		log.Printf("[AI] 读取缓存失败: %v object=%s", err, entry.Object)
+		return nil, false
+	}
+	_ = c.redis.Expire(ctx, c.redisKey(key), c.ttl)
+	log.Printf("[AI] 命中生成缓存: %s %s/%s %s", entry.Kind, entry.Provider, entry.Model, key[:12])
+	return data, true
+}
+
+// put 写入缓存，失败只记录日志，不影响生成结果
+func (c *GenerationCache) put(ctx context.Context, key, kind, provider, model string, data []byte, contentType string) {
+	object := fmt.Sprintf("%s%s/%s/%s", cacheObjectPrefix, kind, key[:2], key)
+	if _, err := c.storage.Upload(ctx, object, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("[AI] 写入缓存失败: %v object=%s", err, object)
+		return
+	}
+	entry, _ := json.Marshal(cacheEntry{
+		Object:    object,
+		Kind:      kind,
+		Provider:  provider,
+		Model:     model,
+		Size:      len(data),
+		CreatedAt: time.Now(),
+	})
+	if err := c.redis.Set(ctx, c.redisKey(key), string(entry), c.ttl); err != nil {
+		log.Printf("[AI] 写入缓存索引失败: %v key=%s", err, key)
+	}
+}
+
+// Sweep 删除索引已过期的缓存对象，返回删除数量
+// 写入时先上传对象再写索引，只处理上传时间早于 TTL 的对象，避免误删正在写入的结果
+func (c *GenerationCache) Sweep(ctx context.Context) (int, error) {
+	objects, err := c.storage.ListObjects(ctx, cacheObjectPrefix)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-c.ttl)
+	deleted := 0
+	for _, object := range objects {
+		if object.LastModified.After(cutoff) {
+			continue
+		}
+		exists, err := c.redis.Exists(ctx, c.redisKey(path.Base(object.Key)))
+		if err != nil {
+			return deleted, err
+		}
+		if exists {
+			continue
+		}
+		if err := c.storage.Delete(ctx, object.Key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// StartSweeper 启动定时清理过期缓存对象
+func (c *GenerationCache) StartSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := c.Sweep(context.Background())
+			if err != nil {
+				log.Printf("[AI] 清理过期生成缓存失败: %v", err)
+			}
+			if n > 0 {
+				log.Printf("[AI] 已清理过期生成缓存 %d 个", n)
+			}
+		}
+	}()
+}
+
+func (c *GenerationCache) download(ctx context.Context, object string) ([]byte, error) {
+	reader, err := c.storage.Open(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// withCache 为任务使用的文本、图片、配音能力套上缓存；语音识别与流式接口不缓存
+func (p *Providers) withCache(cache *GenerationCache) {
+	p.cache = cache
+	p.TextGen = cache.wrapTextGen(p.Names.TextGen.Provider, p.TextGen)
+	p.ImageGen = cache.wrapImageGen(p.Names.ImageGen.Provider, p.ImageGen)
+	p.TTS = cache.wrapTTS(p.Names.TTS.Provider, p.TTS)
+}
+
+func (c *GenerationCache) wrapTextGen(provider string, gen TextGen) TextGen {
+	if c == nil || gen == nil {
+		return gen
+	}
+	model := ""
+	switch g := gen.(type) {
+	case *OllamaClient:
+		model = g.Model
+	case *OpenAIClient:
+		model = g.Model
+	}
+	return &cachedTextGen{TextGen: gen, cache: c, provider: provider, model: model}
+}
+
+func (c *GenerationCache) wrapImageGen(provider string, gen ImageGen) ImageGen {
+	if c == nil || gen == nil {
+		return gen
+	}
+	model := ""
+	if g, ok := gen.(*OpenAIClient); ok {
+		model = g.ImageModel
+	}
+	return &cachedImageGen{ImageGen: gen, cache: c, provider: provider, model: model}
+}
+
+func (c *GenerationCache) wrapTTS(provider string, tts TTS) TTS {
+	if c == nil || tts == nil {
+		return tts
+	}
+	model := ""
+	if g, ok := tts.(*OpenAIClient); ok {
+		model = g.TTSModel
+	}
+	return &cachedTTS{TTS: tts, cache: c, provider: provider, model: model}
+}
+
+// cachedTextGen 缓存 Chat/Generate 结果，流式接口直接透传
+type cachedTextGen struct {
+	TextGen
+	cache    *GenerationCache
+	provider string
+	model    string // 提供方默认模型，opts 未指定模型时参与缓存键
+}
+
+func (g *cachedTextGen) Chat(ctx context.Context, messages []Message, opts map[string]interface{}) (string, error) {
+	key, model := cacheKey("chat", g.provider, g.model, messages, opts)
+	if data, ok := g.cache.get(ctx, key); ok {
+		return string(data), nil
+	}
+	out, err := g.TextGen.Chat(ctx, messages, opts)
+	if err == nil {
+		g.cache.put(ctx, key, "text", g.provider, model, []byte(out), "text/plain; charset=utf-8")
+	}
+	return out, err
+}
+
+func (g *cachedTextGen) Generate(ctx context.Context, prompt string, opts map[string]interface{}) (string, error) {
+	key, model := cacheKey("generate", g.provider, g.model, prompt, opts)
+	if data, ok := g.cache.get(ctx, key); ok {
+		return string(data), nil
+	}
+	out, err := g.TextGen.Generate(ctx, prompt, opts)
+	if err == nil {
+		g.cache.put(ctx, key, "text", g.provider, model, []byte(out), "text/plain; charset=utf-8")
+	}
+	return out, err
+}
+
+// cachedImageGen 缓存出图结果
+// 未指定 seed 时提供方每次随机出图，缓存会固定首次结果，需要重新出图时请求中传 no_cache
+type cachedImageGen struct {
+	ImageGen
+	cache    *GenerationCache
+	provider string
+	model    string
+}
+
+func (g *cachedImageGen) Txt2Img(ctx context.Context, prompt string, opts map[string]interface{}) (ImageResult, error) {
+	key, model := cacheKey("txt2img", g.provider, g.model, prompt, opts)
+	if data, ok := g.cache.get(ctx, key); ok {
+		return ImageResult{Data: data}, nil
+	}
+	res, err := g.ImageGen.Txt2Img(ctx, prompt, opts)
+	if err == nil && len(res.Data) > 0 {
+		g.cache.put(ctx, key, "image", g.provider, model, res.Data, http.DetectContentType(res.Data))
+	}
+	return res, err
+}
+
+func (g *cachedImageGen) Img2Img(ctx context.Context, image []byte, prompt string, opts map[string]interface{}) (ImageResult, error) {
+	sum := sha256.Sum256(image)
+	input := map[string]string{"image": hex.EncodeToString(sum[:]), "prompt": prompt}
+	key, model := cacheKey("img2img", g.provider, g.model, input, opts)
+	if data, ok := g.cache.get(ctx, key); ok {
+		return ImageResult{Data: data}, nil
+	}
+	res, err := g.ImageGen.Img2Img(ctx, image, prompt, opts)
+	if err == nil && len(res.Data) > 0 {
+		g.cache.put(ctx, key, "image", g.provider, model, res.Data, http.DetectContentType(res.Data))
+	}
+	return res, err
+}
+
+// cachedTTS 缓存配音结果，音色、语速等参数均在 opts 中参与缓存键
+type cachedTTS struct {
+	TTS
+	cache    *GenerationCache
+	provider string
+	model    string
+}
+
+func (t *cachedTTS) Synthesize(ctx context.Context, text string, opts map[string]interface{}) ([]byte, error) {
+	key, model := cacheKey("tts", t.provider, t.model, text, opts)
+	if data, ok := t.cache.get(ctx, key); ok {
+		return data, nil
+	}
+	audio, err := t.TTS.Synthesize(ctx, text, opts)
+	if err == nil && len(audio) > 0 {
+		t.cache.put(ctx, key, "tts", t.provider, model, audio, http.DetectContentType(audio))
+	}
+	return audio, err
+}
@@ -0,0 +1,385 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 提供方已熔断，请求被直接拒绝
+var ErrCircuitOpen = errors.New("AI服务熔断中，暂不可用")
+
+// 熔断器状态
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// HTTPOptions 单个AI提供方的HTTP调用策略
+type HTTPOptions struct {
+	TimeoutSec        int    `json:"timeout_sec"`         // 非流式请求的总时限（含重试和读取响应体），流式请求只限制等待响应头的时间；0 表示不限
+	MaxInFlight       int    `json:"max_in_flight"`       // 最大并发请求数，0 表示不限
+	MaxRetries        int    `json:"max_retries"`         // 5xx/429/连接错误的重试次数，0 表示不重试
+	FailureThreshold  int    `json:"failure_threshold"`   // 连续失败多少次后熔断，0 表示不熔断
+	OpenSec           int    `json:"open_sec"`            // 熔断持续时间，之后放行一次探测请求
+	HealthPath        string `json:"health_path"`         // 健康检查路径，为空则不检查
+	HealthIntervalSec int    `json:"health_interval_sec"` // 健康检查间隔
+}
+
+// HTTPClient AI提供方共享的HTTP调用层
+// 负责上下文传递、超时、并发限制、重试、熔断与健康检查
+type HTTPClient struct {
+	Name     string
+	Endpoint string
+	opts     HTTPOptions
+	client   *http.Client
+	sem      chan struct{}
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	inFlight  int
+	healthy   bool
+	lastCheck time.Time
+	lastError string
+}
+
+// ProviderStatus 提供方运行状态
+type ProviderStatus struct {
+	Name         string     `json:"name"`
+	Endpoint     string     `json:"endpoint"`
+	Capabilities []string   `json:"capabilities"`
+	State        string     `json:"state"`
+	Healthy      bool       `json:"healthy"`
+	LastCheck    *time.Time `json:"last_check"`
+	InFlight     int        `json:"in_flight"`
+	MaxInFlight  int        `json:"max_in_flight"`
+	Failures     int        `json:"failures"`
+	LastError    string     `json:"last_error"`
+}
+
+// defaultHTTP 未单独配置的客户端使用，不限并发、不熔断
+var defaultHTTP = NewHTTPClient("default", "", HTTPOptions{TimeoutSec: 600, MaxRetries: 1})
+
+var httpClients = make(map[string]*HTTPClient)
+
+// NewHTTPClient 创建提供方HTTP客户端
+func NewHTTPClient(name, endpoint string, opts HTTPOptions) *HTTPClient {
+	h := &HTTPClient{
+		Name:     name,
+		Endpoint: endpoint,
+		opts:     opts,
+		client:   &http.Client{Transport: newTransport(opts)},
+		state:    CircuitClosed,
+		healthy:  true,
+	}
+	if opts.MaxInFlight > 0 {
+		h.sem = make(chan struct{}, opts.MaxInFlight)
+	}
+	return h
+}
+
+// newTransport 只限制建连和等待响应头的时间，不用 http.Client.Timeout，避免截断长时间的流式响应；非流式请求的总时限由 Do 设置
+func newTransport(opts HTTPOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	t.ResponseHeaderTimeout = time.Duration(opts.TimeoutSec) * time.Second
+	return t
+}
+
+// registerHTTPClient 登记客户端并启动健康检查，供状态接口查询
+func registerHTTPClient(h *HTTPClient) {
+	httpClients[h.Name] = h
+	if h.opts.HealthPath != "" {
+		go h.healthLoop()
+	}
+}
+
+// orDefault 客户端未配置时回退到默认HTTP层
+func orDefault(h *HTTPClient) *HTTPClient {
+	if h == nil {
+		return defaultHTTP
+	}
+	return h
+}
+
+// Do 发送非流式请求，body 会在重试时重放；成功时调用方负责关闭响应体，关闭后释放并发名额
+// 排队、重试和读取响应体合计不超过 TimeoutSec
+func (h *HTTPClient) Do(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Response, error) {
+	return h.send(ctx, method, url, header, body, false)
+}
+
+// DoStream 发送流式请求，TimeoutSec 只限制等待响应头的时间，持续输出只受调用方 ctx 约束
+func (h *HTTPClient) DoStream(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Response, error) {
+	return h.send(ctx, method, url, header, body, true)
+}
+
+func (h *HTTPClient) send(ctx context.Context, method, url string, header http.Header, body []byte, stream bool) (*http.Response, error) {
+	if err := h.allow(); err != nil {
+		return nil, err
+	}
+	parent := ctx
+	cancel := func() {}
+	if !stream && h.opts.TimeoutSec > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(h.opts.TimeoutSec)*time.Second)
+	}
+	if err := h.acquire(ctx); err != nil {
+		cancel()
+		h.endProbe()
+		return nil, err
+	}
+	resp, err := h.doWithRetry(ctx, method, url, header, body)
+	if err != nil {
+		cancel()
+		h.release()
+		// 超过总时限计为提供方失败，调用方取消则不计
+		if parent.Err() == nil {
+			h.recordFailure(err)
+		} else {
+			h.endProbe()
+		}
+		return nil, err
+	}
+	if resp.StatusCode >= 500 {
+		h.recordFailure(fmt.Errorf("%s", resp.Status))
+	} else {
+		h.recordSuccess()
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() {
+		cancel()
+		h.release()
+	}}
+	return resp, nil
+}
+
+// doWithRetry 对连接错误、5xx 和 429 按指数退避重试
+func (h *HTTPClient) doWithRetry(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := h.client.Do(req)
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if attempt >= h.opts.MaxRetries {
+			return resp, err
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("%s", resp.Status)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		backoff := time.Duration(1<<attempt) * 500 * time.Millisecond
+		log.Printf("[AI] %s 请求失败，%v后第%d次重试: %v", h.Name, backoff, attempt+1, lastErr)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// allow 熔断判断：打开状态下拒绝请求，超过熔断时间后放行一次探测
+func (h *HTTPClient) allow() error {
+	if h.opts.FailureThreshold <= 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch h.state {
+	case CircuitOpen:
+		if time.Since(h.openedAt) < time.Duration(h.opts.OpenSec)*time.Second {
+			return ErrCircuitOpen
+		}
+		h.state = CircuitHalfOpen
+		h.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if h.probing {
+			return ErrCircuitOpen
+		}
+		h.probing = true
+	}
+	return nil
+}
+
+// endProbe 探测请求未得出结论（如被取消）时允许下一次探测
+func (h *HTTPClient) endProbe() {
+	h.mu.Lock()
+	h.probing = false
+	h.mu.Unlock()
+}
+
+func (h *HTTPClient) recordSuccess() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != CircuitClosed {
+		log.Printf("[AI] %s 熔断恢复", h.Name)
+	}
+	h.state = CircuitClosed
+	h.failures = 0
+	h.probing = false
+}
+
+func (h *HTTPClient) recordFailure(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	h.lastError = err.Error()
+	h.probing = false
+	if h.opts.FailureThreshold <= 0 {
+		return
+	}
+	if h.state == CircuitHalfOpen || h.failures >= h.opts.FailureThreshold {
+		if h.state != CircuitOpen {
+			log.Printf("[AI] %s 连续失败%d次，熔断%d秒: %v", h.Name, h.failures, h.opts.OpenSec, err)
+		}
+		h.state = CircuitOpen
+		h.openedAt = time.Now()
+	}
+}
+
+// acquire 占用并发名额，ctx 取消时放弃等待
+func (h *HTTPClient) acquire(ctx context.Context) error {
+	if h.sem != nil {
+		select {
+		case h.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	h.mu.Lock()
+	h.inFlight++
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *HTTPClient) release() {
+	h.mu.Lock()
+	h.inFlight--
+	h.mu.Unlock()
+	if h.sem != nil {
+		<-h.sem
+	}
+}
+
+// healthLoop 定期检查提供方健康状态，熔断中检查通过则提前恢复
+func (h *HTTPClient) healthLoop() {
+	interval := time.Duration(h.opts.HealthIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	for {
+		h.checkHealth()
+		time.Sleep(interval)
+	}
+}
+
+func (h *HTTPClient) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var err error
+	req, _ := http.NewRequestWithContext(ctx, "GET", h.Endpoint+h.opts.HealthPath, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("%s", resp.Status)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = time.Now()
+	if err != nil {
+		if h.healthy {
+			log.Printf("[AI] %s 健康检查失败: %v", h.Name, err)
+		}
+		h.healthy = false
+		h.lastError = "health check: " + err.Error()
+		return
+	}
+	h.healthy = true
+	if h.state != CircuitClosed && !h.probing {
+		log.Printf("[AI] %s 健康检查通过，熔断恢复", h.Name)
+		h.state = CircuitClosed
+		h.failures = 0
+	}
+}
+
+// Status 当前运行状态
+func (h *HTTPClient) Status() ProviderStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := ProviderStatus{
+		Name:        h.Name,
+		Endpoint:    h.Endpoint,
+		State:       h.state,
+		Healthy:     h.healthy,
+		InFlight:    h.inFlight,
+		MaxInFlight: h.opts.MaxInFlight,
+		Failures:    h.failures,
+		LastError:   h.lastError,
+	}
+	if !h.lastCheck.IsZero() {
+		t := h.lastCheck
+		st.LastCheck = &t
+	}
+	return st
+}
+
+// ProviderStatuses 所有已注册提供方的运行状态
+func ProviderStatuses() []ProviderStatus {
+	names := ProviderNames()
+	statuses := make([]ProviderStatus, 0, len(httpClients))
+	for _, name := range sortedKeys(httpClients) {
+		st := httpClients[name].Status()
+		st.Capabilities = []string{}
+		for _, capability := range []string{"text_gen", "image_gen", "tts", "audio2text"} {
+			for _, n := range names[capability] {
+				if n == name {
+					st.Capabilities = append(st.Capabilities, capability)
+				}
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// releaseBody 响应体关闭时释放并发名额
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
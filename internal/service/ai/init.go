@@ -1,5 +1,13 @@
 package ai
 
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"comic_video/internal/config"
+)
+
 // Config 结构可从全局配置读取
 // 这里只做演示
 var DefaultSD = &SDClient{Endpoint: "http://127.0.0.1:7860"}
@@ -7,10 +15,124 @@ var DefaultOllama = &OllamaClient{Endpoint: "http://127.0.0.1:11434", Model: "ll
 var DefaultWhisper = &WhisperClient{Endpoint: "http://127.0.0.1:9000"}
 var DefaultTTS = &TTSClient{Endpoint: "http://127.0.0.1:50021"}
 
-func InitAIProviders() {
+// defaultHTTPOptions 各提供方默认HTTP调用策略，可被 AI_HTTP_OPTIONS 覆盖
+// SD 与 Whisper 通常独占单卡，默认串行调用
+// 重试与熔断默认值写在这里而不是创建客户端时补齐，配置中显式写 0 才能关闭重试或熔断
+var defaultHTTPOptions = map[string]HTTPOptions{
+	"sd":      {TimeoutSec: 300, MaxInFlight: 1, MaxRetries: 2, FailureThreshold: 5, OpenSec: 30, HealthPath: "/internal/ping"},
+	"ollama":  {TimeoutSec: 600, MaxInFlight: 2, MaxRetries: 2, FailureThreshold: 5, OpenSec: 30, HealthPath: "/api/tags"},
+	"whisper": {TimeoutSec: 600, MaxInFlight: 1, MaxRetries: 2, FailureThreshold: 5, OpenSec: 30},
+	"edge":    {TimeoutSec: 120, MaxInFlight: 2, MaxRetries: 2, FailureThreshold: 5, OpenSec: 30},
+	"openai":  {TimeoutSec: 300, MaxInFlight: 4, MaxRetries: 2, FailureThreshold: 5, OpenSec: 30, HealthPath: "/v1/models"},
+}
+
+// InitAIProviders 按配置注册AI能力提供方
+func InitAIProviders(cfg *config.AIConfig) {
+	httpOpts := parseHTTPOptions(cfg.HTTPOptions)
+
+	DefaultSD.Endpoint = cfg.SDEndpoint
+	DefaultOllama.Endpoint = cfg.OllamaEndpoint
+	DefaultOllama.Model = cfg.OllamaModel
+	DefaultOllama.ApiKey = cfg.OllamaApiKey
+	DefaultWhisper.Endpoint = cfg.WhisperEndpoint
+	DefaultTTS.Endpoint = cfg.TTSEndpoint
+	DefaultSD.HTTP = newProviderHTTP("sd", cfg.SDEndpoint, httpOpts)
+	DefaultOllama.HTTP = newProviderHTTP("ollama", cfg.OllamaEndpoint, httpOpts)
+	DefaultWhisper.HTTP = newProviderHTTP("whisper", cfg.WhisperEndpoint, httpOpts)
+	DefaultTTS.HTTP = newProviderHTTP("edge", cfg.TTSEndpoint, httpOpts)
+
 	RegisterImageGen("sd", DefaultSD)
 	RegisterTextGen("ollama", DefaultOllama)
 	RegisterAudio2Text("whisper", DefaultWhisper)
 	RegisterTTS("edge", DefaultTTS)
+
+	// OpenAI 兼容服务，一个客户端同时提供四种能力
+	if cfg.OpenAIEndpoint != "" {
+		openai := &OpenAIClient{
+			Endpoint:   cfg.OpenAIEndpoint,
+			ApiKey:     cfg.OpenAIApiKey,
+			Model:      cfg.OpenAIModel,
+			ImageModel: cfg.OpenAIImageModel,
+			TTSModel:   cfg.OpenAITTSModel,
+			Voice:      cfg.OpenAITTSVoice,
+			ASRModel:   cfg.OpenAIASRModel,
+			HTTP:       newProviderHTTP("openai", strings.TrimSuffix(strings.TrimRight(cfg.OpenAIEndpoint, "/"), "/v1"), httpOpts),
+		}
+		RegisterTextGen("openai", openai)
+		RegisterImageGen("openai", openai)
+		RegisterTTS("openai", openai)
+		RegisterAudio2Text("openai", openai)
+	}
 	// 可扩展更多
-} 
\ No newline at end of file
+
+	// 默认提供方、模型与调用参数
+	defaultProviders = ProviderOverrides{
+		TextGen:    ProviderChoice{Provider: cfg.TextGenProvider, Model: cfg.TextGenModel},
+		ImageGen:   ProviderChoice{Provider: cfg.ImageGenProvider, Model: cfg.ImageGenModel},
+		TTS:        ProviderChoice{Provider: cfg.TTSProvider, Model: cfg.TTSModel},
+		Audio2Text: ProviderChoice{Provider: cfg.Audio2TextProvider, Model: cfg.Audio2TextModel},
+	}
+	dropUnsupportedModel("AI_TEXTGEN_MODEL", "文本生成", GetTextGen(cfg.TextGenProvider), &defaultProviders.TextGen)
+	dropUnsupportedModel("AI_IMAGEGEN_MODEL", "图片生成", GetImageGen(cfg.ImageGenProvider), &defaultProviders.ImageGen)
+	dropUnsupportedModel("AI_TTS_MODEL", "语音合成", GetTTS(cfg.TTSProvider), &defaultProviders.TTS)
+	dropUnsupportedModel("AI_AUDIO2TEXT_MODEL", "语音识别", GetAudio2Text(cfg.Audio2TextProvider), &defaultProviders.Audio2Text)
+	defaultOptions.TextGen = parseOptions("AI_TEXTGEN_OPTIONS", cfg.TextGenOptions)
+	defaultOptions.ImageGen = parseOptions("AI_IMAGEGEN_OPTIONS", cfg.ImageGenOptions)
+	defaultOptions.TTS = parseOptions("AI_TTS_OPTIONS", cfg.TTSOptions)
+	defaultOptions.Audio2Text = parseOptions("AI_AUDIO2TEXT_OPTIONS", cfg.Audio2TextOptions)
+
+	log.Printf("[AI] 已注册提供方: %v 默认: %+v", ProviderNames(), defaultProviders)
+}
+
+// dropUnsupportedModel 默认提供方不支持切换模型时忽略配置的默认模型，避免每个任务都解析失败
+func dropUnsupportedModel(key, kind string, client interface{}, choice *ProviderChoice) {
+	if err := checkModel(kind, client, *choice); err != nil {
+		log.Printf("[AI] %s 已忽略: %v", key, err)
+		choice.Model = ""
+	}
+}
+
+// parseOptions 解析JSON格式的默认调用参数，格式错误时忽略
+func parseOptions(key, raw string) map[string]interface{} {
+	opts := map[string]interface{}{}
+	if raw == "" {
+		return opts
+	}
+	if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+		log.Printf("[AI] %s 格式错误，已忽略: %v", key, err)
+		return map[string]interface{}{}
+	}
+	return opts
+}
+
+// parseHTTPOptions 解析各提供方HTTP调用策略，未出现的字段保留默认值
+func parseHTTPOptions(raw string) map[string]HTTPOptions {
+	opts := make(map[string]HTTPOptions, len(defaultHTTPOptions))
+	for name, o := range defaultHTTPOptions {
+		opts[name] = o
+	}
+	if raw == "" {
+		return opts
+	}
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		log.Printf("[AI] AI_HTTP_OPTIONS 格式错误，已忽略: %v", err)
+		return opts
+	}
+	for name, msg := range overrides {
+		o := opts[name]
+		if err := json.Unmarshal(msg, &o); err != nil {
+			log.Printf("[AI] AI_HTTP_OPTIONS.%s 格式错误，已忽略: %v", name, err)
+			continue
+		}
+		opts[name] = o
+	}
+	return opts
+}
+
+// newProviderHTTP 创建并登记提供方HTTP客户端
+func newProviderHTTP(name, endpoint string, opts map[string]HTTPOptions) *HTTPClient {
+	h := NewHTTPClient(name, endpoint, opts[name])
+	registerHTTPClient(h)
+	return h
+}
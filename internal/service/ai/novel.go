@@ -0,0 +1,307 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/redis"
+)
+
+// 小说生成默认参数
+const (
+	defaultNovelChapters      = 5
+	maxNovelChapters          = 30
+	defaultNovelChapterLength = 1500
+	maxNovelChapterLength     = 5000
+	novelSummaryLength        = 600 // 前情提要字数上限，控制上下文长度
+	novelTailLength           = 300 // 续写时附带的上一章结尾字数
+)
+
+// NovelParams 小说生成任务参数
+type NovelParams struct {
+	Novel         string `json:"novel"`          // 小说主题/创作要求
+	Title         string `json:"title"`          // 可选，指定标题
+	Style         string `json:"style"`          // 可选，风格/题材
+	Chapters      int    `json:"chapters"`       // 章节数
+	ChapterLength int    `json:"chapter_length"` // 每章目标字数
+
+	Providers ProviderOverrides `json:"providers"` // 可选，指定提供方/模型
+	NoCache   bool              `json:"no_cache"`  // 可选，跳过生成缓存
+}
+
+// NovelChapter 小说章节
+type NovelChapter struct {
+	Index   int    `json:"index"`
+	Title   string `json:"title"`
+	Summary string `json:"summary"` // 大纲中的章节梗概
+	Content string `json:"content"`
+}
+
+// NovelOutline 小说大纲
+type NovelOutline struct {
+	Title    string         `json:"title"`
+	Synopsis string         `json:"synopsis"`
+	Chapters []NovelChapter `json:"chapters"`
+}
+
+// NovelResult 小说生成结果
+// Novel 为全文拼接，可直接作为 novel-to-video 的 novel 参数
+type NovelResult struct {
+	Title    string         `json:"title"`
+	Synopsis string         `json:"synopsis"`
+	Chapters []NovelChapter `json:"chapters"`
+	Novel    string         `json:"novel"`
+}
+
+// ProcessGenerateNovel: AI长篇小说生成主流程
+// 先生成大纲，再逐章生成正文，通过滚动的前情提要控制上下文长度
+func ProcessGenerateNovel(ctx context.Context, task *entity.Task, redisClient *redis.Client, providers *Providers) error {
+	task.Status = entity.TaskStatusProcessing
+	task.Progress = 5
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+
+	if providers.TextGen == nil {
+		log.Printf("[AI] 任务失败: 文本生成提供方未配置 task=%v", task.ID)
+		return failTask(ctx, task, redisClient, "文本生成提供方未配置")
+	}
+
+	var params NovelParams
+	_ = json.Unmarshal([]byte(task.Params), &params)
+	if params.Novel == "" {
+		log.Printf("[AI] 任务失败: 小说主题为空 task=%v", task.ID)
+		return failTask(ctx, task, redisClient, "小说主题为空")
+	}
+	if params.Chapters <= 0 {
+		params.Chapters = defaultNovelChapters
+	}
+	if params.Chapters > maxNovelChapters {
+		params.Chapters = maxNovelChapters
+	}
+	if params.ChapterLength <= 0 {
+		params.ChapterLength = defaultNovelChapterLength
+	}
+	if params.ChapterLength > maxNovelChapterLength {
+		params.ChapterLength = maxNovelChapterLength
+	}
+
+	// 1. 生成大纲
+	log.Printf("[AI] 开始生成小说大纲: task=%v chapters=%d", task.ID, params.Chapters)
+	outline, err := generateNovelOutline(ctx, task, providers, &params)
+	if err != nil {
+		log.Printf("[AI] 小说大纲生成失败: %v task=%v", err, task.ID)
+		return failTask(ctx, task, redisClient, "大纲生成失败: "+err.Error())
+	}
+	task.Progress = 10
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	log.Printf("[AI] 小说大纲生成完成: task=%v title=%s chapters=%d", task.ID, outline.Title, len(outline.Chapters))
+
+	// 2. 逐章生成正文
+	result := NovelResult{Title: outline.Title, Synopsis: outline.Synopsis}
+	summary := ""
+	for i, ch := range outline.Chapters {
+		log.Printf("[AI] 开始生成第%d章: %s task=%v", i+1, ch.Title, task.ID)
+		prevTail := ""
+		if i > 0 {
+			prevTail = lastRunes(result.Chapters[i-1].Content, novelTailLength)
+		}
+		content, err := generateNovelChapter(ctx, task, providers, &params, outline, i, summary, prevTail)
+		if err != nil {
+			log.Printf("[AI] 第%d章生成失败: %v task=%v", i+1, err, task.ID)
+			return failTask(ctx, task, redisClient, fmt.Sprintf("第%d章生成失败: %v", i+1, err))
+		}
+		ch.Index = i + 1
+		ch.Content = content
+		result.Chapters = append(result.Chapters, ch)
+
+		// 更新前情提要，失败时保留旧提要并追加本章梗概
+		if i < len(outline.Chapters)-1 {
+			newSummary, err := summarizeNovelChapter(ctx, task, providers, summary, &ch)
+			if err != nil {
+				log.Printf("[AI] 第%d章摘要失败，使用大纲梗概: %v task=%v", i+1, err, task.ID)
+				newSummary = lastRunes(summary+ch.Summary, novelSummaryLength*2)
+			}
+			summary = newSummary
+		}
+
+		// 每章完成后写回进度与已生成章节，便于前端逐章展示
+		task.Progress = 10 + int(float64(i+1)/float64(len(outline.Chapters))*85)
+		b, _ := json.Marshal(result)
+		task.Result = string(b)
+		task.UpdatedAt = time.Now()
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+		log.Printf("[AI] 第%d章生成完成: task=%v length=%d", i+1, task.ID, len([]rune(content)))
+	}
+
+	// 3. 拼接全文并写入最终结果
+	var sb strings.Builder
+	for _, ch := range result.Chapters {
+		fmt.Fprintf(&sb, "第%d章 %s\n\n%s\n\n", ch.Index, ch.Title, ch.Content)
+	}
+	result.Novel = strings.TrimSpace(sb.String())
+	b, _ := json.Marshal(result)
+	task.Status = entity.TaskStatusCompleted
+	task.Progress = 100
+	task.Result = string(b)
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	log.Printf("[AI] 小说生成完成: task=%v chapters=%d", task.ID, len(result.Chapters))
+	return nil
+}
+
+// generateNovelOutline 生成结构化大纲，输出不合法时重试
+func generateNovelOutline(ctx context.Context, task *entity.Task, providers *Providers, params *NovelParams) (*NovelOutline, error) {
+	prompt, err := RenderPrompt(ctx, task, PromptNovelOutline, map[string]interface{}{
+		"novel":    params.Novel,
+		"chapters": params.Chapters,
+		"title":    params.Title,
+		"style":    params.Style,
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages, opts := prompt.Messages(), prompt.Options(providers.TextGenOpts)
+
+	var lastErr error
+	maxRetry := 3
+	for retry := 1; retry <= maxRetry; retry++ {
+		// 重试时跳过缓存，避免再次取到同一份不合法的输出
+		callCtx := ctx
+		if retry > 1 {
+			callCtx = WithCacheRefresh(ctx)
+		}
+		out, err := providers.TextGen.Chat(callCtx, messages, opts)
+		if err != nil {
+			lastErr = err
+			log.Printf("[AI] 大纲生成失败: %v 第%d次", err, retry)
+			continue
+		}
+		var outline NovelOutline
+		if err := json.Unmarshal([]byte(extractJSON(out)), &outline); err != nil || len(outline.Chapters) == 0 {
+			lastErr = fmt.Errorf("大纲输出不合法")
+			log.Printf("[AI] 大纲输出不合法，第%d次: %s", retry, out)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		if params.Title != "" {
+			outline.Title = params.Title
+		}
+		if len(outline.Chapters) > params.Chapters {
+			outline.Chapters = outline.Chapters[:params.Chapters]
+		}
+		return &outline, nil
+	}
+	return nil, lastErr
+}
+
+// generateNovelChapter 基于大纲、前情提要和上一章结尾生成单章正文
+func generateNovelChapter(ctx context.Context, task *entity.Task, providers *Providers, params *NovelParams, outline *NovelOutline, idx int, summary, prevTail string) (string, error) {
+	ch := outline.Chapters[idx]
+	nextSummary := ""
+	if idx+1 < len(outline.Chapters) {
+		nextSummary = outline.Chapters[idx+1].Summary
+	}
+	prompt, err := RenderPrompt(ctx, task, PromptNovelChapter, map[string]interface{}{
+		"title":           outline.Title,
+		"synopsis":        outline.Synopsis,
+		"style":           params.Style,
+		"summary":         summary,
+		"prev_tail":       prevTail,
+		"index":           idx + 1,
+		"chapter_title":   ch.Title,
+		"chapter_summary": ch.Summary,
+		"next_summary":    nextSummary,
+		"length":          params.ChapterLength,
+	})
+	if err != nil {
+		return "", err
+	}
+	out, err := providers.TextGen.Chat(ctx, prompt.Messages(), prompt.Options(providers.TextGenOpts))
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(stripThink(out))
+	if content == "" {
+		return "", fmt.Errorf("章节内容为空")
+	}
+	return content, nil
+}
+
+// summarizeNovelChapter 将旧提要与新章节压缩为新的前情提要
+func summarizeNovelChapter(ctx context.Context, task *entity.Task, providers *Providers, summary string, ch *NovelChapter) (string, error) {
+	prompt, err := RenderPrompt(ctx, task, PromptNovelSummary, map[string]interface{}{
+		"summary":         summary,
+		"chapter_title":   ch.Title,
+		"chapter_content": ch.Content,
+		"max_length":      novelSummaryLength,
+	})
+	if err != nil {
+		return "", err
+	}
+	out, err := providers.TextGen.Chat(ctx, prompt.Messages(), prompt.Options(providers.TextGenOpts))
+	if err != nil {
+		return "", err
+	}
+	out = strings.TrimSpace(stripThink(out))
+	if out == "" {
+		return "", fmt.Errorf("摘要为空")
+	}
+	return lastRunes(out, novelSummaryLength*2), nil
+}
+
+// failTask 标记任务失败并写回Redis
+func failTask(ctx context.Context, task *entity.Task, redisClient *redis.Client, msg string) error {
+	task.Status = entity.TaskStatusFailed
+	task.Error = msg
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	return fmt.Errorf("%s", msg)
+}
+
+// stripThink 去除推理模型输出的<think>...</think>片段
+func stripThink(s string) string {
+	for {
+		start := strings.Index(s, "<think>")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "</think>")
+		if end < 0 {
+			return s[:start]
+		}
+		s = s[:start] + s[start+end+len("</think>"):]
+	}
+}
+
+// extractJSON 从模型输出中截取JSON对象或数组（兼容```json代码块和<think>前缀）
+func extractJSON(s string) string {
+	s = strings.TrimSpace(stripThink(s))
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+	closing := "}"
+	if s[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(s, closing)
+	if end < start {
+		return s[start:]
+	}
+	return s[start : end+1]
+}
+
+// lastRunes 取字符串末尾n个字符
+func lastRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[len(r)-n:])
+}
@@ -1,72 +1,133 @@
 package ai
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 )
 
 type OllamaClient struct {
-	Endpoint string // 例如 http://127.0.0.1:11434
-	Model    string // 如 "llama2"、"qwen" 等
-	ApiKey   string // 新增，支持 API Key
+	Endpoint string      // 例如 http://127.0.0.1:11434
+	Model    string      // 如 "llama2"、"qwen" 等
+	ApiKey   string      // 新增，支持 API Key
+	HTTP     *HTTPClient // 共享HTTP调用层，为空时使用默认配置
 }
 
+var _ TextGen = (*OllamaClient)(nil)
+
 type ollamaChatReq struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
 }
 
 type ollamaGenReq struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
+// ollamaResp 兼容 /api/generate（response）与 /api/chat（message.content），流式时每行一个
 type ollamaResp struct {
-	Response string `json:"response"`
+	Response string   `json:"response"`
+	Message  *Message `json:"message"`
+	Done     bool     `json:"done"`
+	Error    string   `json:"error"`
 }
 
-func (o *OllamaClient) Chat(messages []Message, opts map[string]interface{}) (string, error) {
-	reqBody := ollamaChatReq{Model: o.Model, Messages: messages}
-	b, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequest("POST", o.Endpoint+"/api/chat", bytes.NewReader(b))
-	if o.ApiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+o.ApiKey)
+// text 取出本次响应的文本片段
+func (r *ollamaResp) text() string {
+	if r.Message != nil {
+		return r.Message.Content
 	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Ollama API error: %s", resp.Status)
-	}
-	var result ollamaResp
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-	return result.Response, nil
+	return r.Response
+}
+
+// Chat/Generate 的 opts 作为 Ollama options 透传，opts["model"] 可覆盖默认模型
+func (o *OllamaClient) Chat(ctx context.Context, messages []Message, opts map[string]interface{}) (string, error) {
+	model, opts := modelFromOpts(opts, o.Model)
+	return o.do(ctx, "/api/chat", ollamaChatReq{Model: model, Messages: messages, Options: opts}, nil)
 }
 
-func (o *OllamaClient) Generate(prompt string, opts map[string]interface{}) (string, error) {
-	reqBody := ollamaGenReq{Model: o.Model, Prompt: prompt}
+func (o *OllamaClient) Generate(ctx context.Context, prompt string, opts map[string]interface{}) (string, error) {
+	model, opts := modelFromOpts(opts, o.Model)
+	return o.do(ctx, "/api/generate", ollamaGenReq{Model: model, Prompt: prompt, Options: opts}, nil)
+}
+
+// ChatStream 流式对话，每收到一段输出即回调 onToken，返回完整文本
+func (o *OllamaClient) ChatStream(ctx context.Context, messages []Message, opts map[string]interface{}, onToken TokenHandler) (string, error) {
+	model, opts := modelFromOpts(opts, o.Model)
+	return o.do(ctx, "/api/chat", ollamaChatReq{Model: model, Messages: messages, Stream: true, Options: opts}, onToken)
+}
+
+// GenerateStream 流式生成，每收到一段输出即回调 onToken，返回完整文本
+func (o *OllamaClient) GenerateStream(ctx context.Context, prompt string, opts map[string]interface{}, onToken TokenHandler) (string, error) {
+	model, opts := modelFromOpts(opts, o.Model)
+	return o.do(ctx, "/api/generate", ollamaGenReq{Model: model, Prompt: prompt, Stream: true, Options: opts}, onToken)
+}
+
+// do 发送请求；onToken 为空时按非流式解析单个JSON，否则按NDJSON逐行解析
+func (o *OllamaClient) do(ctx context.Context, path string, reqBody interface{}, onToken TokenHandler) (string, error) {
 	b, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequest("POST", o.Endpoint+"/api/generate", bytes.NewReader(b))
+	header := http.Header{"Content-Type": {"application/json"}}
 	if o.ApiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+o.ApiKey)
+		header.Set("Authorization", "Bearer "+o.ApiKey)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	do := orDefault(o.HTTP).Do
+	if onToken != nil {
+		do = orDefault(o.HTTP).DoStream
+	}
+	resp, err := do(ctx, "POST", o.Endpoint+path, header, b)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Ollama API error: %s", resp.Status)
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("Ollama API error: %s %s", resp.Status, strings.TrimSpace(string(msg)))
 	}
-	var result ollamaResp
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+
+	if onToken == nil {
+		var result ollamaResp
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", err
+		}
+		if result.Error != "" {
+			return "", fmt.Errorf("Ollama API error: %s", result.Error)
+		}
+		return result.text(), nil
 	}
-	return result.Response, nil
-} 
\ No newline at end of file
+
+	var sb strings.Builder
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResp
+		if err := dec.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				return sb.String(), nil
+			}
+			if ctx.Err() != nil {
+				return sb.String(), ctx.Err()
+			}
+			return sb.String(), err
+		}
+		if chunk.Error != "" {
+			return sb.String(), fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+		if token := chunk.text(); token != "" {
+			sb.WriteString(token)
+			if err := onToken(token); err != nil {
+				return sb.String(), err
+			}
+		}
+		if chunk.Done {
+			return sb.String(), nil
+		}
+	}
+}
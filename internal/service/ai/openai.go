@@ -0,0 +1,339 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient OpenAI 兼容接口客户端
+// 适用于 OpenAI 及 vLLM、LocalAI、llama.cpp server 等兼容服务，同时实现 TextGen/ImageGen/TTS/Audio2Text
+type OpenAIClient struct {
+	Endpoint   string // 例如 https://api.openai.com 或 http://127.0.0.1:8000（可带 /v1）
+	ApiKey     string
+	Model      string      // 对话模型，如 gpt-4o-mini、qwen2.5-7b-instruct
+	ImageModel string      // 图片模型，如 dall-e-3、stable-diffusion
+	TTSModel   string      // 语音合成模型，如 tts-1
+	Voice      string      // 默认音色，如 alloy
+	ASRModel   string      // 语音识别模型，如 whisper-1
+	HTTP       *HTTPClient // 共享HTTP调用层，为空时使用默认配置
+}
+
+var (
+	_ TextGen    = (*OpenAIClient)(nil)
+	_ ImageGen   = (*OpenAIClient)(nil)
+	_ TTS        = (*OpenAIClient)(nil)
+	_ Audio2Text = (*OpenAIClient)(nil)
+)
+
+type openAIChatResp struct {
+	Choices []struct {
+		Message Message `json:"message"`
+		Delta   Message `json:"delta"`
+	} `json:"choices"`
+	Error *openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+}
+
+// url 拼接接口地址，兼容 Endpoint 是否已包含 /v1
+func (o *OpenAIClient) url(path string) string {
+	base := strings.TrimRight(o.Endpoint, "/")
+	if strings.HasSuffix(base, "/v1") {
+		base = strings.TrimSuffix(base, "/v1")
+	}
+	return base + "/v1" + path
+}
+
+// post 发送非流式请求并检查状态码，调用方负责关闭响应体
+func (o *OpenAIClient) post(ctx context.Context, path, contentType string, body []byte) (*http.Response, error) {
+	return o.send(ctx, path, contentType, body, false)
+}
+
+// send 发送请求并检查状态码，stream 为 true 时不设总时限，调用方负责关闭响应体
+func (o *OpenAIClient) send(ctx context.Context, path, contentType string, body []byte, stream bool) (*http.Response, error) {
+	header := http.Header{"Content-Type": {contentType}}
+	if o.ApiKey != "" {
+		header.Set("Authorization", "Bearer "+o.ApiKey)
+	}
+	do := orDefault(o.HTTP).Do
+	if stream {
+		do = orDefault(o.HTTP).DoStream
+	}
+	resp, err := do(ctx, "POST", o.url(path), header, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		var e struct {
+			Error *openAIError `json:"error"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(msg, &e) == nil && e.Error != nil {
+			return nil, fmt.Errorf("OpenAI API error: %s %s", resp.Status, e.Error.Message)
+		}
+		return nil, fmt.Errorf("OpenAI API error: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// postJSON 以JSON发送请求
+func (o *OpenAIClient) postJSON(ctx context.Context, path string, body map[string]interface{}) (*http.Response, error) {
+	b, _ := json.Marshal(body)
+	return o.post(ctx, path, "application/json", b)
+}
+
+// chatBody 组装 /v1/chat/completions 请求体，opts 透传（temperature、max_tokens 等）
+func (o *OpenAIClient) chatBody(messages []Message, opts map[string]interface{}, stream bool) map[string]interface{} {
+	body := map[string]interface{}{
+		"model":    o.Model,
+		"messages": messages,
+		"stream":   stream,
+	}
+	for k, v := range opts {
+		body[k] = v
+	}
+	return body
+}
+
+func (o *OpenAIClient) Chat(ctx context.Context, messages []Message, opts map[string]interface{}) (string, error) {
+	resp, err := o.postJSON(ctx, "/chat/completions", o.chatBody(messages, opts, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result openAIChatResp
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("OpenAI API error: %s", result.Error.Message)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no choice returned")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+func (o *OpenAIClient) Generate(ctx context.Context, prompt string, opts map[string]interface{}) (string, error) {
+	return o.Chat(ctx, []Message{{Role: "user", Content: prompt}}, opts)
+}
+
+// ChatStream 流式对话，解析 SSE 中的 choices[0].delta.content
+func (o *OpenAIClient) ChatStream(ctx context.Context, messages []Message, opts map[string]interface{}, onToken TokenHandler) (string, error) {
+	b, _ := json.Marshal(o.chatBody(messages, opts, true))
+	resp, err := o.send(ctx, "/chat/completions", "application/json", b, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return sb.String(), nil
+		}
+		var chunk openAIChatResp
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			return sb.String(), fmt.Errorf("OpenAI API error: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		token := chunk.Choices[0].Delta.Content
+		sb.WriteString(token)
+		if err := onToken(token); err != nil {
+			return sb.String(), err
+		}
+	}
+	if ctx.Err() != nil {
+		return sb.String(), ctx.Err()
+	}
+	return sb.String(), scanner.Err()
+}
+
+func (o *OpenAIClient) GenerateStream(ctx context.Context, prompt string, opts map[string]interface{}, onToken TokenHandler) (string, error) {
+	return o.ChatStream(ctx, []Message{{Role: "user", Content: prompt}}, opts, onToken)
+}
+
+// Txt2Img 调用 /v1/images/generations，优先取 b64_json，兼容只返回 url 的实现
+func (o *OpenAIClient) Txt2Img(ctx context.Context, prompt string, opts map[string]interface{}) (ImageResult, error) {
+	body := map[string]interface{}{
+		"model":           o.ImageModel,
+		"prompt":          prompt,
+		"n":               1,
+		"response_format": "b64_json",
+	}
+	for k, v := range opts {
+		body[k] = v
+	}
+	resp, err := o.postJSON(ctx, "/images/generations", body)
+	if err != nil {
+		return ImageResult{}, err
+	}
+	return decodeImageResp(ctx, resp)
+}
+
+// Img2Img 调用 /v1/images/edits（multipart）
+func (o *OpenAIClient) Img2Img(ctx context.Context, image []byte, prompt string, opts map[string]interface{}) (ImageResult, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("image", "image.png")
+	part.Write(image)
+	writeFormFields(writer, map[string]interface{}{
+		"model":           o.ImageModel,
+		"prompt":          prompt,
+		"response_format": "b64_json",
+	}, opts)
+	writer.Close()
+
+	resp, err := o.post(ctx, "/images/edits", writer.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return ImageResult{}, err
+	}
+	return decodeImageResp(ctx, resp)
+}
+
+// writeFormFields 写入表单字段，opts 覆盖默认值
+func writeFormFields(writer *multipart.Writer, fields, opts map[string]interface{}) {
+	for k, v := range opts {
+		fields[k] = v
+	}
+	for k, v := range fields {
+		writer.WriteField(k, fmt.Sprint(v))
+	}
+}
+
+// imageDownloadClient 下载图片接口返回的 url，不经过提供方的并发限制和熔断，CDN 故障不计入提供方失败
+var imageDownloadClient = &http.Client{Timeout: 2 * time.Minute}
+
+// decodeImageResp 解析图片接口响应，先读完并关闭响应体释放并发名额，再按需下载图片
+func decodeImageResp(ctx context.Context, resp *http.Response) (ImageResult, error) {
+	var result struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+			URL     string `json:"url"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		return ImageResult{}, err
+	}
+	if len(result.Data) == 0 {
+		return ImageResult{}, fmt.Errorf("no image returned")
+	}
+	img := result.Data[0]
+	if img.B64JSON != "" {
+		data, err := decodeBase64Image(img.B64JSON)
+		if err != nil {
+			return ImageResult{}, err
+		}
+		return ImageResult{Data: data}, nil
+	}
+	if img.URL == "" {
+		return ImageResult{}, fmt.Errorf("no image returned")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", img.URL, nil)
+	if err != nil {
+		return ImageResult{}, err
+	}
+	imgResp, err := imageDownloadClient.Do(req)
+	if err != nil {
+		return ImageResult{}, err
+	}
+	defer imgResp.Body.Close()
+	if imgResp.StatusCode != 200 {
+		return ImageResult{}, fmt.Errorf("image download error: %s", imgResp.Status)
+	}
+	data, err := io.ReadAll(imgResp.Body)
+	if err != nil {
+		return ImageResult{}, err
+	}
+	return ImageResult{Data: data, URL: img.URL}, nil
+}
+
+// Synthesize 调用 /v1/audio/speech，opts 可覆盖 voice、speed、response_format 等
+// 接口不支持 pitch，忽略；emotion 转为 instructions 语气说明
+func (o *OpenAIClient) Synthesize(ctx context.Context, text string, opts map[string]interface{}) ([]byte, error) {
+	body := map[string]interface{}{
+		"model":           o.TTSModel,
+		"input":           text,
+		"voice":           o.Voice,
+		"response_format": "wav",
+	}
+	for k, v := range opts {
+		switch k {
+		case "pitch":
+		case "emotion":
+			body["instructions"] = fmt.Sprintf("用%v的语气朗读", v)
+		default:
+			body[k] = v
+		}
+	}
+	resp, err := o.postJSON(ctx, "/audio/speech", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Transcribe 调用 /v1/audio/transcriptions（multipart），opts 可传 language、prompt 等
+// 使用 verbose_json 获取分段时间；word_timestamps 为 true 时同时请求逐词时间
+func (o *OpenAIClient) Transcribe(ctx context.Context, audio []byte, opts map[string]interface{}) (*Transcript, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "audio.wav")
+	part.Write(audio)
+	fields := make(map[string]interface{}, len(opts))
+	for k, v := range opts {
+		if k != "word_timestamps" {
+			fields[k] = v
+		}
+	}
+	writeFormFields(writer, map[string]interface{}{
+		"model":           o.ASRModel,
+		"response_format": "verbose_json",
+	}, fields)
+	writer.WriteField("timestamp_granularities[]", "segment")
+	if words, _ := opts["word_timestamps"].(bool); words {
+		writer.WriteField("timestamp_granularities[]", "word")
+	}
+	writer.Close()
+
+	resp, err := o.post(ctx, "/audio/transcriptions", writer.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Transcript
+		Words []TranscriptWord `json:"words"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	segmentsFromWords(result.Segments, result.Words)
+	return &result.Transcript, nil
+}
@@ -0,0 +1,256 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"text/template"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// 提示词模板名
+const (
+	PromptStoryboard   = "storyboard"    // 小说转分镜
+	PromptNovelOutline = "novel_outline" // 小说大纲
+	PromptNovelChapter = "novel_chapter" // 小说单章正文
+	PromptNovelSummary = "novel_summary" // 前情提要压缩
+)
+
+// PromptStore 提示词模板存储，由 prompt 服务实现，通过 SetPromptStore 注入
+type PromptStore interface {
+	ActivePrompts(ctx context.Context, name string) ([]*entity.PromptTemplate, error)
+	GetPrompt(ctx context.Context, name string, version int) (*entity.PromptTemplate, error)
+	RecordUsage(ctx context.Context, usage *entity.PromptUsage) error
+}
+
+var promptStore PromptStore
+
+// SetPromptStore 设置提示词模板存储；未设置时只使用内置提示词
+func SetPromptStore(store PromptStore) {
+	promptStore = store
+}
+
+// BuiltinPrompt 内置提示词，作为数据库初始版本，数据库不可用时兜底（版本号为0）
+type BuiltinPrompt struct {
+	Name        string
+	Description string
+	System      string
+	Content     string
+	Variables   []string
+}
+
+var builtinPrompts = []BuiltinPrompt{
+	{
+		Name:        PromptStoryboard,
+		Description: "小说转分镜，输出画面、旁白与角色台词",
+		Content: `你是一个漫画分镜脚本专家。请将以下小说内容拆分为分镜，直接输出JSON数组，不要输出其它内容、不要输出<think>、不要输出解释说明。
+数组每个元素格式为：{"scene":"画面描述","narration":"旁白","dialogues":[{"speaker":"角色名","line":"台词"}]}
+scene 要简洁、具体、有画面感；narration 为旁白，可为空；dialogues 为该格中角色说出的台词，按说话顺序排列，可为空数组。{{if .characters}}台词的说话人请使用以下角色名之一：{{.characters}}；其他角色使用其在小说中的名字。{{end}}
+小说内容如下：{{.novel}}`,
+		Variables: []string{"novel", "characters"},
+	},
+	{
+		Name:        PromptNovelOutline,
+		Description: "生成小说大纲（JSON）",
+		System:      "你是一位擅长长篇连载的小说家，善于设计起承转合清晰、人物鲜明的故事结构。",
+		Content: `请根据以下创作要求，为一部{{.chapters}}章的中文小说设计大纲。
+创作要求：{{.novel}}
+{{if .title}}小说标题：{{.title}}
+{{end}}{{if .style}}风格题材：{{.style}}
+{{end}}直接输出JSON对象，格式为 {"title":"标题","synopsis":"故事简介","chapters":[{"title":"章节标题","summary":"章节梗概"}]}，chapters 必须恰好{{.chapters}}项，不要输出其它内容、不要输出<think>、不要输出解释说明。`,
+		Variables: []string{"novel", "chapters", "title", "style"},
+	},
+	{
+		Name:        PromptNovelChapter,
+		Description: "基于大纲与前情提要生成单章正文",
+		System:      "你是一位擅长长篇连载的小说家，文笔生动，善于刻画人物和场景。",
+		Content: `小说标题：{{.title}}
+故事简介：{{.synopsis}}
+{{if .style}}风格题材：{{.style}}
+{{end}}{{if .summary}}前情提要：{{.summary}}
+{{end}}{{if .prev_tail}}上一章结尾：{{.prev_tail}}
+{{end}}
+请写第{{.index}}章《{{.chapter_title}}》，本章梗概：{{.chapter_summary}}
+{{if .next_summary}}下一章梗概（仅供衔接，不要提前展开）：{{.next_summary}}
+{{end}}要求：约{{.length}}字，情节与前文自然衔接，多用对话和画面描写。只输出正文，不要输出章节标题、不要输出<think>、不要输出解释说明。`,
+		Variables: []string{"title", "synopsis", "style", "summary", "prev_tail", "index", "chapter_title", "chapter_summary", "next_summary", "length"},
+	},
+	{
+		Name:        PromptNovelSummary,
+		Description: "将已有提要与最新章节压缩为前情提要",
+		System:      "你是小说编辑，擅长精炼地概括故事情节。",
+		Content: `{{if .summary}}已有前情提要：{{.summary}}
+{{end}}最新章节《{{.chapter_title}}》正文：{{.chapter_content}}
+请将以上内容合并为新的前情提要，保留主要人物、关键事件和未解决的伏笔，不超过{{.max_length}}字。只输出提要，不要输出<think>、不要输出解释说明。`,
+		Variables: []string{"summary", "chapter_title", "chapter_content", "max_length"},
+	},
+}
+
+// BuiltinPrompts 所有内置提示词
+func BuiltinPrompts() []BuiltinPrompt {
+	return builtinPrompts
+}
+
+func builtinPrompt(name string) (BuiltinPrompt, bool) {
+	for _, p := range builtinPrompts {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return BuiltinPrompt{}, false
+}
+
+// Prompt 渲染后的提示词
+type Prompt struct {
+	Name       string
+	Version    int        // 0 表示内置提示词
+	TemplateID *uuid.UUID // 内置提示词为空
+	System     string
+	Text       string
+	Params     map[string]interface{} // 模板自带的调用参数
+}
+
+// Messages 组装对话消息，系统提示词为空时省略
+func (p *Prompt) Messages() []Message {
+	var messages []Message
+	if p.System != "" {
+		messages = append(messages, Message{Role: "system", Content: p.System})
+	}
+	return append(messages, Message{Role: "user", Content: p.Text})
+}
+
+// Options 在提供方默认参数上叠加模板参数
+func (p *Prompt) Options(base map[string]interface{}) map[string]interface{} {
+	opts := make(map[string]interface{}, len(base)+len(p.Params))
+	for k, v := range base {
+		opts[k] = v
+	}
+	for k, v := range p.Params {
+		opts[k] = v
+	}
+	return opts
+}
+
+// RenderTemplate 按 text/template 语法渲染系统提示词与正文，变量以 {{.name}} 引用
+func RenderTemplate(system, content string, vars map[string]interface{}) (string, string, error) {
+	render := func(name, text string) (string, error) {
+		if text == "" {
+			return "", nil
+		}
+		tpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, vars); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+	}
+	sys, err := render("system", system)
+	if err != nil {
+		return "", "", fmt.Errorf("系统提示词模板错误: %w", err)
+	}
+	text, err := render("content", content)
+	if err != nil {
+		return "", "", fmt.Errorf("提示词模板错误: %w", err)
+	}
+	return sys, text, nil
+}
+
+// RenderPrompt 为任务渲染提示词
+// 同名有多个启用版本时按权重随机选择（A/B测试），同一任务内固定使用首次选中的版本；
+// 选中的版本记录在 task.PromptVersions 中，并写入使用记录供效果统计
+func RenderPrompt(ctx context.Context, task *entity.Task, name string, vars map[string]interface{}) (*Prompt, error) {
+	tpl, err := selectPrompt(ctx, task, name)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prompt{Name: name}
+	system, content := "", ""
+	if tpl != nil {
+		p.Version = tpl.Version
+		p.TemplateID = &tpl.ID
+		system, content = tpl.System, tpl.Content
+		if tpl.ModelParams != "" {
+			if err := json.Unmarshal([]byte(tpl.ModelParams), &p.Params); err != nil {
+				log.Printf("[AI] 提示词 %s v%d 调用参数格式错误，已忽略: %v", name, tpl.Version, err)
+			}
+		}
+	} else {
+		builtin, ok := builtinPrompt(name)
+		if !ok {
+			return nil, fmt.Errorf("提示词不存在: %s", name)
+		}
+		system, content = builtin.System, builtin.Content
+	}
+	if p.System, p.Text, err = RenderTemplate(system, content, vars); err != nil {
+		return nil, err
+	}
+
+	versions := TaskPromptVersions(task)
+	if _, recorded := versions[name]; !recorded && task != nil {
+		versions[name] = p.Version
+		b, _ := json.Marshal(versions)
+		task.PromptVersions = string(b)
+		if promptStore != nil {
+			usage := &entity.PromptUsage{TaskID: task.ID, TaskType: task.Type, TemplateID: p.TemplateID, Name: name, Version: p.Version}
+			if err := promptStore.RecordUsage(ctx, usage); err != nil {
+				log.Printf("[AI] 记录提示词使用失败: %v task=%v", err, task.ID)
+			}
+		}
+	}
+	return p, nil
+}
+
+// selectPrompt 选择模板版本，返回 nil 表示使用内置提示词
+func selectPrompt(ctx context.Context, task *entity.Task, name string) (*entity.PromptTemplate, error) {
+	if promptStore == nil {
+		return nil, nil
+	}
+	if version, ok := TaskPromptVersions(task)[name]; ok {
+		if version == 0 {
+			return nil, nil
+		}
+		return promptStore.GetPrompt(ctx, name, version)
+	}
+	candidates, err := promptStore.ActivePrompts(ctx, name)
+	if err != nil {
+		log.Printf("[AI] 读取提示词 %s 失败，使用内置版本: %v", name, err)
+		return nil, nil
+	}
+	total := 0
+	for _, c := range candidates {
+		total += c.Weight
+	}
+	if total <= 0 {
+		if len(candidates) > 0 {
+			return candidates[0], nil
+		}
+		return nil, nil
+	}
+	n := rand.Intn(total)
+	for _, c := range candidates {
+		if n < c.Weight {
+			return c, nil
+		}
+		n -= c.Weight
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+// TaskPromptVersions 任务已使用的提示词版本（模板名 → 版本号）
+func TaskPromptVersions(task *entity.Task) map[string]int {
+	versions := map[string]int{}
+	if task != nil && task.PromptVersions != "" {
+		_ = json.Unmarshal([]byte(task.PromptVersions), &versions)
+	}
+	return versions
+}
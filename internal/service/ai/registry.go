@@ -1,5 +1,13 @@
 package ai
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"comic_video/internal/domain/entity"
+)
+
 var imageGenRegistry = make(map[string]ImageGen)
 var textGenRegistry = make(map[string]TextGen)
 var audio2TextRegistry = make(map[string]Audio2Text)
@@ -28,4 +36,178 @@ func RegisterTTS(name string, gen TTS) {
 }
 func GetTTS(name string) TTS {
 	return ttsRegistry[name]
-} 
\ No newline at end of file
+}
+
+// ProviderChoice 指定某项能力使用的提供方和模型，为空表示使用默认值
+type ProviderChoice struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// ProviderOverrides 任务级的提供方/模型覆盖，随任务参数 providers 字段传入
+type ProviderOverrides struct {
+	TextGen    ProviderChoice `json:"text_gen"`
+	ImageGen   ProviderChoice `json:"image_gen"`
+	TTS        ProviderChoice `json:"tts"`
+	Audio2Text ProviderChoice `json:"audio2text"`
+}
+
+// Providers 一次任务实际使用的AI能力及各自的调用参数
+type Providers struct {
+	TextGen        TextGen
+	ImageGen       ImageGen
+	TTS            TTS
+	Audio2Text     Audio2Text
+	TextGenOpts    map[string]interface{}
+	ImageGenOpts   map[string]interface{}
+	TTSOpts        map[string]interface{}
+	Audio2TextOpts map[string]interface{}
+	Names          ProviderOverrides // 实际选用的提供方与模型，便于记录
+
+	cache *GenerationCache // 非空时文本、图片、配音结果走生成缓存
+}
+
+// 默认提供方与默认调用参数，由 InitAIProviders 按配置设置
+var defaultProviders ProviderOverrides
+var defaultOptions = struct {
+	TextGen, ImageGen, TTS, Audio2Text map[string]interface{}
+}{}
+
+// ResolveProviders 按默认配置和任务级覆盖从注册表解析AI能力
+// 指定了未注册的提供方，或为不支持切换模型的提供方指定了模型时返回错误；模型覆盖通过 opts["model"] 传给客户端
+func ResolveProviders(override ProviderOverrides) (*Providers, error) {
+	p := &Providers{}
+	names := defaultProviders
+	mergeChoice(&names.TextGen, override.TextGen)
+	mergeChoice(&names.ImageGen, override.ImageGen)
+	mergeChoice(&names.TTS, override.TTS)
+	mergeChoice(&names.Audio2Text, override.Audio2Text)
+	p.Names = names
+
+	if p.TextGen = GetTextGen(names.TextGen.Provider); p.TextGen == nil && override.TextGen.Provider != "" {
+		return nil, fmt.Errorf("未注册的文本生成提供方: %s", names.TextGen.Provider)
+	}
+	if p.ImageGen = GetImageGen(names.ImageGen.Provider); p.ImageGen == nil && override.ImageGen.Provider != "" {
+		return nil, fmt.Errorf("未注册的图片生成提供方: %s", names.ImageGen.Provider)
+	}
+	if p.TTS = GetTTS(names.TTS.Provider); p.TTS == nil && override.TTS.Provider != "" {
+		return nil, fmt.Errorf("未注册的语音合成提供方: %s", names.TTS.Provider)
+	}
+	if p.Audio2Text = GetAudio2Text(names.Audio2Text.Provider); p.Audio2Text == nil && override.Audio2Text.Provider != "" {
+		return nil, fmt.Errorf("未注册的语音识别提供方: %s", names.Audio2Text.Provider)
+	}
+
+	checks := []struct {
+		kind   string
+		client interface{}
+		choice ProviderChoice
+	}{
+		{"文本生成", p.TextGen, names.TextGen},
+		{"图片生成", p.ImageGen, names.ImageGen},
+		{"语音合成", p.TTS, names.TTS},
+		{"语音识别", p.Audio2Text, names.Audio2Text},
+	}
+	for _, c := range checks {
+		if err := checkModel(c.kind, c.client, c.choice); err != nil {
+			return nil, err
+		}
+	}
+
+	p.TextGenOpts = withModel(defaultOptions.TextGen, names.TextGen.Model)
+	p.ImageGenOpts = withModel(defaultOptions.ImageGen, names.ImageGen.Model)
+	p.TTSOpts = withModel(defaultOptions.TTS, names.TTS.Model)
+	p.Audio2TextOpts = withModel(defaultOptions.Audio2Text, names.Audio2Text.Model)
+	return p, nil
+}
+
+// ResolveTaskProviders 读取任务参数中的 providers 覆盖并解析AI能力
+// 已配置生成缓存时默认启用，任务参数 no_cache 为 true 时全部重新生成
+func ResolveTaskProviders(task *entity.Task) (*Providers, error) {
+	var params struct {
+		Providers ProviderOverrides `json:"providers"`
+		NoCache   bool              `json:"no_cache"`
+	}
+	_ = json.Unmarshal([]byte(task.Params), &params)
+	p, err := ResolveProviders(params.Providers)
+	if err != nil {
+		return nil, err
+	}
+	if generationCache != nil && !params.NoCache {
+		p.withCache(generationCache)
+	}
+	return p, nil
+}
+
+// ProviderNames 列出已注册的提供方名称
+func ProviderNames() map[string][]string {
+	return map[string][]string{
+		"text_gen":   sortedKeys(textGenRegistry),
+		"image_gen":  sortedKeys(imageGenRegistry),
+		"tts":        sortedKeys(ttsRegistry),
+		"audio2text": sortedKeys(audio2TextRegistry),
+	}
+}
+
+// mergeChoice 用覆盖值替换默认值；切换提供方时不沿用默认模型
+func mergeChoice(dst *ProviderChoice, override ProviderChoice) {
+	if override.Provider != "" && override.Provider != dst.Provider {
+		dst.Provider = override.Provider
+		dst.Model = ""
+	}
+	if override.Model != "" {
+		dst.Model = override.Model
+	}
+}
+
+// supportsModel 客户端是否支持按调用切换模型；Edge TTS 与 Whisper 服务的模型在服务端部署时固定
+func supportsModel(client interface{}) bool {
+	switch client.(type) {
+	case *OllamaClient, *OpenAIClient, *SDClient:
+		return true
+	}
+	return false
+}
+
+// checkModel 为不支持切换模型的提供方指定了模型时返回错误，避免 model 被当作普通参数透传
+func checkModel(kind string, client interface{}, choice ProviderChoice) error {
+	if choice.Model == "" || client == nil || supportsModel(client) {
+		return nil
+	}
+	return fmt.Errorf("%s提供方 %s 不支持指定模型: %s", kind, choice.Provider, choice.Model)
+}
+
+// withModel 复制默认参数并写入模型覆盖
+func withModel(opts map[string]interface{}, model string) map[string]interface{} {
+	out := make(map[string]interface{}, len(opts)+1)
+	for k, v := range opts {
+		out[k] = v
+	}
+	if model != "" {
+		out["model"] = model
+	}
+	return out
+}
+
+// modelFromOpts 从 opts 中取出模型覆盖，返回不含 model 的参数副本
+func modelFromOpts(opts map[string]interface{}, defaultModel string) (string, map[string]interface{}) {
+	model, _ := opts["model"].(string)
+	if model == "" {
+		return defaultModel, opts
+	}
+	rest := make(map[string]interface{}, len(opts))
+	for k, v := range opts {
+		if k != "model" {
+			rest[k] = v
+		}
+	}
+	return model, rest
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
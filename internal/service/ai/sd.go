@@ -1,27 +1,34 @@
 package ai
 
 import (
-	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"encoding/base64"
 )
 
 type SDClient struct {
-	Endpoint string // 例如 http://127.0.0.1:7860
+	Endpoint string      // 例如 http://127.0.0.1:7860
+	HTTP     *HTTPClient // 共享HTTP调用层，为空时使用默认配置
 }
 
-func (s *SDClient) Txt2Img(prompt string, opts map[string]interface{}) (ImageResult, error) {
-	// 组装请求体
+var _ ImageGen = (*SDClient)(nil)
+
+func (s *SDClient) Txt2Img(ctx context.Context, prompt string, opts map[string]interface{}) (ImageResult, error) {
+	// 组装请求体，opts["model"] 映射为 SD WebUI 的模型切换参数
+	model, opts := modelFromOpts(opts, "")
 	body := map[string]interface{}{
 		"prompt": prompt,
 	}
 	for k, v := range opts {
 		body[k] = v
 	}
+	if model != "" {
+		body["override_settings"] = map[string]interface{}{"sd_model_checkpoint": model}
+	}
 	b, _ := json.Marshal(body)
-	resp, err := http.Post(s.Endpoint+"/sdapi/v1/txt2img", "application/json", bytes.NewReader(b))
+	resp, err := orDefault(s.HTTP).Do(ctx, "POST", s.Endpoint+"/sdapi/v1/txt2img", http.Header{"Content-Type": {"application/json"}}, b)
 	if err != nil {
 		return ImageResult{}, err
 	}
@@ -46,7 +53,7 @@ func (s *SDClient) Txt2Img(prompt string, opts map[string]interface{}) (ImageRes
 	return ImageResult{Data: imgData}, nil
 }
 
-func (s *SDClient) Img2Img(image []byte, prompt string, opts map[string]interface{}) (ImageResult, error) {
+func (s *SDClient) Img2Img(ctx context.Context, image []byte, prompt string, opts map[string]interface{}) (ImageResult, error) {
 	// 可扩展，暂未实现
 	return ImageResult{}, fmt.Errorf("not implemented")
 }
@@ -54,4 +61,4 @@ func (s *SDClient) Img2Img(image []byte, prompt string, opts map[string]interfac
 // decodeBase64Image 解码base64图片
 func decodeBase64Image(b64 string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(b64)
-} 
\ No newline at end of file
+}
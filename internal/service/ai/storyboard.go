@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+
+	"comic_video/internal/domain/entity"
+)
+
+// DialogueLine 分镜中的一句台词
+type DialogueLine struct {
+	Speaker string `json:"speaker"` // 说话角色，与 VoiceCast.Characters 的键对应
+	Line    string `json:"line"`
+}
+
+// StoryPanel 一格分镜：画面描述用于出图，旁白与台词用于配音
+type StoryPanel struct {
+	Scene     string         `json:"scene"`
+	Narration string         `json:"narration"`
+	Dialogues []DialogueLine `json:"dialogues"`
+}
+
+// PanelStore 分镜稿存储，由 storyboard 服务实现，通过 SetPanelStore 注入
+// 小说转视频任务出图完成后保存分镜与图片，之后可逐格修改、重绘并重新合成
+type PanelStore interface {
+	SavePanels(ctx context.Context, task *entity.Task, panels []StoryPanel, seeds []int64, images [][]byte) error
+	// SaveAudio 保存配音音轨及每格时长，用于转存为可编辑项目
+	SaveAudio(ctx context.Context, task *entity.Task, track *AudioTrack) error
+}
+
+var panelStore PanelStore
+
+// SetPanelStore 设置分镜稿存储；未设置时分镜不落库，任务只能整体重跑
+func SetPanelStore(store PanelStore) {
+	panelStore = store
+}
+
+// seededImageProviders 支持通过 seed 固定出图结果的图片提供方
+var seededImageProviders = map[string]bool{"sd": true}
+
+// PanelImageOptions 分镜出图参数及实际使用的 seed
+// 提供方不支持 seed 时返回0；seed 为0时优先使用默认参数中的固定 seed，否则由画面描述派生，
+// 使未修改的分镜重跑时得到相同的图片（并命中生成缓存）
+func PanelImageOptions(providers *Providers, scene string, seed int64) (map[string]interface{}, int64) {
+	opts := withModel(providers.ImageGenOpts, "")
+	if !seededImageProviders[providers.Names.ImageGen.Provider] {
+		return opts, 0
+	}
+	if seed == 0 {
+		if v, ok := opts["seed"].(float64); ok && v > 0 {
+			seed = int64(v)
+		} else {
+			h := fnv.New32a()
+			h.Write([]byte(scene))
+			seed = int64(h.Sum32()%2147483646) + 1
+		}
+	}
+	opts["seed"] = seed
+	return opts, seed
+}
+
+// NewSeed 随机 seed，用于重绘
+func NewSeed() int64 {
+	return rand.Int63n(2147483646) + 1
+}
+
+// storyboardVars 分镜提示词变量，指定了角色音色时要求说话人使用相同的角色名
+func storyboardVars(novel string, cast *VoiceCast) map[string]interface{} {
+	return map[string]interface{}{
+		"novel":      novel,
+		"characters": strings.Join(cast.CharacterNames(), "、"),
+	}
+}
+
+// parseStoryboard 解析分镜输出，兼容旧格式的字符串数组（整句作为画面和旁白）
+func parseStoryboard(script string) ([]StoryPanel, error) {
+	raw := extractJSON(script)
+	var panels []StoryPanel
+	if err := json.Unmarshal([]byte(raw), &panels); err == nil {
+		valid := panels[:0]
+		for _, p := range panels {
+			if strings.TrimSpace(p.Scene) != "" {
+				valid = append(valid, p)
+			}
+		}
+		if len(valid) > 0 {
+			return valid, nil
+		}
+	}
+	var lines []string
+	if err := json.Unmarshal([]byte(raw), &lines); err == nil && len(lines) > 0 {
+		panels = make([]StoryPanel, 0, len(lines))
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				panels = append(panels, StoryPanel{Scene: line, Narration: line})
+			}
+		}
+		if len(panels) > 0 {
+			return panels, nil
+		}
+	}
+	return nil, fmt.Errorf("panel parse error")
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"comic_video/internal/utils"
+)
+
+// 字幕切分参数
+const (
+	maxCueRunes    = 32  // 单条字幕最多字符数，超出时按逐词时间切分
+	maxCueDuration = 7.0 // 单条字幕最长显示时间（秒）
+)
+
+// TranscriptWord 逐词时间戳
+type TranscriptWord struct {
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Word        string  `json:"word"`
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// TranscriptSegment 识别分段，Words 在提供方支持逐词时间时返回
+type TranscriptSegment struct {
+	Start float64          `json:"start"`
+	End   float64          `json:"end"`
+	Text  string           `json:"text"`
+	Words []TranscriptWord `json:"words,omitempty"`
+}
+
+// Transcript 语音识别结果
+type Transcript struct {
+	Text     string              `json:"text"`
+	Language string              `json:"language"` // 识别或检测出的语言
+	Duration float64             `json:"duration"`
+	Segments []TranscriptSegment `json:"segments"`
+}
+
+// Cues 转为字幕条目：过长的分段在有逐词时间时切分为多条
+func (t *Transcript) Cues() []utils.SubtitleCue {
+	var cues []utils.SubtitleCue
+	for _, seg := range t.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if len(seg.Words) == 0 || (utf8.RuneCountInString(text) <= maxCueRunes && seg.End-seg.Start <= maxCueDuration) {
+			cues = append(cues, utils.SubtitleCue{Start: seg.Start, End: seg.End, Text: text})
+			continue
+		}
+		cues = append(cues, splitWords(seg.Words)...)
+	}
+	return cues
+}
+
+// splitWords 按字数和时长将逐词结果分组为字幕
+func splitWords(words []TranscriptWord) []utils.SubtitleCue {
+	var cues []utils.SubtitleCue
+	var cur utils.SubtitleCue
+	var sb strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(sb.String()); text != "" {
+			cur.Text = text
+			cues = append(cues, cur)
+		}
+		sb.Reset()
+	}
+	for _, w := range words {
+		if sb.Len() > 0 && (utf8.RuneCountInString(sb.String()+w.Word) > maxCueRunes || w.End-cur.Start > maxCueDuration) {
+			flush()
+		}
+		if sb.Len() == 0 {
+			cur.Start = w.Start
+		}
+		sb.WriteString(w.Word)
+		cur.End = w.End
+	}
+	flush()
+	return cues
+}
+
+// SRT 导出 SRT 字幕
+func (t *Transcript) SRT() string {
+	return utils.FormatSRT(t.Cues())
+}
+
+// VTT 导出 WebVTT 字幕
+func (t *Transcript) VTT() string {
+	return utils.FormatVTT(t.Cues())
+}
+
+// segmentsFromWords 提供方只返回顶层逐词结果时，按分段时间归属到各分段
+func segmentsFromWords(segments []TranscriptSegment, words []TranscriptWord) {
+	i := 0
+	for _, w := range words {
+		for i < len(segments)-1 && w.Start >= segments[i].End {
+			i++
+		}
+		if i < len(segments) {
+			segments[i].Words = append(segments[i].Words, w)
+		}
+	}
+}
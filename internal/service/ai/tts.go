@@ -1,7 +1,7 @@
 package ai
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,10 +9,13 @@ import (
 )
 
 type TTSClient struct {
-	Endpoint string // 例如 http://127.0.0.1:50021
+	Endpoint string      // 例如 http://127.0.0.1:50021
+	HTTP     *HTTPClient // 共享HTTP调用层，为空时使用默认配置
 }
 
-func (t *TTSClient) Synthesize(text string, opts map[string]interface{}) ([]byte, error) {
+var _ TTS = (*TTSClient)(nil)
+
+func (t *TTSClient) Synthesize(ctx context.Context, text string, opts map[string]interface{}) ([]byte, error) {
 	body := map[string]interface{}{
 		"text": text,
 	}
@@ -20,7 +23,7 @@ func (t *TTSClient) Synthesize(text string, opts map[string]interface{}) ([]byte
 		body[k] = v
 	}
 	b, _ := json.Marshal(body)
-	resp, err := http.Post(t.Endpoint+"/tts", "application/json", bytes.NewReader(b))
+	resp, err := orDefault(t.HTTP).Do(ctx, "POST", t.Endpoint+"/tts", http.Header{"Content-Type": {"application/json"}}, b)
 	if err != nil {
 		return nil, err
 	}
@@ -29,4 +32,4 @@ func (t *TTSClient) Synthesize(text string, opts map[string]interface{}) ([]byte
 		return nil, fmt.Errorf("TTS API error: %s", resp.Status)
 	}
 	return io.ReadAll(resp.Body)
-} 
\ No newline at end of file
+}
@@ -1,5 +1,7 @@
 package ai
 
+import "context"
+
 // ImageResult 图片生成结果
 // 可扩展更多字段，如base64、url等
 //
@@ -11,10 +13,13 @@ type ImageResult struct {
 // Message 对话消息体
 //
 type Message struct {
-	Role    string // user/assistant/system
-	Content string
+	Role    string `json:"role"` // user/assistant/system
+	Content string `json:"content"`
 }
 
+// TokenHandler 流式输出回调，每收到一段文本调用一次；返回错误时中止生成
+type TokenHandler func(token string) error
+
 // 业务场景建议：
 // 1. 漫画生成：TextGen 生成剧情脚本/分镜描述，ImageGen 生成分镜图片
 // 2. 小说/推文生成：TextGen 生成长文本/短文案
@@ -22,20 +27,26 @@ type Message struct {
 // 4. 其它AI能力可按需扩展
 // 
 
+// 所有接口均接收 ctx，取消或超时时中断对提供方的请求
+
 type ImageGen interface {
-	Txt2Img(prompt string, opts map[string]interface{}) (ImageResult, error)
-	Img2Img(image []byte, prompt string, opts map[string]interface{}) (ImageResult, error)
+	Txt2Img(ctx context.Context, prompt string, opts map[string]interface{}) (ImageResult, error)
+	Img2Img(ctx context.Context, image []byte, prompt string, opts map[string]interface{}) (ImageResult, error)
 }
 
 type TextGen interface {
-	Chat(messages []Message, opts map[string]interface{}) (string, error)
-	Generate(prompt string, opts map[string]interface{}) (string, error)
+	Chat(ctx context.Context, messages []Message, opts map[string]interface{}) (string, error)
+	Generate(ctx context.Context, prompt string, opts map[string]interface{}) (string, error)
+	// 流式接口：ctx 取消即中断生成，返回已生成的完整文本
+	ChatStream(ctx context.Context, messages []Message, opts map[string]interface{}, onToken TokenHandler) (string, error)
+	GenerateStream(ctx context.Context, prompt string, opts map[string]interface{}, onToken TokenHandler) (string, error)
 }
 
+// Audio2Text 语音识别，opts 常用键：language（为空自动检测）、word_timestamps（是否返回逐词时间）
 type Audio2Text interface {
-	Transcribe(audio []byte, opts map[string]interface{}) (string, error)
+	Transcribe(ctx context.Context, audio []byte, opts map[string]interface{}) (*Transcript, error)
 }
 
 type TTS interface {
-	Synthesize(text string, opts map[string]interface{}) ([]byte, error)
+	Synthesize(ctx context.Context, text string, opts map[string]interface{}) ([]byte, error)
 } 
\ No newline at end of file
@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 配音拼接参数
+const (
+	voiceSampleRate  = 24000 // 各片段统一转为 24kHz 单声道 PCM 后拼接
+	voiceLineGap     = 0.3   // 相邻两句之间的停顿（秒）
+	voiceSilentPanel = 2.0   // 无旁白无台词的分镜停留时长（秒）
+	voicePreviewText = "你好，这是一段音色试听。今天的天气真不错，我们出发吧！"
+)
+
+// ErrInvalidVoice 音色配置不可用（提供方未注册、不支持指定模型），属于调用方错误
+var ErrInvalidVoice = errors.New("音色配置无效")
+
+// VoiceProfile 音色配置，Speed/Pitch 为 0 时使用提供方默认值
+type VoiceProfile struct {
+	Provider string  `json:"provider,omitempty"` // TTS提供方，为空使用任务默认提供方
+	Model    string  `json:"model,omitempty"`
+	Voice    string  `json:"voice,omitempty"` // 提供方音色ID，如 alloy、zh-CN-XiaoxiaoNeural
+	Speed    float64 `json:"speed,omitempty"` // 语速倍率，1 为正常
+	Pitch    float64 `json:"pitch,omitempty"` // 音调偏移
+	Emotion  string  `json:"emotion,omitempty"`
+}
+
+// VoiceCast 旁白与各角色的音色分配，未分配音色的角色使用旁白音色
+type VoiceCast struct {
+	Narrator   VoiceProfile            `json:"narrator"`
+	Characters map[string]VoiceProfile `json:"characters"`
+}
+
+// For 取说话人的音色，speaker 为空表示旁白
+func (c *VoiceCast) For(speaker string) VoiceProfile {
+	if c == nil {
+		return VoiceProfile{}
+	}
+	if v, ok := c.Characters[speaker]; ok && speaker != "" {
+		return v
+	}
+	return c.Narrator
+}
+
+// CharacterNames 已分配音色的角色名
+func (c *VoiceCast) CharacterNames() []string {
+	if c == nil {
+		return nil
+	}
+	return sortedKeys(c.Characters)
+}
+
+// Validate 检查音色配置中指定的提供方均已注册，指定的模型为提供方所支持
+// ttsProvider 为任务实际使用的语音合成提供方，音色未指定提供方时按它检查模型
+func (c *VoiceCast) Validate(ttsProvider string) error {
+	if c == nil {
+		return nil
+	}
+	profiles := map[string]VoiceProfile{"旁白": c.Narrator}
+	for name, v := range c.Characters {
+		profiles[name] = v
+	}
+	for name, v := range profiles {
+		if v.Provider != "" && GetTTS(v.Provider) == nil {
+			return fmt.Errorf("%s的音色使用了未注册的语音合成提供方: %s", name, v.Provider)
+		}
+		provider := ttsProvider
+		if v.Provider != "" {
+			provider = v.Provider
+		}
+		if err := checkModel("语音合成", GetTTS(provider), ProviderChoice{Provider: provider, Model: v.Model}); err != nil {
+			return fmt.Errorf("%s的音色: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// AudioSegment 配音时间轴上的一句
+type AudioSegment struct {
+	Panel   int     `json:"panel"`   // 所属分镜序号，从0开始
+	Speaker string  `json:"speaker"` // 为空表示旁白
+	Text    string  `json:"text"`
+	Start   float64 `json:"start"` // 秒
+	End     float64 `json:"end"`
+}
+
+// AudioTrack 拼接后的配音，PanelDurations 为每格分镜的显示时长
+type AudioTrack struct {
+	Path           string
+	PanelDurations []float64
+	Segments       []AudioSegment
+}
+
+// voiceTTS 按音色解析TTS能力与调用参数，未指定提供方时使用任务默认TTS
+func voiceTTS(providers *Providers, v VoiceProfile) (TTS, map[string]interface{}, error) {
+	tts, defaults := providers.TTS, providers.TTSOpts
+	if v.Provider != "" && v.Provider != providers.Names.TTS.Provider {
+		// 换用其他提供方时不沿用默认提供方的调用参数
+		if tts = GetTTS(v.Provider); tts == nil {
+			return nil, nil, fmt.Errorf("%w: 未注册的语音合成提供方: %s", ErrInvalidVoice, v.Provider)
+		}
+		tts = providers.cache.wrapTTS(v.Provider, tts)
+		defaults = nil
+	}
+	opts := withModel(defaults, "")
+	if tts == nil {
+		return nil, nil, fmt.Errorf("语音合成提供方未配置")
+	}
+	if v.Model != "" {
+		name := providers.Names.TTS.Provider
+		if v.Provider != "" {
+			name = v.Provider
+		}
+		if err := checkModel("语音合成", GetTTS(name), ProviderChoice{Provider: name, Model: v.Model}); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidVoice, err)
+		}
+		opts["model"] = v.Model
+	}
+	if v.Voice != "" {
+		opts["voice"] = v.Voice
+	}
+	if v.Speed != 0 {
+		opts["speed"] = v.Speed
+	}
+	if v.Pitch != 0 {
+		opts["pitch"] = v.Pitch
+	}
+	if v.Emotion != "" {
+		opts["emotion"] = v.Emotion
+	}
+	return tts, opts, nil
+}
+
+// PreviewVoice 用指定音色合成一段试听音频，text 为空时使用默认示例文本
+// 音色配置不可用时返回的错误包装 ErrInvalidVoice，其余为提供方或服务配置错误
+func PreviewVoice(ctx context.Context, v VoiceProfile, text string) ([]byte, error) {
+	providers, err := ResolveProviders(ProviderOverrides{})
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		text = voicePreviewText
+	}
+	tts, opts, err := voiceTTS(providers, v)
+	if err != nil {
+		return nil, err
+	}
+	return tts.Synthesize(ctx, text, opts)
+}
+
+// SynthesizeStoryboard 逐句合成旁白与台词并按顺序拼接
+// 每句使用说话人的音色，句间插入停顿；每格分镜的时长等于其所有语句的时长之和
+// onLine 在每句合成后回调，用于上报进度
+func SynthesizeStoryboard(ctx context.Context, providers *Providers, cast *VoiceCast, panels []StoryPanel, dir string, onLine func(done, total int)) (*AudioTrack, error) {
+	type line struct {
+		panel   int
+		speaker string
+		text    string
+	}
+	var lines []line
+	for i, p := range panels {
+		if t := strings.TrimSpace(p.Narration); t != "" {
+			lines = append(lines, line{panel: i, text: t})
+		}
+		for _, d := range p.Dialogues {
+			if t := strings.TrimSpace(d.Line); t != "" {
+				lines = append(lines, line{panel: i, speaker: strings.TrimSpace(d.Speaker), text: t})
+			}
+		}
+	}
+
+	gapPath := filepath.Join(dir, "gap.wav")
+	if err := writeSilenceWAV(gapPath, voiceLineGap); err != nil {
+		return nil, err
+	}
+	silentPath := filepath.Join(dir, "silent.wav")
+	if err := writeSilenceWAV(silentPath, voiceSilentPanel); err != nil {
+		return nil, err
+	}
+
+	track := &AudioTrack{
+		Path:           filepath.Join(dir, "audio.wav"),
+		PanelDurations: make([]float64, len(panels)),
+	}
+	var files []string
+	var cursor float64
+	next := 0
+	for i := range panels {
+		start := cursor
+		spoken := false
+		for ; next < len(lines) && lines[next].panel == i; next++ {
+			l := lines[next]
+			v := cast.For(l.speaker)
+			tts, opts, err := voiceTTS(providers, v)
+			if err != nil {
+				return nil, err
+			}
+			audio, err := tts.Synthesize(ctx, l.text, opts)
+			if err != nil {
+				return nil, fmt.Errorf("第%d格配音失败(%s): %w", i+1, speakerLabel(l.speaker), err)
+			}
+			segPath, duration, err := normalizeSegment(dir, next, audio)
+			if err != nil {
+				return nil, fmt.Errorf("第%d格配音处理失败: %w", i+1, err)
+			}
+			if spoken {
+				files = append(files, gapPath)
+				cursor += voiceLineGap
+			}
+			files = append(files, segPath)
+			track.Segments = append(track.Segments, AudioSegment{
+				Panel:   i,
+				Speaker: l.speaker,
+				Text:    l.text,
+				Start:   cursor,
+				End:     cursor + duration,
+			})
+			cursor += duration
+			spoken = true
+			if onLine != nil {
+				onLine(next+1, len(lines))
+			}
+		}
+		if !spoken {
+			files = append(files, silentPath)
+			cursor += voiceSilentPanel
+		} else if i < len(panels)-1 {
+			// 分镜之间同样留出停顿，计入前一格
+			files = append(files, gapPath)
+			cursor += voiceLineGap
+		}
+		track.PanelDurations[i] = cursor - start
+	}
+
+	if err := concatAudio(dir, files, track.Path); err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+func speakerLabel(speaker string) string {
+	if speaker == "" {
+		return "旁白"
+	}
+	return speaker
+}
+
+// normalizeSegment 将提供方返回的音频（wav/mp3等）统一转码为 PCM WAV 并返回时长
+func normalizeSegment(dir string, idx int, audio []byte) (string, float64, error) {
+	rawPath := filepath.Join(dir, fmt.Sprintf("seg_%04d.raw", idx))
+	if err := os.WriteFile(rawPath, audio, 0644); err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(rawPath)
+	segPath := filepath.Join(dir, fmt.Sprintf("seg_%04d.wav", idx))
+	cmd := exec.Command("ffmpeg", "-y", "-i", rawPath, "-ar", strconv.Itoa(voiceSampleRate), "-ac", "1", "-c:a", "pcm_s16le", segPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", 0, fmt.Errorf("ffmpeg: %v %s", err, lastRunes(string(out), 300))
+	}
+	duration, err := probeDuration(segPath)
+	if err != nil {
+		return "", 0, err
+	}
+	return segPath, duration, nil
+}
+
+// concatAudio 按顺序拼接同格式的 WAV 片段
+func concatAudio(dir string, files []string, output string) error {
+	listPath := filepath.Join(dir, "audio.txt")
+	var sb strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&sb, "file '%s'\n", f)
+	}
+	if err := os.WriteFile(listPath, []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+	cmd := exec.Command("ffmpeg", "-y", "-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg: %v %s", err, lastRunes(string(out), 300))
+	}
+	return nil
+}
+
+// probeDuration 获取音视频时长（秒）
+func probeDuration(path string) (float64, error) {
+	out, err := exec.Command("ffprobe", "-v", "quiet", "-show_entries", "format=duration", "-of", "csv=p=0", path).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+// writeSilenceWAV 写入指定时长的静音 WAV（与 normalizeSegment 输出格式一致）
+func writeSilenceWAV(path string, seconds float64) error {
+	dataLen := uint32(seconds*voiceSampleRate) * 2
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataLen)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1) // 单声道
+	binary.LittleEndian.PutUint32(header[24:], voiceSampleRate)
+	binary.LittleEndian.PutUint32(header[28:], voiceSampleRate*2)
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataLen)
+	return os.WriteFile(path, append(header, make([]byte, dataLen)...), 0644)
+}
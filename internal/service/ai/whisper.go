@@ -2,37 +2,48 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"mime/multipart"
+	"net/http"
+	"net/url"
 )
 
 type WhisperClient struct {
-	Endpoint string // 例如 http://127.0.0.1:9000
+	Endpoint string      // 例如 http://127.0.0.1:9000
+	HTTP     *HTTPClient // 共享HTTP调用层，为空时使用默认配置
 }
 
-func (w *WhisperClient) Transcribe(audio []byte, opts map[string]interface{}) (string, error) {
-	// 假设API为 /asr，multipart上传
+var _ Audio2Text = (*WhisperClient)(nil)
+
+// Transcribe 调用 whisper-asr-webservice 的 /asr 接口（multipart），output=json 时返回分段与逐词时间
+// opts 以查询参数透传，如 language、word_timestamps、initial_prompt、vad_filter
+func (w *WhisperClient) Transcribe(ctx context.Context, audio []byte, opts map[string]interface{}) (*Transcript, error) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
-	part, _ := writer.CreateFormFile("audio", "audio.wav")
+	part, _ := writer.CreateFormFile("audio_file", "audio.wav")
 	part.Write(audio)
 	writer.Close()
-	
-	req, _ := http.NewRequest("POST", w.Endpoint+"/asr", body)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	resp, err := http.DefaultClient.Do(req)
+
+	query := url.Values{"output": {"json"}, "task": {"transcribe"}}
+	for k, v := range opts {
+		query.Set(k, fmt.Sprint(v))
+	}
+	resp, err := orDefault(w.HTTP).Do(ctx, "POST", w.Endpoint+"/asr?"+query.Encode(), http.Header{"Content-Type": {writer.FormDataContentType()}}, body.Bytes())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Whisper API error: %s", resp.Status)
+		return nil, fmt.Errorf("Whisper API error: %s", resp.Status)
 	}
-	var result struct{ Text string `json:"text"` }
+	var result Transcript
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+		return nil, err
+	}
+	if n := len(result.Segments); n > 0 && result.Duration == 0 {
+		result.Duration = result.Segments[n-1].End
 	}
-	return result.Text, nil
-} 
\ No newline at end of file
+	return &result, nil
+}
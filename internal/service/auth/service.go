@@ -98,7 +98,7 @@ func (s *Service) Login(ctx context.Context, req dto.LoginRequest) (string, *dto
 	}
 
 	// 生成JWT令牌
-	token, err := s.generateToken(user.ID.String())
+	token, err := s.generateToken(user.ID.String(), user.Role)
 	if err != nil {
 		return "", nil, err
 	}
@@ -191,15 +191,28 @@ func (s *Service) UpdateProfile(ctx context.Context, userID string, req dto.Upda
 	}, nil
 }
 
-// ValidateToken 验证令牌
-func (s *Service) ValidateToken(ctx context.Context, tokenString string) (string, error) {
+// getRole 获取用户角色
+func (s *Service) getRole(ctx context.Context, userID string) (string, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return "", errors.New("无效的用户ID")
+	}
+	user, err := s.userRepo.GetByID(ctx, userUUID)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// ValidateToken 验证令牌，返回用户ID和签发时写入令牌的角色
+func (s *Service) ValidateToken(ctx context.Context, tokenString string) (string, string, error) {
 	// 解析JWT令牌
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.config.SecretKey), nil
 	})
 
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
@@ -208,19 +221,28 @@ func (s *Service) ValidateToken(ctx context.Context, tokenString string) (string
 		// 检查令牌是否在Redis中
 		exists, err := s.redis.Exists(ctx, "token:"+userID)
 		if err != nil || !exists {
-			return "", errors.New("令牌已失效")
+			return "", "", errors.New("令牌已失效")
+		}
+
+		// 角色写入令牌之前签发的令牌没有 role，查库补全
+		role, _ := claims["role"].(string)
+		if role == "" {
+			if role, err = s.getRole(ctx, userID); err != nil {
+				return "", "", errors.New("用户不存在")
+			}
 		}
 
-		return userID, nil
+		return userID, role, nil
 	}
 
-	return "", errors.New("无效的令牌")
+	return "", "", errors.New("无效的令牌")
 }
 
-// generateToken 生成JWT令牌
-func (s *Service) generateToken(userID string) (string, error) {
+// generateToken 生成JWT令牌，角色随令牌下发，认证时不再查库；角色或状态变更时令牌被吊销，重新登录后生效
+func (s *Service) generateToken(userID, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
+		"role":    role,
 		"exp":     time.Now().Add(time.Duration(s.config.Expire) * time.Second).Unix(),
 		"iat":     time.Now().Unix(),
 	}
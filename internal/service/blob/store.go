@@ -0,0 +1,123 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/media"
+)
+
+// Store 内容寻址存储：上传的文件按 SHA-256 存为 blobs/sha256/<前两位>/<哈希>，
+// 相同内容只保存一份，视频和素材记录通过引用计数共享，最后一个引用释放时删除文件
+type Store struct {
+	repo    *postgres.BlobRepository
+	storage storage.BlobStore
+}
+
+func NewStore(repo *postgres.BlobRepository, store storage.BlobStore) *Store {
+	return &Store{repo: repo, storage: store}
+}
+
+// ObjectName 内容哈希对应的对象名
+func ObjectName(hash string) string {
+	return fmt.Sprintf("blobs/sha256/%s/%s", hash[:2], hash)
+}
+
+// Put 存入本地文件并增加引用：相同内容已存在时不再上传
+func (s *Store) Put(ctx context.Context, localPath, contentType string) (*entity.Blob, error) {
+	hash, err := media.FileSHA256(localPath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	blob := &entity.Blob{Hash: hash, ObjectName: ObjectName(hash), Size: stat.Size(), ContentType: contentType}
+	err = s.acquire(ctx, blob, func() error {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := s.storage.Upload(ctx, blob.ObjectName, file, blob.Size, contentType)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// Adopt 将已在存储中的对象（分片上传、直传的文件）纳入内容寻址并增加引用：
+// 相同内容不存在时复制到内容寻址的对象名，原对象由调用方在确认后删除
+func (s *Store) Adopt(ctx context.Context, objectName, hash string, size int64, contentType string) (*entity.Blob, error) {
+	blob := &entity.Blob{Hash: hash, ObjectName: ObjectName(hash), Size: size, ContentType: contentType}
+	err := s.acquire(ctx, blob, func() error {
+		if objectName == blob.ObjectName {
+			return nil
+		}
+		return s.storage.CopyObject(ctx, objectName, blob.ObjectName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// acquire 先确保对象存在再增加引用，保证计入引用的记录总能读到文件。
+// 新建记录时再检查一次：并发的 Release 可能在写入后、计数前删除了文件，
+// 它持有行锁，Acquire 返回时删除已经完成，此时补写即可，之后有引用不会再被删。
+// 计数前失败留下的对象可能已被并发请求引用，不做删除
+func (s *Store) acquire(ctx context.Context, blob *entity.Blob, write func() error) error {
+	if err := s.ensure(ctx, blob.ObjectName, write); err != nil {
+		return err
+	}
+	if err := s.repo.Acquire(ctx, blob); err != nil {
+		return err
+	}
+	if blob.RefCount == 1 {
+		if err := s.ensure(ctx, blob.ObjectName, write); err != nil {
+			_ = s.Release(ctx, blob.Hash)
+			return err
+		}
+	}
+	return nil
+}
+
+// ensure 对象不存在时写入
+func (s *Store) ensure(ctx context.Context, objectName string, write func() error) error {
+	_, err := s.storage.StatObject(ctx, objectName)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storage.ErrObjectNotFound) {
+		return err
+	}
+	return write()
+}
+
+// Release 释放一个引用，最后一个引用释放时删除文件
+func (s *Store) Release(ctx context.Context, hash string) error {
+	return s.repo.Release(ctx, hash, func(blob *entity.Blob) error {
+		return s.storage.Delete(ctx, blob.ObjectName)
+	})
+}
+
+// Remove 删除记录引用的文件：内容寻址的文件释放引用，早期按时间戳命名、未去重的文件直接删除
+func (s *Store) Remove(ctx context.Context, hash, objectName string) error {
+	if hash == "" {
+		return s.storage.Delete(ctx, objectName)
+	}
+	return s.Release(ctx, hash)
+}
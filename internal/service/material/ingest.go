@@ -0,0 +1,52 @@
+package material
+
+import (
+	"context"
+	"log"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/media"
+)
+
+// categoryKinds 素材分类对应的媒体种类，未列出的分类（如 effect）不做探测
+var categoryKinds = map[string]string{
+	"video": media.KindVideo,
+	"music": media.KindAudio,
+	"image": media.KindImage,
+}
+
+// ingest 探测已上传素材的实际格式并回填时长、尺寸，状态由 uploaded 变为 ready 或 failed
+// 探测失败的素材保留记录供所有者查看和删除，返回的错误包装 media.ErrUnsupported
+func (s *Service) ingest(ctx context.Context, material *entity.Material, input string) error {
+	kind, ok := categoryKinds[material.Category]
+	if !ok {
+		material.Status = entity.MaterialStatusReady
+		return s.repo.UpdateFields(ctx, material.ID, map[string]interface{}{"status": material.Status})
+	}
+
+	info, err := media.Probe(ctx, input)
+	if err == nil {
+		err = info.Expect(kind)
+	}
+	if err != nil {
+		log.Printf("[Material] 素材探测失败: %v material=%v", err, material.ID)
+		material.Status = entity.MaterialStatusFailed
+		if uerr := s.repo.UpdateFields(ctx, material.ID, map[string]interface{}{"status": material.Status}); uerr != nil {
+			log.Printf("[Material] 更新素材状态失败: %v material=%v", uerr, material.ID)
+		}
+		return err
+	}
+
+	material.Format = info.Container
+	material.Duration = info.Duration
+	material.Width = info.Width
+	material.Height = info.Height
+	material.Status = entity.MaterialStatusReady
+	return s.repo.UpdateFields(ctx, material.ID, map[string]interface{}{
+		"format":   material.Format,
+		"duration": material.Duration,
+		"width":    material.Width,
+		"height":   material.Height,
+		"status":   material.Status,
+	})
+}
@@ -0,0 +1,123 @@
+package material
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/media"
+
+	"github.com/google/uuid"
+)
+
+// previewParams 预览生成任务参数
+type previewParams struct {
+	MaterialID uuid.UUID `json:"material_id"`
+}
+
+// previewPrefix 素材预览资源的对象路径前缀
+func previewPrefix(id uuid.UUID) string {
+	return "previews/materials/" + id.String()
+}
+
+// GeneratePreviews 重新生成素材的封面、胶片条和波形，仅所有者和管理员可操作
+func (s *Service) GeneratePreviews(ctx context.Context, id, userID, role string) (*dto.MaterialResponse, error) {
+	material, err := s.editable(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := categoryKinds[material.Category]; !ok {
+		return nil, errors.New("该分类素材不支持生成预览")
+	}
+	if !material.Usable() {
+		return nil, errors.New("素材尚未就绪，无法生成预览")
+	}
+	if s.mediaQueue == nil {
+		return nil, errors.New("预览生成未启用")
+	}
+	s.requestPreviews(ctx, material)
+	return s.materialResponse(material), nil
+}
+
+// requestPreviews 提交预览生成任务；未配置任务队列或分类不需要预览时跳过
+func (s *Service) requestPreviews(ctx context.Context, material *entity.Material) {
+	if s.mediaQueue == nil {
+		return
+	}
+	if _, ok := categoryKinds[material.Category]; !ok {
+		return
+	}
+	params, _ := json.Marshal(previewParams{MaterialID: material.ID})
+	task := &entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeMaterialPreview,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.UpdatePreview(ctx, material.ID, map[string]interface{}{"preview_status": media.PreviewPending}); err != nil {
+		log.Printf("[Material] 更新预览状态失败: %v material=%v", err, material.ID)
+	}
+	material.PreviewStatus = media.PreviewPending
+	_ = s.mediaQueue.Enqueue(task)
+}
+
+// ProcessPreviews 预览生成任务：下载素材文件，生成封面、胶片条和波形后写回素材记录
+func (s *Service) ProcessPreviews(ctx context.Context, task *entity.Task) error {
+	var params previewParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fmt.Errorf("任务参数错误: %w", err)
+	}
+	fail := func(err error) error {
+		log.Printf("[Material] 预览生成失败: %v material=%v", err, params.MaterialID)
+		_ = s.repo.UpdatePreview(ctx, params.MaterialID, map[string]interface{}{"preview_status": media.PreviewFailed})
+		return err
+	}
+
+	material, err := s.repo.GetByID(ctx, params.MaterialID)
+	if err != nil {
+		return fail(err)
+	}
+	_ = s.repo.UpdatePreview(ctx, material.ID, map[string]interface{}{"preview_status": media.PreviewProcessing})
+
+	tempDir, err := os.MkdirTemp("", "material_preview_*")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(tempDir)
+	localPath := filepath.Join(tempDir, "source"+filepath.Ext(material.FileName))
+	if err := media.Download(ctx, s.storage, material.FilePath, localPath); err != nil {
+		return fail(fmt.Errorf("下载素材失败: %w", err))
+	}
+	info, err := media.Probe(ctx, localPath)
+	if err != nil {
+		return fail(err)
+	}
+	previews, err := media.GeneratePreviews(ctx, s.storage, localPath, info, previewPrefix(material.ID))
+	if err != nil {
+		return fail(err)
+	}
+
+	updates := map[string]interface{}{
+		"filmstrip":      previews.Filmstrip,
+		"filmstrip_vtt":  previews.FilmstripVTT,
+		"waveform":       previews.Waveform,
+		"preview_status": media.PreviewCompleted,
+	}
+	if previews.Poster != "" {
+		updates["thumbnail"] = previews.Poster
+	}
+	if err := s.repo.UpdatePreview(ctx, material.ID, updates); err != nil {
+		return fail(err)
+	}
+	log.Printf("[Material] 预览生成完成: material=%v", material.ID)
+	return nil
+}
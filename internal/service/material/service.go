@@ -3,28 +3,37 @@ package material
 import (
 	"context"
 	"errors"
-	"fmt"
 	"mime/multipart"
+	"os"
 	"strings"
-	"time"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/entity"
-	"comic_video/internal/repository/minio"
 	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/blob"
+	"comic_video/internal/service/media"
+	"comic_video/internal/service/render"
 
 	"github.com/google/uuid"
 )
 
+// ErrForbidden 当前用户无权修改该素材
+var ErrForbidden = errors.New("无权操作该素材")
+
 type Service struct {
-	repo  postgres.MaterialRepository
-	minio minio.MinioClient
+	repo       postgres.MaterialRepository
+	storage    storage.BlobStore
+	blobs      *blob.Store      // 上传文件按内容去重存储
+	mediaQueue render.TaskQueue // 媒体处理任务队列，为 nil 时不生成预览
 }
 
-func NewService(repo postgres.MaterialRepository, minio minio.MinioClient) *Service {
+func NewService(repo postgres.MaterialRepository, store storage.BlobStore, blobs *blob.Store, mediaQueue render.TaskQueue) *Service {
 	return &Service{
-		repo:  repo,
-		minio: minio,
+		repo:       repo,
+		storage:    store,
+		blobs:      blobs,
+		mediaQueue: mediaQueue,
 	}
 }
 
@@ -35,45 +44,61 @@ func (s *Service) Upload(ctx context.Context, userID string, req dto.UploadMater
 		return nil, errors.New("无效的用户ID")
 	}
 
-	file, err := fileHeader.Open()
+	localPath, err := media.Spool(fileHeader)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	defer os.Remove(localPath)
 
-	objectName := fmt.Sprintf("%s/%d_%s", userUUID.String(), time.Now().UnixNano(), fileHeader.Filename)
-	url, err := s.minio.Upload(ctx, objectName, file, fileHeader.Size, fileHeader.Header.Get("Content-Type"))
+	// 按内容存入MinIO，相同文件只保存一份
+	stored, err := s.blobs.Put(ctx, localPath, fileHeader.Header.Get("Content-Type"))
 	if err != nil {
 		return nil, err
 	}
 
+	return s.CreateFromBlob(ctx, userUUID, req, stored, fileHeader.Filename, localPath)
+}
+
+// CategoryKind 素材分类要求的媒体种类，不做探测的分类返回空
+func CategoryKind(category string) string {
+	return categoryKinds[category]
+}
+
+// CreateFromBlob 为已存入内容寻址存储的文件创建素材记录并探测媒体信息，调用方已持有一个引用，input 为同一文件的本地副本或签名地址，仅用于探测
+// 普通上传、分片上传和直传完成后都经由此处入库
+func (s *Service) CreateFromBlob(ctx context.Context, userID uuid.UUID, req dto.UploadMaterialRequest, stored *entity.Blob, fileName string, input string) (*dto.MaterialResponse, error) {
 	material := &entity.Material{
 		Name:        req.Name,
 		Description: req.Description,
 		Category:    req.Category,
 		Type:        req.Type,
-		FileName:    fileHeader.Filename,
-		FilePath:    objectName,
-		FileSize:    fileHeader.Size,
-		Format:      getFileExt(fileHeader.Filename),
+		FileName:    fileName,
+		FilePath:    stored.ObjectName,
+		ContentHash: stored.Hash,
+		FileSize:    stored.Size,
+		Format:      getFileExt(fileName),
 		Tags:        req.Tags,
 		IsPublic:    req.IsPublic,
 		IsPremium:   req.IsPremium,
-		Status:      "active",
+		OwnerID:     &userID,
+		Status:      entity.MaterialStatusUploaded,
 	}
 
-	err = s.repo.Create(ctx, material)
-	if err != nil {
-		// 回滚MinIO
-		_ = s.minio.Delete(ctx, objectName)
+	if err := s.repo.Create(ctx, material); err != nil {
+		// 释放引用
+		_ = s.blobs.Release(ctx, stored.Hash)
 		return nil, err
 	}
 
-	return toMaterialResponse(material, url), nil
+	if err := s.ingest(ctx, material, input); err != nil {
+		return nil, err
+	}
+	s.requestPreviews(ctx, material)
+	return s.materialResponse(material), nil
 }
 
-// GetByID 获取素材详情
-func (s *Service) GetByID(ctx context.Context, id string) (*dto.MaterialResponse, error) {
+// GetByID 获取素材详情，私有素材仅所有者和管理员可见
+func (s *Service) GetByID(ctx context.Context, id, userID, role string) (*dto.MaterialResponse, error) {
 	materialUUID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, errors.New("无效的素材ID")
@@ -82,90 +107,171 @@ func (s *Service) GetByID(ctx context.Context, id string) (*dto.MaterialResponse
 	if err != nil {
 		return nil, err
 	}
-	url := s.minio.GetURL(material.FilePath)
-	return toMaterialResponse(material, url), nil
+	viewer, _ := uuid.Parse(userID)
+	if role != entity.RoleAdmin && !material.VisibleTo(viewer) {
+		return nil, errors.New("素材不存在")
+	}
+	return s.materialResponse(material), nil
 }
 
-// List 获取素材列表
-func (s *Service) List(ctx context.Context, req dto.ListMaterialsRequest) ([]*dto.MaterialResponse, int64, error) {
+// List 搜索素材：公开素材和当前用户的私有素材，未登录时只有公开素材
+func (s *Service) List(ctx context.Context, userID string, req dto.ListMaterialsRequest) ([]*dto.MaterialResponse, int64, error) {
 	offset := (req.Page - 1) * req.PageSize
+	search, err := buildSearch(userID, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	materials, total, err := s.repo.Search(ctx, offset, req.PageSize, search)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var responses []*dto.MaterialResponse
+	for _, m := range materials {
+		responses = append(responses, s.materialResponse(m))
+	}
+	return responses, total, nil
+}
+
+// Facets 统计与 List 相同条件下的分类、格式、时长区间和分辨率分布
+func (s *Service) Facets(ctx context.Context, userID string, req dto.ListMaterialsRequest) (*dto.MaterialFacets, error) {
+	search, err := buildSearch(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	facets, err := s.repo.Facets(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MaterialFacets{
+		Category:   toFacetCounts(facets[postgres.FacetCategory]),
+		Format:     toFacetCounts(facets[postgres.FacetFormat]),
+		Duration:   toFacetCounts(facets[postgres.FacetDuration]),
+		Resolution: toFacetCounts(facets[postgres.FacetResolution]),
+	}, nil
+}
+
+// buildSearch 将列表请求转为搜索条件
+func buildSearch(userID string, req dto.ListMaterialsRequest) (postgres.MaterialSearch, error) {
+	viewer, _ := uuid.Parse(userID)
 	filter := make(map[string]interface{})
+	if req.Mine {
+		if viewer == uuid.Nil {
+			return postgres.MaterialSearch{}, errors.New("请先登录")
+		}
+		filter["owner_id"] = viewer
+	}
 	if req.Category != "" {
 		filter["category"] = req.Category
 	}
 	if req.Type != "" {
 		filter["type"] = req.Type
 	}
+	if req.Format != "" {
+		filter["format"] = strings.ToLower(req.Format)
+	}
 	if req.IsPublic != nil {
 		filter["is_public"] = *req.IsPublic
 	}
 	if req.IsPremium != nil {
 		filter["is_premium"] = *req.IsPremium
 	}
-	// 关键词模糊搜索可后续扩展
-
-	materials, total, err := s.repo.List(ctx, offset, req.PageSize, filter)
-	if err != nil {
-		return nil, 0, err
-	}
+	return postgres.MaterialSearch{
+		Filter:     filter,
+		Keyword:    strings.TrimSpace(req.Keyword),
+		Tags:       entity.ParseTags(req.Tags),
+		Duration:   req.Duration,
+		Resolution: req.Resolution,
+		ViewerID:   viewer,
+		Sort:       req.Sort,
+	}, nil
+}
 
-	var responses []*dto.MaterialResponse
-	for _, m := range materials {
-		url := s.minio.GetURL(m.FilePath)
-		responses = append(responses, toMaterialResponse(m, url))
+func toFacetCounts(counts []postgres.FacetCount) []dto.FacetCount {
+	out := make([]dto.FacetCount, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, dto.FacetCount{Value: c.Value, Count: c.Count})
 	}
-	return responses, total, nil
+	return out
 }
 
-// Update 更新素材
-func (s *Service) Update(ctx context.Context, id string, req dto.UpdateMaterialRequest) (*dto.MaterialResponse, error) {
-	materialUUID, err := uuid.Parse(id)
-	if err != nil {
-		return nil, errors.New("无效的素材ID")
-	}
-	material, err := s.repo.GetByID(ctx, materialUUID)
+// Update 更新素材，仅所有者和管理员可操作
+func (s *Service) Update(ctx context.Context, id, userID, role string, req dto.UpdateMaterialRequest) (*dto.MaterialResponse, error) {
+	material, err := s.editable(ctx, id, userID, role)
 	if err != nil {
 		return nil, err
 	}
+	updates := map[string]interface{}{}
 	if req.Name != "" {
 		material.Name = req.Name
+		updates["name"] = material.Name
 	}
 	if req.Description != "" {
 		material.Description = req.Description
+		updates["description"] = material.Description
 	}
 	if req.IsPublic != nil {
 		material.IsPublic = *req.IsPublic
+		updates["is_public"] = material.IsPublic
 	}
 	if req.IsPremium != nil {
 		material.IsPremium = *req.IsPremium
+		updates["is_premium"] = material.IsPremium
 	}
 	if req.Tags != "" {
 		material.Tags = req.Tags
+		updates["tags"] = material.Tags
 	}
-	err = s.repo.Update(ctx, material)
+	err = s.repo.UpdateFields(ctx, material.ID, updates)
 	if err != nil {
 		return nil, err
 	}
-	url := s.minio.GetURL(material.FilePath)
-	return toMaterialResponse(material, url), nil
+	return s.materialResponse(material), nil
 }
 
-// Delete 删除素材
-func (s *Service) Delete(ctx context.Context, id string) error {
-	materialUUID, err := uuid.Parse(id)
+// Delete 删除素材，仅所有者和管理员可操作
+func (s *Service) Delete(ctx context.Context, id, userID, role string) error {
+	material, err := s.editable(ctx, id, userID, role)
 	if err != nil {
-		return errors.New("无效的素材ID")
+		return err
 	}
-	material, err := s.repo.GetByID(ctx, materialUUID)
+	// 先删MinIO，其他记录仍引用相同内容时保留
+	err = s.blobs.Remove(ctx, material.ContentHash, material.FilePath)
 	if err != nil {
 		return err
 	}
-	// 先删MinIO
-	err = s.minio.Delete(ctx, material.FilePath)
+	media.DeletePreviews(ctx, s.storage, previewPrefix(material.ID))
+	return s.repo.Delete(ctx, material.ID)
+}
+
+// editable 获取当前用户可修改的素材；平台素材（无所有者）仅管理员可修改
+func (s *Service) editable(ctx context.Context, id, userID, role string) (*entity.Material, error) {
+	materialUUID, err := uuid.Parse(id)
 	if err != nil {
-		return err
+		return nil, errors.New("无效的素材ID")
 	}
-	return s.repo.Delete(ctx, materialUUID)
+	material, err := s.repo.GetByID(ctx, materialUUID)
+	if err != nil {
+		return nil, err
+	}
+	if role == entity.RoleAdmin {
+		return material, nil
+	}
+	if material.OwnerID == nil || material.OwnerID.String() != userID {
+		return nil, ErrForbidden
+	}
+	return material, nil
+}
+
+// materialResponse 转为响应结构体，对象名换成访问地址
+func (s *Service) materialResponse(m *entity.Material) *dto.MaterialResponse {
+	resp := toMaterialResponse(m, s.storage.GetURL(m.FilePath))
+	resp.Thumbnail = storage.ResolveURL(s.storage, m.Thumbnail)
+	resp.Filmstrip = storage.ResolveURL(s.storage, m.Filmstrip)
+	resp.FilmstripVTT = storage.ResolveURL(s.storage, m.FilmstripVTT)
+	resp.Waveform = storage.ResolveURL(s.storage, m.Waveform)
+	return resp
 }
 
 // 工具函数：转为响应结构体
@@ -178,14 +284,20 @@ func toMaterialResponse(m *entity.Material, url string) *dto.MaterialResponse {
 		Type:          m.Type,
 		FileName:      m.FileName,
 		FilePath:      url,
+		ContentHash:   m.ContentHash,
 		FileSize:      m.FileSize,
 		Duration:      m.Duration,
 		Width:         m.Width,
 		Height:        m.Height,
 		Format:        m.Format,
 		Thumbnail:     m.Thumbnail,
+		Filmstrip:     m.Filmstrip,
+		FilmstripVTT:  m.FilmstripVTT,
+		Waveform:      m.Waveform,
+		PreviewStatus: m.PreviewStatus,
 		Tags:          m.Tags,
 		IsPublic:      m.IsPublic,
+		OwnerID:       m.OwnerID,
 		IsPremium:     m.IsPremium,
 		DownloadCount: m.DownloadCount,
 		Rating:        m.Rating,
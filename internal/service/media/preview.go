@@ -0,0 +1,298 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"comic_video/internal/repository/storage"
+)
+
+// 预览生成状态
+const (
+	PreviewPending    = "pending"
+	PreviewProcessing = "processing"
+	PreviewCompleted  = "completed"
+	PreviewFailed     = "failed"
+)
+
+const (
+	posterWidth = 640 // 封面宽度
+
+	// 胶片条：每隔固定秒数取一帧拼成雪碧图，帧数过多时拉长间隔
+	stripInterval = 2.0
+	stripMaxTiles = 100
+	stripColumns  = 10
+	stripTileW    = 160
+	stripTileH    = 90
+
+	// 波形：单声道 8kHz 解码后按桶取峰值，输出 peaks.js / audiowaveform 兼容的 JSON
+	waveSampleRate    = 8000
+	wavePeaksPerSec   = 20
+	waveMaxPeaks      = 20000
+	waveBits          = 8
+	waveFormatVersion = 2
+)
+
+// Previews 上传后的预览资源对象名，不适用的资源为空；访问地址在构建响应时用 storage.ResolveURL 生成
+type Previews struct {
+	Poster       string // 封面图
+	Filmstrip    string // 胶片条雪碧图
+	FilmstripVTT string // 胶片条 WebVTT 索引，cue 指向雪碧图中的区域
+	Waveform     string // 波形峰值 JSON
+}
+
+// Waveform 波形峰值数据，data 为交替的 min/max 值
+type Waveform struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"`
+}
+
+// GeneratePreviews 为本地媒体文件生成封面、胶片条和波形并上传到 objectPrefix 下
+// 视频生成全部三项，图片只生成封面，音频只生成波形；视频没有音轨时跳过波形
+func GeneratePreviews(ctx context.Context, store storage.BlobStore, localPath string, info *Info, objectPrefix string) (*Previews, error) {
+	tempDir, err := os.MkdirTemp("", "media_preview_*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	result := &Previews{}
+	upload := func(name, contentType string) (string, error) {
+		objectName := objectPrefix + "/" + name
+		if err := uploadFile(ctx, store, filepath.Join(tempDir, name), objectName, contentType); err != nil {
+			return "", fmt.Errorf("上传%s失败: %w", name, err)
+		}
+		return objectName, nil
+	}
+
+	if info.Kind == KindVideo || info.Kind == KindImage {
+		if err := poster(ctx, localPath, info, filepath.Join(tempDir, "poster.jpg")); err != nil {
+			return nil, err
+		}
+		if result.Poster, err = upload("poster.jpg", "image/jpeg"); err != nil {
+			return nil, err
+		}
+	}
+
+	if info.Kind == KindVideo {
+		vtt, err := filmstrip(ctx, localPath, info, filepath.Join(tempDir, "filmstrip.jpg"))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, "filmstrip.vtt"), []byte(vtt), 0644); err != nil {
+			return nil, err
+		}
+		if result.Filmstrip, err = upload("filmstrip.jpg", "image/jpeg"); err != nil {
+			return nil, err
+		}
+		if result.FilmstripVTT, err = upload("filmstrip.vtt", "text/vtt"); err != nil {
+			return nil, err
+		}
+	}
+
+	if info.AudioCodec != "" {
+		wave, err := waveform(ctx, localPath, info.Duration)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(wave)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, "waveform.json"), data, 0644); err != nil {
+			return nil, err
+		}
+		if result.Waveform, err = upload("waveform.json", "application/json"); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// poster 视频取三分之一处的画面避开片头黑场，图片直接缩放
+func poster(ctx context.Context, input string, info *Info, output string) error {
+	args := []string{"-y"}
+	if info.Kind == KindVideo {
+		args = append(args, "-ss", formatSeconds(info.Duration/3))
+	}
+	args = append(args,
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", posterWidth),
+		"-q:v", "3",
+		output,
+	)
+	if err := runFFmpeg(ctx, args...); err != nil {
+		return fmt.Errorf("生成封面失败: %w", err)
+	}
+	return nil
+}
+
+// filmstrip 生成胶片条雪碧图，返回对应的 WebVTT 索引
+func filmstrip(ctx context.Context, input string, info *Info, output string) (string, error) {
+	interval := stripInterval
+	if info.Duration/interval > stripMaxTiles {
+		interval = info.Duration / stripMaxTiles
+	}
+	tiles := int(math.Ceil(info.Duration / interval))
+	if tiles < 1 {
+		tiles = 1
+	}
+	columns := stripColumns
+	if tiles < columns {
+		columns = tiles
+	}
+	rows := (tiles + columns - 1) / columns
+
+	filter := fmt.Sprintf(
+		"fps=1/%s,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		formatSeconds(interval), stripTileW, stripTileH, stripTileW, stripTileH, columns, rows)
+	if err := runFFmpeg(ctx, "-y", "-i", input, "-vf", filter, "-frames:v", "1", "-q:v", "5", output); err != nil {
+		return "", fmt.Errorf("生成胶片条失败: %w", err)
+	}
+
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n")
+	name := filepath.Base(output)
+	for i := 0; i < tiles; i++ {
+		start := float64(i) * interval
+		end := math.Min(start+interval, info.Duration)
+		x := (i % columns) * stripTileW
+		y := (i / columns) * stripTileH
+		fmt.Fprintf(&vtt, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			vttTimestamp(start), vttTimestamp(end), name, x, y, stripTileW, stripTileH)
+	}
+	return vtt.String(), nil
+}
+
+// waveform 解码为单声道 16 位 PCM 流式计算每个桶的最小、最大采样，长文件增大桶宽限制数据量
+func waveform(ctx context.Context, input string, duration float64) (*Waveform, error) {
+	perPixel := waveSampleRate / wavePeaksPerSec
+	if total := int(duration * waveSampleRate); total/perPixel > waveMaxPeaks {
+		perPixel = (total + waveMaxPeaks - 1) / waveMaxPeaks
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-i", input,
+		"-vn", "-ac", "1", "-ar", fmt.Sprint(waveSampleRate),
+		"-f", "s16le", "-",
+	)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("生成波形失败: %w", err)
+	}
+
+	wave := &Waveform{
+		Version:         waveFormatVersion,
+		Channels:        1,
+		SampleRate:      waveSampleRate,
+		SamplesPerPixel: perPixel,
+		Bits:            waveBits,
+	}
+	var lo, hi int16
+	count := 0
+	flush := func() {
+		wave.Data = append(wave.Data, int8(lo>>8), int8(hi>>8))
+		lo, hi, count = 0, 0, 0
+	}
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := io.ReadFull(reader, buf)
+		// ReadFull 只在流结束时返回不足一整块的数据，末尾不足一个采样的字节丢弃
+		for i := 0; i+1 < n; i += 2 {
+			sample := int16(binary.LittleEndian.Uint16(buf[i:]))
+			if count == 0 || sample < lo {
+				lo = sample
+			}
+			if count == 0 || sample > hi {
+				hi = sample
+			}
+			count++
+			if count == perPixel {
+				flush()
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("读取音频数据失败: %w", err)
+		}
+	}
+	if count > 0 {
+		flush()
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("生成波形失败: %s", strings.TrimSpace(stderr.String()))
+	}
+	wave.Length = len(wave.Data) / 2
+	return wave, nil
+}
+
+// runFFmpeg 执行 ffmpeg，失败时附带输出末尾便于排查
+func runFFmpeg(ctx context.Context, args ...string) error {
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		out := string(output)
+		if len(out) > 500 {
+			out = out[len(out)-500:]
+		}
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+// uploadFile 上传本地文件
+func uploadFile(ctx context.Context, store storage.BlobStore, localPath, objectName, contentType string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = store.Upload(ctx, objectName, file, stat.Size(), contentType)
+	return err
+}
+
+func formatSeconds(v float64) string {
+	return fmt.Sprintf("%.3f", v)
+}
+
+// vttTimestamp 秒数转为 WebVTT 时间戳 HH:MM:SS.mmm
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// DeletePreviews 删除 objectPrefix 下生成的预览资源，不存在的对象忽略
+func DeletePreviews(ctx context.Context, store storage.BlobStore, objectPrefix string) {
+	for _, name := range []string{"poster.jpg", "filmstrip.jpg", "filmstrip.vtt", "waveform.json"} {
+		_ = store.Delete(ctx, objectPrefix+"/"+name)
+	}
+}
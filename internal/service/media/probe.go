@@ -0,0 +1,298 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 媒体种类
+const (
+	KindVideo = "video"
+	KindAudio = "audio"
+	KindImage = "image"
+)
+
+// ErrUnsupported 文件损坏或不是支持的媒体格式，属于调用方错误
+var ErrUnsupported = errors.New("文件已损坏或格式不支持")
+
+// probeTimeout 单个文件探测超时，ffprobe 只读取头部，正常文件远小于该值
+const probeTimeout = 30 * time.Second
+
+// Info 从文件内容探测出的媒体信息
+type Info struct {
+	Kind       string  // video / audio / image
+	Container  string  // 归一化后的封装格式，如 mp4、mov、webm、mp3、png
+	MimeType   string  // 按封装格式推断的 Content-Type
+	Duration   float64 // 时长（秒），图片为 0
+	Bitrate    int     // 总码率（bps）
+	Width      int
+	Height     int
+	FPS        float64
+	VideoCodec string // 视频或图片编码，如 h264、png
+	AudioCodec string // 音频编码，如 aac、mp3
+}
+
+// Codec 主编码：视频和图片取画面编码，音频取声音编码
+func (i *Info) Codec() string {
+	if i.Kind == KindAudio {
+		return i.AudioCodec
+	}
+	return i.VideoCodec
+}
+
+// Expect 校验探测结果与声明的媒体种类一致
+func (i *Info) Expect(kind string) error {
+	if kind != "" && i.Kind != kind {
+		return fmt.Errorf("%w: 需要%s文件，实际为%s", ErrUnsupported, kind, i.Kind)
+	}
+	return nil
+}
+
+// container 支持的封装格式
+type container struct {
+	kind string
+	mime string
+}
+
+var containers = map[string]container{
+	"mp4":  {KindVideo, "video/mp4"},
+	"mov":  {KindVideo, "video/quicktime"},
+	"webm": {KindVideo, "video/webm"},
+	"mkv":  {KindVideo, "video/x-matroska"},
+	"avi":  {KindVideo, "video/x-msvideo"},
+	"flv":  {KindVideo, "video/x-flv"},
+	"ts":   {KindVideo, "video/mp2t"},
+	"mp3":  {KindAudio, "audio/mpeg"},
+	"m4a":  {KindAudio, "audio/mp4"},
+	"aac":  {KindAudio, "audio/aac"},
+	"wav":  {KindAudio, "audio/wav"},
+	"flac": {KindAudio, "audio/flac"},
+	"ogg":  {KindAudio, "audio/ogg"},
+	"png":  {KindImage, "image/png"},
+	"jpeg": {KindImage, "image/jpeg"},
+	"webp": {KindImage, "image/webp"},
+	"gif":  {KindImage, "image/gif"},
+	"bmp":  {KindImage, "image/bmp"},
+}
+
+// extensionKinds 允许上传的扩展名及对应的媒体种类，用于上传前快速拦截，实际格式以探测结果为准
+var extensionKinds = map[string]string{
+	".mp4": KindVideo, ".m4v": KindVideo, ".mov": KindVideo, ".webm": KindVideo, ".mkv": KindVideo,
+	".avi": KindVideo, ".flv": KindVideo, ".ts": KindVideo,
+	".mp3": KindAudio, ".m4a": KindAudio, ".aac": KindAudio, ".wav": KindAudio, ".flac": KindAudio,
+	".ogg": KindAudio, ".opus": KindAudio,
+	".png": KindImage, ".jpg": KindImage, ".jpeg": KindImage, ".webp": KindImage, ".gif": KindImage,
+	".bmp": KindImage,
+}
+
+// KindOfFile 按扩展名判断媒体种类，不支持的扩展名返回空
+func KindOfFile(name string) string {
+	return extensionKinds[strings.ToLower(filepath.Ext(name))]
+}
+
+// 支持的编码，渲染时 FFmpeg 需能直接解码
+var (
+	videoCodecs = map[string]bool{
+		"h264": true, "hevc": true, "vp8": true, "vp9": true, "av1": true,
+		"mpeg4": true, "mpeg2video": true, "prores": true, "mjpeg": true,
+	}
+	audioCodecs = map[string]bool{
+		"aac": true, "mp3": true, "opus": true, "vorbis": true, "flac": true,
+		"alac": true, "ac3": true, "eac3": true,
+	}
+	imageCodecs = map[string]string{
+		"png": "png", "mjpeg": "jpeg", "webp": "webp", "gif": "gif", "bmp": "bmp",
+	}
+)
+
+// ffprobe -print_format json 输出中用到的字段
+type probeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		Duration     string `json:"duration"`
+		BitRate      string `json:"bit_rate"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string            `json:"format_name"`
+		Duration   string            `json:"duration"`
+		BitRate    string            `json:"bit_rate"`
+		Tags       map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// Probe 用 ffprobe 探测本地文件或 URL，按内容识别封装格式与编码
+// 无法解析、格式或编码不在支持范围内、缺少时长或画面尺寸的文件返回 ErrUnsupported
+func Probe(ctx context.Context, input string) (*Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		input,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupported, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("执行ffprobe失败: %w", err)
+	}
+
+	var out probeOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("解析ffprobe输出失败: %w", err)
+	}
+	return parse(&out)
+}
+
+// parse 将 ffprobe 输出归一化为 Info 并校验
+func parse(out *probeOutput) (*Info, error) {
+	info := &Info{
+		Duration: parseFloat(out.Format.Duration),
+		Bitrate:  int(parseFloat(out.Format.BitRate)),
+	}
+	var hasVideo bool
+	for _, st := range out.Streams {
+		switch st.CodecType {
+		case "video":
+			// 音频文件内嵌的封面图不算画面
+			if st.Disposition.AttachedPic == 1 || hasVideo {
+				continue
+			}
+			hasVideo = true
+			info.VideoCodec = st.CodecName
+			info.Width, info.Height = st.Width, st.Height
+			info.FPS = parseRate(st.AvgFrameRate)
+			if info.FPS == 0 {
+				info.FPS = parseRate(st.RFrameRate)
+			}
+			if info.Duration == 0 {
+				info.Duration = parseFloat(st.Duration)
+			}
+			if info.Bitrate == 0 {
+				info.Bitrate = int(parseFloat(st.BitRate))
+			}
+		case "audio":
+			if info.AudioCodec != "" {
+				continue
+			}
+			info.AudioCodec = st.CodecName
+			if info.Duration == 0 {
+				info.Duration = parseFloat(st.Duration)
+			}
+		}
+	}
+
+	info.Container = detectContainer(out, info, hasVideo)
+	c, ok := containers[info.Container]
+	if !ok {
+		return nil, fmt.Errorf("%w: 封装格式 %s", ErrUnsupported, out.Format.FormatName)
+	}
+	info.Kind, info.MimeType = c.kind, c.mime
+
+	switch info.Kind {
+	case KindImage:
+		if !hasVideo || info.Width <= 0 || info.Height <= 0 {
+			return nil, fmt.Errorf("%w: 图片缺少有效画面", ErrUnsupported)
+		}
+		// 静态图片没有时长和帧率，动图保留 ffprobe 给出的值
+		if info.Container != "gif" {
+			info.Duration, info.FPS, info.Bitrate = 0, 0, 0
+		}
+		return info, nil
+	case KindVideo:
+		if !hasVideo {
+			// 只有声音的 mp4/mkv 等按音频处理
+			info.Kind = KindAudio
+			break
+		}
+		if !videoCodecs[info.VideoCodec] {
+			return nil, fmt.Errorf("%w: 视频编码 %s", ErrUnsupported, info.VideoCodec)
+		}
+		if info.Width <= 0 || info.Height <= 0 {
+			return nil, fmt.Errorf("%w: 视频缺少有效画面", ErrUnsupported)
+		}
+	}
+	if info.Kind == KindAudio {
+		if info.AudioCodec == "" {
+			return nil, fmt.Errorf("%w: 没有音频流", ErrUnsupported)
+		}
+		info.VideoCodec, info.Width, info.Height, info.FPS = "", 0, 0, 0
+	}
+	if info.AudioCodec != "" && !audioCodecs[info.AudioCodec] && !strings.HasPrefix(info.AudioCodec, "pcm_") {
+		return nil, fmt.Errorf("%w: 音频编码 %s", ErrUnsupported, info.AudioCodec)
+	}
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("%w: 无法读取时长", ErrUnsupported)
+	}
+	return info, nil
+}
+
+// detectContainer 根据 ffprobe 的 format_name 与流编码确定实际封装格式
+func detectContainer(out *probeOutput, info *Info, hasVideo bool) string {
+	names := strings.Split(out.Format.FormatName, ",")
+	switch {
+	case out.Format.FormatName == "mov,mp4,m4a,3gp,3g2,mj2":
+		brand := strings.TrimSpace(out.Format.Tags["major_brand"])
+		switch {
+		case brand == "qt":
+			return "mov"
+		case !hasVideo:
+			return "m4a"
+		}
+		return "mp4"
+	case out.Format.FormatName == "matroska,webm":
+		webm := map[string]bool{"": true, "vp8": true, "vp9": true, "av1": true, "opus": true, "vorbis": true}
+		if webm[info.VideoCodec] && webm[info.AudioCodec] {
+			return "webm"
+		}
+		return "mkv"
+	case names[0] == "image2" || strings.HasSuffix(names[0], "_pipe") || names[0] == "gif":
+		return imageCodecs[info.VideoCodec]
+	case names[0] == "mpegts":
+		return "ts"
+	}
+	return names[0]
+}
+
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// parseRate 解析 "30000/1001" 形式的帧率
+func parseRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		return parseFloat(s)
+	}
+	d := parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(num) / d
+}
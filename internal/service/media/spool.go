@@ -0,0 +1,67 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"comic_video/internal/repository/storage"
+)
+
+// Spool 将上传文件落到本地临时文件供探测和上传使用，保留原扩展名，调用方负责删除
+func Spool(fileHeader *multipart.FileHeader) (string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "upload_*"+filepath.Ext(fileHeader.Filename))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// Download 将存储中的对象下载到本地
+func Download(ctx context.Context, store storage.BlobStore, objectName, localPath string) error {
+	src, err := store.Open(ctx, objectName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// FileSHA256 计算本地文件的 SHA-256，返回小写十六进制
+func FileSHA256(localPath string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,288 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"comic_video/internal/repository/storage"
+)
+
+const (
+	// 剪辑代理：低分辨率 H.264、恒定帧率、每秒一个关键帧，便于时间线精确拖动
+	proxyHeight = 540
+	proxyMaxFPS = 30
+	proxyCRF    = 23
+
+	hlsSegmentSeconds = 4
+)
+
+// Rendition 自适应码率档位
+type Rendition struct {
+	Name         string // 档位名，同时作为子目录名
+	Height       int
+	VideoBitrate int // kbps
+	AudioBitrate int // kbps
+}
+
+// DefaultLadder 默认自适应码率阶梯，按源视频高度截取
+var DefaultLadder = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "540p", Height: 540, VideoBitrate: 1500, AudioBitrate: 128},
+	{Name: "720p", Height: 720, VideoBitrate: 3000, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 6000, AudioBitrate: 192},
+}
+
+// ProgressFunc 转码进度回调，取值 0~1
+type ProgressFunc func(float64)
+
+// LadderFor 截取不超过源视频高度的档位，源视频低于最低档时保留最低档
+func LadderFor(height int, ladder []Rendition) []Rendition {
+	var out []Rendition
+	for _, r := range ladder {
+		if r.Height <= height {
+			out = append(out, r)
+		}
+	}
+	if len(out) == 0 && len(ladder) > 0 {
+		out = append(out, ladder[0])
+	}
+	return out
+}
+
+// Proxy 生成剪辑代理文件
+func Proxy(ctx context.Context, input string, info *Info, output string, progress ProgressFunc) error {
+	height := proxyHeight
+	if info.Height > 0 && info.Height < height {
+		height = info.Height
+	}
+	fps := constantFPS(info.FPS, proxyMaxFPS)
+	args := []string{"-y", "-i", input,
+		"-vf", fmt.Sprintf("scale=-2:%d,fps=%d", evenDown(height), fps),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", strconv.Itoa(proxyCRF),
+		"-pix_fmt", "yuv420p",
+	}
+	args = append(args, keyframeArgs(fps)...)
+	args = append(args, audioArgs(info, 128)...)
+	args = append(args, "-movflags", "+faststart", output)
+	if err := runWithProgress(ctx, info.Duration, progress, args...); err != nil {
+		return fmt.Errorf("生成剪辑代理失败: %w", err)
+	}
+	return nil
+}
+
+// PackageHLS 按码率阶梯逐档转码为 HLS，输出 outDir/<档位>/index.m3u8 及分片和 outDir/master.m3u8
+func PackageHLS(ctx context.Context, input string, info *Info, outDir string, ladder []Rendition, progress ProgressFunc) error {
+	if len(ladder) == 0 {
+		return fmt.Errorf("码率阶梯为空")
+	}
+	fps := constantFPS(info.FPS, 60)
+	var master strings.Builder
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for i, r := range ladder {
+		dir := filepath.Join(outDir, r.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		args := []string{"-y", "-i", input,
+			"-vf", fmt.Sprintf("scale=-2:%d,fps=%d", evenDown(r.Height), fps),
+			"-c:v", "libx264", "-preset", "veryfast", "-pix_fmt", "yuv420p",
+			"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+			"-maxrate", fmt.Sprintf("%dk", r.VideoBitrate*107/100),
+			"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		}
+		args = append(args, keyframeArgs(fps)...)
+		args = append(args, audioArgs(info, r.AudioBitrate)...)
+		args = append(args,
+			"-f", "hls",
+			"-hls_time", strconv.Itoa(hlsSegmentSeconds),
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(dir, "seg_%05d.ts"),
+			filepath.Join(dir, "index.m3u8"),
+		)
+		step := func(p float64) {
+			if progress != nil {
+				progress((float64(i) + p) / float64(len(ladder)))
+			}
+		}
+		if err := runWithProgress(ctx, info.Duration, step, args...); err != nil {
+			return fmt.Errorf("生成%s档位失败: %w", r.Name, err)
+		}
+
+		bandwidth := (r.VideoBitrate + r.AudioBitrate) * 1000
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n%s/index.m3u8\n",
+			bandwidth, scaledWidth(info, r.Height), evenDown(r.Height), r.Name)
+	}
+	return os.WriteFile(filepath.Join(outDir, "master.m3u8"), []byte(master.String()), 0644)
+}
+
+// PackageDASH 一次转码输出所有档位的 DASH 分片和 outDir/manifest.mpd
+// 使用 SegmentList 逐个列出分片地址（不使用 SegmentTemplate），便于播放时逐个替换为签名地址
+func PackageDASH(ctx context.Context, input string, info *Info, outDir string, ladder []Rendition, progress ProgressFunc) error {
+	if len(ladder) == 0 {
+		return fmt.Errorf("码率阶梯为空")
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	fps := constantFPS(info.FPS, 60)
+	var filter strings.Builder
+	fmt.Fprintf(&filter, "[0:v]fps=%d,split=%d", fps, len(ladder))
+	for i := range ladder {
+		fmt.Fprintf(&filter, "[s%d]", i)
+	}
+	for i, r := range ladder {
+		fmt.Fprintf(&filter, ";[s%d]scale=-2:%d[v%d]", i, evenDown(r.Height), i)
+	}
+
+	args := []string{"-y", "-i", input, "-filter_complex", filter.String()}
+	audioBitrate := 0
+	for i, r := range ladder {
+		args = append(args,
+			"-map", fmt.Sprintf("[v%d]", i),
+			fmt.Sprintf("-c:v:%d", i), "libx264",
+			fmt.Sprintf("-b:v:%d", i), fmt.Sprintf("%dk", r.VideoBitrate),
+			fmt.Sprintf("-maxrate:v:%d", i), fmt.Sprintf("%dk", r.VideoBitrate*107/100),
+			fmt.Sprintf("-bufsize:v:%d", i), fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		)
+		if r.AudioBitrate > audioBitrate {
+			audioBitrate = r.AudioBitrate
+		}
+	}
+	args = append(args, "-preset", "veryfast", "-pix_fmt", "yuv420p")
+	args = append(args, keyframeArgs(fps)...)
+	adaptationSets := "id=0,streams=v"
+	if info.AudioCodec != "" {
+		// 所有档位共用一路音频
+		args = append(args, "-map", "0:a:0")
+		args = append(args, audioArgs(info, audioBitrate)...)
+		adaptationSets += " id=1,streams=a"
+	}
+	args = append(args,
+		"-f", "dash",
+		"-seg_duration", strconv.Itoa(hlsSegmentSeconds),
+		"-use_template", "0",
+		"-use_timeline", "0",
+		"-adaptation_sets", adaptationSets,
+		"-init_seg_name", "init-$RepresentationID$.m4s",
+		"-media_seg_name", "chunk-$RepresentationID$-$Number%05d$.m4s",
+		filepath.Join(outDir, "manifest.mpd"),
+	)
+	if err := runWithProgress(ctx, info.Duration, progress, args...); err != nil {
+		return fmt.Errorf("生成DASH失败: %w", err)
+	}
+	return nil
+}
+
+// UploadDir 递归上传本地目录，按扩展名设置 Content-Type
+func UploadDir(ctx context.Context, store storage.BlobStore, localDir, objectPrefix string) error {
+	return filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		return uploadFile(ctx, store, path, objectPrefix+"/"+filepath.ToSlash(rel), ContentType(path))
+	})
+}
+
+// ContentType 按扩展名推断流媒体相关文件的 Content-Type
+func ContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".mp4":
+		return "video/mp4"
+	}
+	return "application/octet-stream"
+}
+
+// keyframeArgs 每秒一个关键帧且禁用场景切换插帧，保证分片和拖动位置对齐
+func keyframeArgs(fps int) []string {
+	return []string{
+		"-g", strconv.Itoa(fps),
+		"-keyint_min", strconv.Itoa(fps),
+		"-sc_threshold", "0",
+		"-force_key_frames", "expr:gte(t,n_forced*1)",
+	}
+}
+
+// audioArgs 统一转为 AAC 立体声 48kHz，没有音轨时关闭音频
+func audioArgs(info *Info, bitrate int) []string {
+	if info.AudioCodec == "" {
+		return []string{"-an"}
+	}
+	return []string{"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", bitrate), "-ar", "48000", "-ac", "2"}
+}
+
+// constantFPS 取整后的恒定帧率，源帧率未知时按 max 处理
+func constantFPS(fps float64, max int) int {
+	v := int(math.Round(fps))
+	if v <= 0 || v > max {
+		return max
+	}
+	return v
+}
+
+// scaledWidth 按源宽高比计算缩放后的偶数宽度
+func scaledWidth(info *Info, height int) int {
+	if info.Height <= 0 {
+		return evenDown(height * 16 / 9)
+	}
+	return evenDown(int(math.Round(float64(info.Width) * float64(height) / float64(info.Height))))
+}
+
+func evenDown(v int) int {
+	return v &^ 1
+}
+
+// runWithProgress 执行 ffmpeg 并按 -progress 输出的已处理时长回调进度
+func runWithProgress(ctx context.Context, duration float64, progress ProgressFunc, args ...string) error {
+	args = append([]string{"-progress", "pipe:1", "-nostats", "-v", "error"}, args...)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		// out_time_us 为已输出的时长（微秒），旧版本 ffmpeg 的 out_time_ms 实际同样是微秒
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || (key != "out_time_us" && key != "out_time_ms") || progress == nil || duration <= 0 {
+			continue
+		}
+		us, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		progress(math.Min(us/1e6/duration, 1))
+	}
+	if err := cmd.Wait(); err != nil {
+		out := strings.TrimSpace(stderr.String())
+		if len(out) > 500 {
+			out = out[len(out)-500:]
+		}
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/entity"
 	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
 
 	"github.com/google/uuid"
 )
@@ -17,12 +18,14 @@ import (
 type Service struct {
 	projectRepo       postgres.ProjectRepository
 	projectShareRepo  postgres.ProjectShareRepository // 新增
+	storage           storage.BlobStore
 }
 
-func NewService(projectRepo postgres.ProjectRepository, projectShareRepo postgres.ProjectShareRepository) *Service {
+func NewService(projectRepo postgres.ProjectRepository, projectShareRepo postgres.ProjectShareRepository, store storage.BlobStore) *Service {
 	return &Service{
 		projectRepo:      projectRepo,
 		projectShareRepo: projectShareRepo,
+		storage:          store,
 	}
 }
 
@@ -63,6 +66,7 @@ func (s *Service) Create(ctx context.Context, userID string, req dto.CreateProje
 		Description: project.Description,
 		UserID:      project.UserID,
 		Status:      project.Status,
+		Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
 		Settings:    settings,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
@@ -100,6 +104,7 @@ func (s *Service) GetByID(ctx context.Context, projectID string, userID string)
 		Description: project.Description,
 		UserID:      project.UserID,
 		Status:      project.Status,
+		Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
 		Settings:    settings,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
@@ -128,6 +133,7 @@ func (s *Service) List(ctx context.Context, userID string, offset, limit int) ([
 			Description: project.Description,
 			UserID:      project.UserID,
 			Status:      project.Status,
+			Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
 			Settings:    settings,
 			CreatedAt:   project.CreatedAt,
 			UpdatedAt:   project.UpdatedAt,
@@ -187,6 +193,7 @@ func (s *Service) Update(ctx context.Context, projectID string, userID string, r
 		Description: project.Description,
 		UserID:      project.UserID,
 		Status:      project.Status,
+		Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
 		Settings:    settings,
 		CreatedAt:   project.CreatedAt,
 		UpdatedAt:   project.UpdatedAt,
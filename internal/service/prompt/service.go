@@ -0,0 +1,282 @@
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/postgres"
+	"comic_video/internal/service/ai"
+
+	"github.com/google/uuid"
+)
+
+// Service 提示词模板库，同时作为 ai.PromptStore 供AI任务读取模板
+type Service struct {
+	repo *postgres.PromptRepository
+}
+
+var _ ai.PromptStore = (*Service)(nil)
+
+func NewService(repo *postgres.PromptRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// SeedBuiltins 将内置提示词写入为版本1，已存在的模板不覆盖
+func (s *Service) SeedBuiltins(ctx context.Context) error {
+	for _, b := range ai.BuiltinPrompts() {
+		version, err := s.repo.MaxVersion(ctx, b.Name)
+		if err != nil {
+			return err
+		}
+		if version > 0 {
+			continue
+		}
+		variables, _ := json.Marshal(b.Variables)
+		prompt := &entity.PromptTemplate{
+			Name:        b.Name,
+			Version:     1,
+			Description: b.Description,
+			System:      b.System,
+			Content:     b.Content,
+			Variables:   string(variables),
+			Status:      entity.PromptStatusActive,
+			Weight:      100,
+		}
+		if err := s.repo.Create(ctx, prompt); err != nil {
+			return err
+		}
+		log.Printf("[Prompt] 已初始化内置提示词: %s", b.Name)
+	}
+	return nil
+}
+
+// ActivePrompts 实现 ai.PromptStore
+func (s *Service) ActivePrompts(ctx context.Context, name string) ([]*entity.PromptTemplate, error) {
+	return s.repo.ListActive(ctx, name)
+}
+
+// GetPrompt 实现 ai.PromptStore
+func (s *Service) GetPrompt(ctx context.Context, name string, version int) (*entity.PromptTemplate, error) {
+	return s.repo.GetByVersion(ctx, name, version)
+}
+
+// RecordUsage 实现 ai.PromptStore
+func (s *Service) RecordUsage(ctx context.Context, usage *entity.PromptUsage) error {
+	return s.repo.CreateUsage(ctx, usage)
+}
+
+// List 获取提示词模板列表
+func (s *Service) List(ctx context.Context, name string) ([]*dto.PromptResponse, error) {
+	prompts, err := s.repo.List(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*dto.PromptResponse, 0, len(prompts))
+	for _, p := range prompts {
+		responses = append(responses, toPromptResponse(p))
+	}
+	return responses, nil
+}
+
+// GetByID 获取提示词模板详情
+func (s *Service) GetByID(ctx context.Context, id string) (*dto.PromptResponse, error) {
+	promptUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("无效的提示词ID")
+	}
+	prompt, err := s.repo.GetByID(ctx, promptUUID)
+	if err != nil {
+		return nil, err
+	}
+	return toPromptResponse(prompt), nil
+}
+
+// Create 新建提示词版本，版本号在同名模板中自动递增
+func (s *Service) Create(ctx context.Context, userID string, req dto.CreatePromptRequest) (*dto.PromptResponse, error) {
+	if _, _, err := ai.RenderTemplate(req.System, req.Content, nil); err != nil {
+		return nil, err
+	}
+	version, err := s.repo.MaxVersion(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	prompt := &entity.PromptTemplate{
+		Name:        req.Name,
+		Version:     version + 1,
+		Description: req.Description,
+		System:      req.System,
+		Content:     req.Content,
+		Status:      entity.PromptStatusDraft,
+		Weight:      100,
+	}
+	if req.Status != "" {
+		prompt.Status = req.Status
+	}
+	if req.Weight != nil {
+		prompt.Weight = *req.Weight
+	}
+	if userUUID, err := uuid.Parse(userID); err == nil {
+		prompt.CreatedBy = &userUUID
+	}
+	setPromptJSON(prompt, req.Variables, req.ModelParams)
+	if err := s.repo.Create(ctx, prompt); err != nil {
+		return nil, err
+	}
+	return toPromptResponse(prompt), nil
+}
+
+// Update 更新提示词；已启用或已被任务使用过的版本只能调整状态、权重和描述
+func (s *Service) Update(ctx context.Context, id string, req dto.UpdatePromptRequest) (*dto.PromptResponse, error) {
+	promptUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("无效的提示词ID")
+	}
+	prompt, err := s.repo.GetByID(ctx, promptUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.System != nil || req.Content != nil || req.Variables != nil || req.ModelParams != nil {
+		used, err := s.repo.CountUsages(ctx, prompt.Name, prompt.Version)
+		if err != nil {
+			return nil, err
+		}
+		if prompt.Status != entity.PromptStatusDraft || used > 0 {
+			return nil, errors.New("已启用或已使用的版本不可修改内容，请新建版本")
+		}
+		if req.System != nil {
+			prompt.System = *req.System
+		}
+		if req.Content != nil {
+			prompt.Content = *req.Content
+		}
+		if _, _, err := ai.RenderTemplate(prompt.System, prompt.Content, nil); err != nil {
+			return nil, err
+		}
+		variables := req.Variables
+		if variables == nil {
+			_ = json.Unmarshal([]byte(prompt.Variables), &variables)
+		}
+		params := req.ModelParams
+		if params == nil {
+			_ = json.Unmarshal([]byte(prompt.ModelParams), &params)
+		}
+		setPromptJSON(prompt, variables, params)
+	}
+	if req.Description != nil {
+		prompt.Description = *req.Description
+	}
+	if req.Status != "" {
+		prompt.Status = req.Status
+	}
+	if req.Weight != nil {
+		prompt.Weight = *req.Weight
+	}
+
+	if err := s.repo.Update(ctx, prompt); err != nil {
+		return nil, err
+	}
+	return toPromptResponse(prompt), nil
+}
+
+// StartABTest 启用两个版本按权重分流，同名其它启用版本归档
+func (s *Service) StartABTest(ctx context.Context, name string, req dto.PromptABTestRequest) ([]*dto.PromptResponse, error) {
+	if req.WeightA+req.WeightB <= 0 {
+		return nil, errors.New("分流权重之和必须大于0")
+	}
+	for _, v := range []int{req.VersionA, req.VersionB} {
+		if _, err := s.repo.GetByVersion(ctx, name, v); err != nil {
+			return nil, errors.New("提示词版本不存在")
+		}
+	}
+	if err := s.repo.SetABTest(ctx, name, map[int]int{req.VersionA: req.WeightA, req.VersionB: req.WeightB}); err != nil {
+		return nil, err
+	}
+	return s.List(ctx, name)
+}
+
+// Stats 各版本使用次数与任务成败统计
+func (s *Service) Stats(ctx context.Context, name string) (*dto.PromptStatsResponse, error) {
+	stats, err := s.repo.Stats(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := s.repo.List(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*entity.PromptTemplate, len(prompts))
+	for _, p := range prompts {
+		byVersion[p.Version] = p
+	}
+
+	resp := &dto.PromptStatsResponse{Name: name, Versions: make([]dto.PromptVersionStats, 0, len(stats))}
+	for _, st := range stats {
+		item := dto.PromptVersionStats{
+			Version:   st.Version,
+			Uses:      st.Uses,
+			Completed: st.Completed,
+			Failed:    st.Failed,
+		}
+		if p, ok := byVersion[st.Version]; ok {
+			item.Status = p.Status
+			item.Weight = p.Weight
+		}
+		if finished := st.Completed + st.Failed; finished > 0 {
+			item.SuccessRate = float64(st.Completed) / float64(finished)
+		}
+		resp.Versions = append(resp.Versions, item)
+	}
+	return resp, nil
+}
+
+// Preview 用示例变量渲染提示词，便于编辑时检查
+func (s *Service) Preview(ctx context.Context, req dto.PreviewPromptRequest) (map[string]string, error) {
+	system, content, err := ai.RenderTemplate(req.System, req.Content, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"system": system, "content": content}, nil
+}
+
+// setPromptJSON 写入变量列表与调用参数
+func setPromptJSON(prompt *entity.PromptTemplate, variables []string, params map[string]interface{}) {
+	prompt.Variables, prompt.ModelParams = "", ""
+	if len(variables) > 0 {
+		b, _ := json.Marshal(variables)
+		prompt.Variables = string(b)
+	}
+	if len(params) > 0 {
+		b, _ := json.Marshal(params)
+		prompt.ModelParams = string(b)
+	}
+}
+
+// 工具函数：转为响应结构体
+func toPromptResponse(p *entity.PromptTemplate) *dto.PromptResponse {
+	resp := &dto.PromptResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Version:     p.Version,
+		Description: p.Description,
+		System:      p.System,
+		Content:     p.Content,
+		Variables:   []string{},
+		Status:      p.Status,
+		Weight:      p.Weight,
+		CreatedBy:   p.CreatedBy,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if p.Variables != "" {
+		_ = json.Unmarshal([]byte(p.Variables), &resp.Variables)
+	}
+	if p.ModelParams != "" {
+		_ = json.Unmarshal([]byte(p.ModelParams), &resp.ModelParams)
+	}
+	return resp
+}
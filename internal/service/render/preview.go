@@ -0,0 +1,101 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+const (
+	previewWidth    = 480 // 预览渲染宽度
+	previewGIFWidth = 320 // 预览动图宽度
+	previewGIFLen   = 6.0 // 预览动图最长秒数
+	previewGIFFPS   = 10
+)
+
+// PreviewResult 预览渲染结果
+type PreviewResult struct {
+	ThumbnailPath string  // 封面图对象路径
+	PreviewPath   string  // 预览动图对象路径
+	Duration      float64 // 渲染成片时长（秒）
+}
+
+// RenderPreview 按项目配置做一次低分辨率渲染，从成片中抽取封面图和预览动图并上传到 objectPrefix 下
+// 不创建渲染记录，用于模板等无需保留成片的场景；不属于任何用户，只能使用公开素材
+func (s *service) RenderPreview(ctx context.Context, config ProjectConfig, objectPrefix string) (*PreviewResult, error) {
+	tempDir, err := os.MkdirTemp("", "preview_*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := s.downloadProjectMaterials(ctx, config, uuid.Nil, tempDir); err != nil {
+		return nil, fmt.Errorf("下载素材失败: %w", err)
+	}
+	outputFile := filepath.Join(tempDir, "preview.mp4")
+	cmd, err := s.buildFFmpegCommand(config, tempDir, outputFile, previewWidth)
+	if err != nil {
+		return nil, fmt.Errorf("构建FFmpeg命令失败: %w", err)
+	}
+	if err := s.executeFFmpeg(cmd); err != nil {
+		return nil, err
+	}
+	duration, _ := s.getVideoDuration(outputFile)
+
+	// 封面取成片三分之一处，避开片头淡入的黑场
+	thumbnailFile := filepath.Join(tempDir, "thumbnail.jpg")
+	if err := s.executeFFmpeg(exec.Command("ffmpeg", "-y",
+		"-ss", formatFloat(duration/3),
+		"-i", outputFile,
+		"-frames:v", "1", "-q:v", "3",
+		thumbnailFile,
+	)); err != nil {
+		return nil, fmt.Errorf("抽取封面失败: %w", err)
+	}
+
+	// 预览动图：先生成调色板再编码，避免GIF色带
+	previewFile := filepath.Join(tempDir, "preview.gif")
+	filter := fmt.Sprintf("fps=%d,scale=%d:-1:flags=lanczos,split[a][b];[a]palettegen[p];[b][p]paletteuse",
+		previewGIFFPS, previewGIFWidth)
+	if err := s.executeFFmpeg(exec.Command("ffmpeg", "-y",
+		"-t", formatFloat(previewGIFLen),
+		"-i", outputFile,
+		"-filter_complex", filter,
+		"-loop", "0",
+		previewFile,
+	)); err != nil {
+		return nil, fmt.Errorf("生成预览动图失败: %w", err)
+	}
+
+	result := &PreviewResult{
+		ThumbnailPath: objectPrefix + "/thumbnail.jpg",
+		PreviewPath:   objectPrefix + "/preview.gif",
+		Duration:      duration,
+	}
+	if err := s.uploadFile(ctx, thumbnailFile, result.ThumbnailPath, "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("上传封面失败: %w", err)
+	}
+	if err := s.uploadFile(ctx, previewFile, result.PreviewPath, "image/gif"); err != nil {
+		return nil, fmt.Errorf("上传预览动图失败: %w", err)
+	}
+	return result, nil
+}
+
+// uploadFile 上传本地文件到默认存储桶
+func (s *service) uploadFile(ctx context.Context, localPath, objectPath, contentType string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = s.storage.Upload(ctx, objectPath, file, info.Size(), contentType)
+	return err
+}
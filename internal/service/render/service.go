@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,11 +14,11 @@ import (
 	"github.com/google/uuid"
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/entity"
-	"comic_video/internal/repository/minio"
 	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/media"
+	"comic_video/internal/utils"
 	"encoding/json"
-	"io"
-	"net/http"
 )
 
 // Service 渲染服务接口
@@ -29,6 +30,8 @@ type Service interface {
 	GetRenderStatus(ctx context.Context, userID, renderID uuid.UUID) (*dto.RenderStatusResponse, error)
 	DownloadRender(ctx context.Context, userID, renderID uuid.UUID) (*dto.DownloadRenderResponse, error)
 	ProcessRender(ctx context.Context, renderID uuid.UUID) error
+	RenderPreview(ctx context.Context, config ProjectConfig, objectPrefix string) (*PreviewResult, error)
+	Playback(ctx context.Context, renderID uuid.UUID, access PlaybackAccess, format, name string) (*PlaybackFile, error)
 }
 
 // Effect 定义特效/滤镜/转场等
@@ -45,15 +48,28 @@ type Clip struct {
 	MaterialID string   `json:"material_id"`
 	Start      float64  `json:"start"`
 	End        float64  `json:"end"`
-	Effects    []Effect `json:"effects"` // 支持多个特效/滤镜/转场
+	Effects    []Effect `json:"effects"`        // 支持多个特效/滤镜/转场
+	Text       string   `json:"text,omitempty"` // 字幕文本，仅 subtitle 轨道使用
 }
 
+// TrackTypeSubtitle 字幕轨道，clip 的 start/end 为成片时间，渲染时烧录到画面
+const TrackTypeSubtitle = "subtitle"
+
 // Track 轨道，支持多类型
 type Track struct {
-	Type  string `json:"type"` // video/audio/image
+	Type  string `json:"type"` // video/audio/image/subtitle
 	Clips []Clip `json:"clips"`
 }
 
+// SubtitleTrack 由字幕条目生成字幕轨道，可直接加入 ProjectConfig.Tracks
+func SubtitleTrack(cues []utils.SubtitleCue) Track {
+	clips := make([]Clip, 0, len(cues))
+	for _, cue := range cues {
+		clips = append(clips, Clip{Start: cue.Start, End: cue.End, Text: cue.Text})
+	}
+	return Track{Type: TrackTypeSubtitle, Clips: clips}
+}
+
 // ProjectConfig 项目配置，支持多轨道/分辨率/帧率等
 type ProjectConfig struct {
 	Tracks     []Track `json:"tracks"`
@@ -67,9 +83,10 @@ type service struct {
 	renderRepo   postgres.RenderRepository
 	projectRepo  postgres.ProjectRepository
 	materialRepo postgres.MaterialRepository
-	minioClient  minio.MinioClient
+	storage      storage.BlobStore
 	outputDir    string
 	queue        RenderQueue // 新增：渲染任务队列
+	playbackKey  []byte      // 播放令牌签名密钥
 }
 
 // NewService 创建渲染服务实例
@@ -77,17 +94,19 @@ func NewService(
 	renderRepo postgres.RenderRepository,
 	projectRepo postgres.ProjectRepository,
 	materialRepo postgres.MaterialRepository,
-	minioClient minio.MinioClient,
+	store storage.BlobStore,
 	outputDir string,
 	queue RenderQueue, // 新增参数
+	playbackKey string,
 ) Service {
 	return &service{
 		renderRepo:   renderRepo,
 		projectRepo:  projectRepo,
 		materialRepo: materialRepo,
-		minioClient:  minioClient,
+		storage:      store,
 		outputDir:    outputDir,
 		queue:        queue,
+		playbackKey:  []byte(playbackKey),
 	}
 }
 
@@ -101,6 +120,13 @@ func (s *service) CreateRender(ctx context.Context, userID uuid.UUID, req *dto.C
 	if project.UserID != userID {
 		return nil, fmt.Errorf("无权访问此项目")
 	}
+	var config ProjectConfig
+	if err := json.Unmarshal([]byte(project.Config), &config); err != nil {
+		return nil, fmt.Errorf("解析项目配置失败: %w", err)
+	}
+	if _, err := s.projectMaterials(ctx, config, project.UserID); err != nil {
+		return nil, err
+	}
 
 	// 创建渲染任务
 	render := &entity.Render{
@@ -112,6 +138,7 @@ func (s *service) CreateRender(ctx context.Context, userID uuid.UUID, req *dto.C
 		Quality:    req.Quality,
 		Format:     req.Format,
 		Resolution: req.Resolution,
+		Streaming:  strings.Join(req.Streaming, ","),
 	}
 
 	if err := s.renderRepo.Create(ctx, render); err != nil {
@@ -204,10 +231,11 @@ func (s *service) DeleteRender(ctx context.Context, userID, renderID uuid.UUID)
 
 	// 如果渲染已完成，删除输出文件
 	if render.Status == "completed" && render.OutputPath != "" {
-		if err := s.minioClient.DeleteObject(ctx, "renders", render.OutputPath); err != nil {
+		if err := s.storage.Delete(ctx, renderObject(render.OutputPath)); err != nil {
 			fmt.Printf("删除输出文件失败: %v\n", err)
 		}
 	}
+	s.deleteStreams(ctx, render)
 
 	return s.renderRepo.Delete(ctx, renderID)
 }
@@ -250,13 +278,13 @@ func (s *service) DownloadRender(ctx context.Context, userID, renderID uuid.UUID
 	}
 
 	// 生成预签名下载URL
-	url, err := s.minioClient.PresignedGetObject(ctx, "renders", render.OutputPath, 24*time.Hour)
+	url, err := s.storage.PresignedURL(ctx, renderObject(render.OutputPath), 24*time.Hour)
 	if err != nil {
 		return nil, fmt.Errorf("生成下载链接失败: %w", err)
 	}
 
 	return &dto.DownloadRenderResponse{
-		URL: url.String(),
+		URL: url,
 	}, nil
 }
 
@@ -285,8 +313,13 @@ func (s *service) ProcessRender(ctx context.Context, renderID uuid.UUID) error {
 	}
 	defer os.RemoveAll(tempDir)
 
+	var config ProjectConfig
+	if err := json.Unmarshal([]byte(project.Config), &config); err != nil {
+		return s.handleRenderError(ctx, renderID, fmt.Sprintf("解析项目配置失败: %v", err))
+	}
+
 	// 下载项目素材
-	if err := s.downloadProjectMaterials(ctx, project, tempDir); err != nil {
+	if err := s.downloadProjectMaterials(ctx, config, project.UserID, tempDir); err != nil {
 		return s.handleRenderError(ctx, renderID, fmt.Sprintf("下载项目素材失败: %v", err))
 	}
 
@@ -297,7 +330,7 @@ func (s *service) ProcessRender(ctx context.Context, renderID uuid.UUID) error {
 
 	// 生成FFmpeg命令
 	outputFile := filepath.Join(tempDir, fmt.Sprintf("output.%s", s.getFileExtension(render.Format)))
-	ffmpegCmd, err := s.buildFFmpegCommand(project, tempDir, outputFile, render)
+	ffmpegCmd, err := s.buildFFmpegCommand(config, tempDir, outputFile, 0)
 	if err != nil {
 		return s.handleRenderError(ctx, renderID, fmt.Sprintf("构建FFmpeg命令失败: %v", err))
 	}
@@ -323,6 +356,11 @@ func (s *service) ProcessRender(ctx context.Context, renderID uuid.UUID) error {
 		return s.handleRenderError(ctx, renderID, fmt.Sprintf("上传输出文件失败: %v", err))
 	}
 
+	// 按需打包 HLS / DASH 自适应码率版本
+	if err := s.packageStreams(ctx, render, outputFile, tempDir, 85, 99); err != nil {
+		return s.handleRenderError(ctx, renderID, fmt.Sprintf("生成自适应码率版本失败: %v", err))
+	}
+
 	// 获取文件信息
 	fileInfo, err := os.Stat(outputFile)
 	if err != nil {
@@ -349,31 +387,51 @@ func (s *service) ProcessRender(ctx context.Context, renderID uuid.UUID) error {
 	return nil
 }
 
-// downloadProjectMaterials 下载项目所有用到的素材到本地
-func (s *service) downloadProjectMaterials(ctx context.Context, project *entity.Project, tempDir string) error {
-	var config ProjectConfig
-	if err := json.Unmarshal([]byte(project.Config), &config); err != nil {
-		return err
-	}
-	materialsDir := filepath.Join(tempDir, "materials")
-	if err := os.MkdirAll(materialsDir, 0755); err != nil {
-		return err
-	}
+// projectMaterials 加载项目用到的素材，拒绝使用他人的私有素材
+// ownerID 为项目所有者，为空时只允许公开素材
+func (s *service) projectMaterials(ctx context.Context, config ProjectConfig, ownerID uuid.UUID) ([]*entity.Material, error) {
 	materialSet := make(map[string]struct{})
+	var materials []*entity.Material
 	for _, track := range config.Tracks {
+		if track.Type == TrackTypeSubtitle {
+			continue
+		}
 		for _, clip := range track.Clips {
+			if _, ok := materialSet[clip.MaterialID]; ok {
+				continue
+			}
 			materialSet[clip.MaterialID] = struct{}{}
+			id, err := uuid.Parse(clip.MaterialID)
+			if err != nil {
+				return nil, fmt.Errorf("无效的素材ID: %s", clip.MaterialID)
+			}
+			material, err := s.materialRepo.GetByID(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("素材不存在: %s", clip.MaterialID)
+			}
+			if !material.VisibleTo(ownerID) {
+				return nil, fmt.Errorf("无权使用私有素材: %s", material.Name)
+			}
+			if !material.Usable() {
+				return nil, fmt.Errorf("素材不可用: %s", material.Name)
+			}
+			materials = append(materials, material)
 		}
 	}
-	for materialID := range materialSet {
-		id, err := uuid.Parse(materialID)
-		if err != nil {
-			return err
-		}
-		material, err := s.materialRepo.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
+	return materials, nil
+}
+
+// downloadProjectMaterials 下载项目所有用到的素材到本地
+func (s *service) downloadProjectMaterials(ctx context.Context, config ProjectConfig, ownerID uuid.UUID, tempDir string) error {
+	materialsDir := filepath.Join(tempDir, "materials")
+	if err := os.MkdirAll(materialsDir, 0755); err != nil {
+		return err
+	}
+	materials, err := s.projectMaterials(ctx, config, ownerID)
+	if err != nil {
+		return err
+	}
+	for _, material := range materials {
 		localPath := filepath.Join(materialsDir, material.ID.String()+filepath.Ext(material.FileName))
 		if err := s.downloadFromMinio(ctx, material.FilePath, localPath); err != nil {
 			return err
@@ -384,22 +442,7 @@ func (s *service) downloadProjectMaterials(ctx context.Context, project *entity.
 
 // downloadFromMinio 下载单个素材
 func (s *service) downloadFromMinio(ctx context.Context, objectPath, localPath string) error {
-	url, err := s.minioClient.PresignedURL(ctx, objectPath, 10*time.Minute)
-	if err != nil {
-		return err
-	}
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	out, err := os.Create(localPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return media.Download(ctx, s.storage, objectPath, localPath)
 }
 
 // buildClipFilter 根据 effects 生成 FFmpeg filter 字符串
@@ -436,17 +479,22 @@ func buildClipFilter(effects []Effect, clipDuration float64) string {
 	return strings.Join(filters, ",")
 }
 
+// materialFile 素材本地路径，下载时按原文件扩展名保存，找不到时按 ext 兜底
+func materialFile(materialsDir, materialID, ext string) string {
+	if matches, _ := filepath.Glob(filepath.Join(materialsDir, materialID+".*")); len(matches) > 0 {
+		return matches[0]
+	}
+	return filepath.Join(materialsDir, materialID+ext)
+}
+
 // formatFloat 保证小数点格式
 func formatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', 2, 64)
 }
 
 // buildFFmpegCommand 根据项目轨道智能生成FFmpeg命令，支持clip.effects
-func (s *service) buildFFmpegCommand(project *entity.Project, tempDir, outputFile string, render *entity.Render) (*exec.Cmd, error) {
-	var config ProjectConfig
-	if err := json.Unmarshal([]byte(project.Config), &config); err != nil {
-		return nil, err
-	}
+// scaleWidth 大于0时按该宽度等比缩放输出画面，用于低分辨率预览
+func (s *service) buildFFmpegCommand(config ProjectConfig, tempDir, outputFile string, scaleWidth int) (*exec.Cmd, error) {
 	materialsDir := filepath.Join(tempDir, "materials")
 
 	// 仅支持单视频轨道，逐clip处理
@@ -459,9 +507,13 @@ func (s *service) buildFFmpegCommand(project *entity.Project, tempDir, outputFil
 			continue
 		}
 		for _, clip := range track.Clips {
-			inputFile := filepath.Join(materialsDir, clip.MaterialID+".mp4")
+			inputFile := materialFile(materialsDir, clip.MaterialID, ".mp4")
 			if track.Type == "image" {
-				inputFile = filepath.Join(materialsDir, clip.MaterialID+".jpg")
+				inputFile = materialFile(materialsDir, clip.MaterialID, ".jpg")
+				// 图片按 clip 时长循环为视频流
+				if d := clip.End - clip.Start; d > 0 {
+					inputArgs = append(inputArgs, "-loop", "1", "-t", formatFloat(d))
+				}
 			}
 			inputArgs = append(inputArgs, "-i", inputFile)
 			filter := buildClipFilter(clip.Effects, clip.End-clip.Start)
@@ -479,20 +531,97 @@ func (s *service) buildFFmpegCommand(project *entity.Project, tempDir, outputFil
 	// 拼接所有片段
 	var args []string
 	args = append(args, inputArgs...)
+	filterStr, outLabel := "", ""
 	if len(filterCmds) > 0 {
-		filterStr := strings.Join(filterCmds, ";")
+		filterStr = strings.Join(filterCmds, ";")
 		if len(concatInputs) > 1 {
 			filterStr += ";" + strings.Join(concatInputs, "") + "concat=n=" + strconv.Itoa(len(concatInputs)) + ":v=1:a=0[vout]"
-			args = append(args, "-filter_complex", filterStr, "-map", "[vout]")
+			outLabel = "[vout]"
 		} else {
-			args = append(args, "-filter_complex", filterStr, "-map", concatInputs[0])
+			outLabel = concatInputs[0]
 		}
 	} else if len(concatInputs) > 1 {
 		// 无滤镜，仅拼接
-		filterStr := strings.Join(concatInputs, "") + "concat=n=" + strconv.Itoa(len(concatInputs)) + ":v=1:a=0[vout]"
-		args = append(args, "-filter_complex", filterStr, "-map", "[vout]")
+		filterStr = strings.Join(concatInputs, "") + "concat=n=" + strconv.Itoa(len(concatInputs)) + ":v=1:a=0[vout]"
+		outLabel = "[vout]"
+	}
+	// 字幕轨道写入SRT并烧录
+	var cues []utils.SubtitleCue
+	for _, track := range config.Tracks {
+		if track.Type != TrackTypeSubtitle {
+			continue
+		}
+		for _, clip := range track.Clips {
+			cues = append(cues, utils.SubtitleCue{Start: clip.Start, End: clip.End, Text: clip.Text})
+		}
+	}
+	if len(cues) > 0 && len(concatInputs) > 0 {
+		sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+		if err := os.WriteFile(filepath.Join(tempDir, "subtitles.srt"), []byte(utils.FormatSRT(cues)), 0644); err != nil {
+			return nil, err
+		}
+		if outLabel == "" {
+			outLabel = concatInputs[0]
+		}
+		if filterStr != "" {
+			filterStr += ";"
+		}
+		filterStr += outLabel + "subtitles=subtitles.srt[vsub]"
+		outLabel = "[vsub]"
+	}
+	if scaleWidth > 0 && len(concatInputs) > 0 {
+		if outLabel == "" {
+			outLabel = concatInputs[0]
+		}
+		if filterStr != "" {
+			filterStr += ";"
+		}
+		filterStr += outLabel + "scale=" + strconv.Itoa(scaleWidth) + ":-2[vscaled]"
+		outLabel = "[vscaled]"
 	}
-	args = append(args, "-c:v", "libx264", "-preset", "fast", "-crf", "23", outputFile)
+	// 音频轨道：每个 clip 从素材开头截取 end-start 秒，延迟到 start 处后混音
+	audioIdx := 0
+	var audioLabels []string
+	for _, track := range config.Tracks {
+		if track.Type != "audio" {
+			continue
+		}
+		for _, clip := range track.Clips {
+			d := clip.End - clip.Start
+			if d <= 0 {
+				continue
+			}
+			args = append(args, "-i", materialFile(materialsDir, clip.MaterialID, ".mp3"))
+			delay := strconv.Itoa(int(clip.Start * 1000))
+			label := "[a" + strconv.Itoa(audioIdx) + "]"
+			if filterStr != "" {
+				filterStr += ";"
+			}
+			filterStr += "[" + strconv.Itoa(clipIdx+audioIdx) + ":a]atrim=0:" + formatFloat(d) + ",adelay=" + delay + "|" + delay + label
+			audioLabels = append(audioLabels, label)
+			audioIdx++
+		}
+	}
+	audioOut := ""
+	if len(audioLabels) == 1 {
+		audioOut = audioLabels[0]
+	} else if len(audioLabels) > 1 {
+		filterStr += ";" + strings.Join(audioLabels, "") + "amix=inputs=" + strconv.Itoa(len(audioLabels)) + ":duration=longest:normalize=0[aout]"
+		audioOut = "[aout]"
+	}
+	if filterStr != "" {
+		args = append(args, "-filter_complex", filterStr)
+		if outLabel != "" {
+			args = append(args, "-map", outLabel)
+		} else if len(concatInputs) == 1 {
+			// 单个未经滤镜处理的输入直接映射原始视频流
+			args = append(args, "-map", "0:v")
+		}
+	}
+	if audioOut != "" {
+		args = append(args, "-map", audioOut, "-c:a", "aac")
+	}
+	args = append(args, "-c:v", "libx264", "-preset", "fast", "-crf", "23", "-pix_fmt", "yuv420p", outputFile)
 	cmd := exec.Command("ffmpeg", args...)
 	cmd.Dir = tempDir
 	return cmd, nil
@@ -514,8 +643,13 @@ func (s *service) uploadOutputFile(ctx context.Context, localPath, objectPath st
 		return err
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
 
-	return s.minioClient.PutObject(ctx, "renders", objectPath, file, "video/mp4")
+	_, err = s.storage.Upload(ctx, renderObject(objectPath), file, info.Size(), "video/mp4")
+	return err
 }
 
 // getVideoDuration 获取视频时长
@@ -575,6 +709,9 @@ func (s *service) entityToResponse(render *entity.Render) *dto.RenderResponse {
 		Format:      render.Format,
 		Quality:     render.Quality,
 		Error:       render.Error,
+		Streaming:   streamingFormats(render.Streaming),
+		HLSURL:      playbackURL(render, StreamHLS, render.HLSPath),
+		DASHURL:     playbackURL(render, StreamDASH, render.DASHPath),
 		StartedAt:   render.StartedAt,
 		CompletedAt: render.CompletedAt,
 		CreatedAt:   render.CreatedAt,
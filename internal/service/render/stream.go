@@ -0,0 +1,327 @@
+package render
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/media"
+
+	"github.com/google/uuid"
+)
+
+// 自适应码率输出格式
+const (
+	StreamHLS  = "hls"
+	StreamDASH = "dash"
+)
+
+// rendersPrefix 渲染成片及其播放文件的对象名前缀，记录中保存的路径相对于该前缀
+const rendersPrefix = "renders/"
+
+// playbackURLExpiry 播放列表中分片签名地址的有效期，覆盖一次完整观看
+const playbackURLExpiry = 6 * time.Hour
+
+var (
+	// ErrPlaybackForbidden 既不是渲染任务所有者也没有有效的项目分享
+	ErrPlaybackForbidden = errors.New("无权观看此渲染结果")
+	// ErrPlaybackNotFound 渲染任务不存在、未生成对应格式或请求的播放列表不存在
+	ErrPlaybackNotFound = errors.New("播放文件不存在")
+
+	// dashURLAttr DASH 清单中引用分片的属性
+	dashURLAttr = regexp.MustCompile(`\b(initialization|media|sourceURL)="([^"]+)"`)
+	// hlsURIAttr HLS 标签中的 URI 属性，如 EXT-X-MAP、EXT-X-MEDIA
+	hlsURIAttr = regexp.MustCompile(`URI="([^"]+)"`)
+)
+
+// PlaybackAccess 观看者身份：登录用户、通过项目分享校验的匿名观看者，或持有播放令牌的观看者
+type PlaybackAccess struct {
+	UserID          uuid.UUID  // 登录用户，匿名为空
+	SharedProjectID *uuid.UUID // 分享校验通过的项目
+	Token           string     // 子播放列表地址上的播放令牌
+}
+
+// PlaybackFile 改写后的播放列表
+type PlaybackFile struct {
+	ContentType string
+	Body        []byte
+}
+
+// allows 所有者或持有该项目有效分享的观看者可观看
+func (a PlaybackAccess) allows(render *entity.Render) bool {
+	if a.UserID != uuid.Nil && a.UserID == render.UserID {
+		return true
+	}
+	return a.SharedProjectID != nil && *a.SharedProjectID == render.ProjectID
+}
+
+// streamingFormats 解析渲染任务要求的自适应码率格式
+func streamingFormats(streaming string) []string {
+	var formats []string
+	for _, f := range strings.Split(streaming, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// streamPrefix 渲染任务自适应码率文件的对象路径前缀
+func streamPrefix(render *entity.Render, format string) string {
+	return fmt.Sprintf("%s/%s/%s", render.UserID.String(), render.ID.String(), format)
+}
+
+// packageStreams 将渲染成片按码率阶梯打包为 HLS / DASH 并上传，进度从 from 推进到 to
+func (s *service) packageStreams(ctx context.Context, render *entity.Render, outputFile, tempDir string, from, to int) error {
+	formats := streamingFormats(render.Streaming)
+	if len(formats) == 0 {
+		return nil
+	}
+	info, err := media.Probe(ctx, outputFile)
+	if err != nil {
+		return err
+	}
+	ladder := media.LadderFor(info.Height, media.DefaultLadder)
+
+	last := from
+	for i, format := range formats {
+		report := func(p float64) {
+			pct := from + int((float64(i)+p)/float64(len(formats))*float64(to-from))
+			if pct > last {
+				last = pct
+				_ = s.renderRepo.UpdateStatus(ctx, render.ID, "processing", pct, "")
+			}
+		}
+		dir := filepath.Join(tempDir, "stream", format)
+		var manifest string
+		switch format {
+		case StreamHLS:
+			err = media.PackageHLS(ctx, outputFile, info, dir, ladder, report)
+			manifest = "master.m3u8"
+		case StreamDASH:
+			err = media.PackageDASH(ctx, outputFile, info, dir, ladder, report)
+			manifest = "manifest.mpd"
+		default:
+			err = fmt.Errorf("不支持的播放格式: %s", format)
+		}
+		if err != nil {
+			return err
+		}
+		prefix := streamPrefix(render, format)
+		if err := media.UploadDir(ctx, s.storage, dir, renderObject(prefix)); err != nil {
+			return fmt.Errorf("上传%s文件失败: %w", format, err)
+		}
+		if format == StreamHLS {
+			render.HLSPath = prefix + "/" + manifest
+		} else {
+			render.DASHPath = prefix + "/" + manifest
+		}
+	}
+	return nil
+}
+
+// Playback 返回改写后的播放列表：子播放列表仍经本接口鉴权，分片替换为临时签名地址
+// name 为相对于格式根目录的播放列表路径，如 master.m3u8、720p/index.m3u8、manifest.mpd
+func (s *service) Playback(ctx context.Context, renderID uuid.UUID, access PlaybackAccess, format, name string) (*PlaybackFile, error) {
+	render, err := s.renderRepo.GetByID(ctx, renderID)
+	if err != nil {
+		return nil, ErrPlaybackNotFound
+	}
+	token := access.Token
+	if token != "" && !s.validPlaybackToken(render.ID, token) {
+		return nil, ErrPlaybackForbidden
+	}
+	if token == "" && !access.allows(render) {
+		return nil, ErrPlaybackForbidden
+	}
+	if render.Status != "completed" {
+		return nil, ErrPlaybackNotFound
+	}
+	// 子播放列表一律改带播放令牌：原生 HLS 播放器请求子播放列表时不会带上 Authorization 头，
+	// 分享密码也不出现在播放列表中；持有令牌时沿用原令牌，不延长有效期
+	if token == "" {
+		token = s.playbackToken(render.ID, time.Now().Add(playbackURLExpiry))
+	}
+	query := url.Values{"playback_token": {token}}.Encode()
+
+	var root string
+	switch format {
+	case StreamHLS:
+		root = render.HLSPath
+	case StreamDASH:
+		root = render.DASHPath
+	}
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if root == "" || name == "." || strings.HasPrefix(name, "..") {
+		return nil, ErrPlaybackNotFound
+	}
+	if (format == StreamHLS && path.Ext(name) != ".m3u8") || (format == StreamDASH && name != path.Base(root)) {
+		return nil, ErrPlaybackNotFound
+	}
+	baseDir := path.Dir(root)
+	objectName := baseDir + "/" + name
+
+	body, err := s.fetchObject(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	objectDir := path.Dir(objectName)
+	if format == StreamDASH {
+		out, err := s.rewriteDASH(ctx, body, objectDir)
+		if err != nil {
+			return nil, err
+		}
+		return &PlaybackFile{ContentType: media.ContentType(name), Body: out}, nil
+	}
+	out, err := s.rewriteHLS(ctx, body, objectDir, query)
+	if err != nil {
+		return nil, err
+	}
+	return &PlaybackFile{ContentType: media.ContentType(name), Body: out}, nil
+}
+
+// rewriteHLS 子播放列表保留相对地址并附加查询参数，分片和初始化段替换为签名地址
+func (s *service) rewriteHLS(ctx context.Context, body []byte, objectDir, query string) ([]byte, error) {
+	var firstErr error
+	rewrite := func(uri string) string {
+		if strings.Contains(uri, "://") {
+			return uri
+		}
+		if path.Ext(uri) == ".m3u8" {
+			if query == "" {
+				return uri
+			}
+			return uri + "?" + query
+		}
+		signed, err := s.signObject(ctx, path.Join(objectDir, uri))
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return signed
+	}
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			line = hlsURIAttr.ReplaceAllStringFunc(line, func(m string) string {
+				return `URI="` + rewrite(hlsURIAttr.FindStringSubmatch(m)[1]) + `"`
+			})
+		default:
+			line = rewrite(line)
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return []byte(out.String()), nil
+}
+
+// rewriteDASH 将 SegmentList 中的初始化段和分片地址替换为签名地址
+func (s *service) rewriteDASH(ctx context.Context, body []byte, objectDir string) ([]byte, error) {
+	var firstErr error
+	out := dashURLAttr.ReplaceAllStringFunc(string(body), func(m string) string {
+		sub := dashURLAttr.FindStringSubmatch(m)
+		uri := html.UnescapeString(sub[2])
+		if strings.Contains(uri, "://") {
+			return m
+		}
+		signed, err := s.signObject(ctx, path.Join(objectDir, uri))
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return sub[1] + `="` + html.EscapeString(signed) + `"`
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return []byte(out), nil
+}
+
+func (s *service) signObject(ctx context.Context, objectName string) (string, error) {
+	u, err := s.storage.PresignedURL(ctx, renderObject(objectName), playbackURLExpiry)
+	if err != nil {
+		return "", fmt.Errorf("生成播放地址失败: %w", err)
+	}
+	return u, nil
+}
+
+// fetchObject 读取渲染结果的播放列表
+func (s *service) fetchObject(ctx context.Context, objectName string) ([]byte, error) {
+	reader, err := s.storage.Open(ctx, renderObject(objectName))
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrPlaybackNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取播放列表失败: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// deleteStreams 删除渲染任务的自适应码率文件
+func (s *service) deleteStreams(ctx context.Context, render *entity.Render) {
+	for _, format := range streamingFormats(render.Streaming) {
+		if err := s.storage.DeletePrefix(ctx, renderObject(streamPrefix(render, format)+"/")); err != nil {
+			fmt.Printf("删除播放文件失败: %v\n", err)
+		}
+	}
+}
+
+// playbackToken 签发渲染任务的播放令牌，格式为 <过期时间>.<HMAC>，只在该渲染任务的子播放列表上有效
+func (s *service) playbackToken(renderID uuid.UUID, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expires + "." + s.signPlayback(renderID, expires)
+}
+
+// validPlaybackToken 校验播放令牌签名且未过期
+func (s *service) validPlaybackToken(renderID uuid.UUID, token string) bool {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signPlayback(renderID, expires)))
+}
+
+func (s *service) signPlayback(renderID uuid.UUID, expires string) string {
+	mac := hmac.New(sha256.New, s.playbackKey)
+	mac.Write([]byte("playback\n" + renderID.String() + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// renderObject 记录中保存的渲染文件路径对应的对象名
+func renderObject(name string) string {
+	return rendersPrefix + name
+}
+
+// playbackURL 渲染结果的稳定播放地址，由 Playback 接口鉴权后返回改写的播放列表
+func playbackURL(render *entity.Render, format, manifestPath string) string {
+	if manifestPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("/api/v1/renders/%s/playback/%s/%s", render.ID, format, path.Base(manifestPath))
+}
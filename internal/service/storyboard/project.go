@@ -0,0 +1,177 @@
+package storyboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/ai"
+	"comic_video/internal/service/render"
+	"comic_video/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// ToProject 将分镜稿转存为可编辑项目
+// 各格图片与配音音轨导入为素材，按最近一次合成的时长生成图片轨道、音频轨道和字幕轨道，
+// 之后可在编辑器中调整并通过渲染服务重新导出；与修改分镜相同，只有提交者和管理员可以转存
+func (s *Service) ToProject(ctx context.Context, taskID, userID, role string, req dto.StoryboardProjectRequest) (*dto.ProjectResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	storyboard, err := s.editable(ctx, taskID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if storyboard.AudioPath == "" {
+		return nil, errors.New("分镜稿尚未合成配音，请先合成视频")
+	}
+	if storyboard.AudioStale {
+		return nil, errors.New("分镜已修改，请先重新合成视频")
+	}
+
+	// 失败时清理已导入的素材
+	var imported []*entity.Material
+	cleanup := func() {
+		for _, m := range imported {
+			_ = s.materialRepo.Delete(ctx, m.ID)
+			_ = s.blobs.Release(ctx, m.ContentHash)
+		}
+	}
+
+	imageTrack := render.Track{Type: "image"}
+	var cursor float64
+	resolution := ""
+	for i := range storyboard.Panels {
+		p := &storyboard.Panels[i]
+		m, err := s.importMaterial(ctx, userUUID, p.ImagePath, fmt.Sprintf("%s 第%d格", req.Name, i+1), "image", "ai_panel", p.Duration)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("导入第%d格图片失败: %w", i+1, err)
+		}
+		imported = append(imported, m)
+		if resolution == "" && m.Width > 0 {
+			resolution = fmt.Sprintf("%dx%d", m.Width, m.Height)
+		}
+		imageTrack.Clips = append(imageTrack.Clips, render.Clip{
+			MaterialID: m.ID.String(),
+			Start:      cursor,
+			End:        cursor + p.Duration,
+		})
+		cursor += p.Duration
+	}
+
+	audio, err := s.importMaterial(ctx, userUUID, storyboard.AudioPath, req.Name+" 配音", "music", "ai_voice", cursor)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("导入配音失败: %w", err)
+	}
+	imported = append(imported, audio)
+
+	config := render.ProjectConfig{
+		Tracks: []render.Track{
+			imageTrack,
+			{Type: "audio", Clips: []render.Clip{{MaterialID: audio.ID.String(), Start: 0, End: cursor}}},
+		},
+		Resolution: resolution,
+		FrameRate:  25,
+	}
+	if req.Subtitles == nil || *req.Subtitles {
+		var segments []ai.AudioSegment
+		_ = json.Unmarshal([]byte(storyboard.Segments), &segments)
+		cues := make([]utils.SubtitleCue, 0, len(segments))
+		for _, seg := range segments {
+			cues = append(cues, utils.SubtitleCue{Start: seg.Start, End: seg.End, Text: seg.Text})
+		}
+		if len(cues) > 0 {
+			config.Tracks = append(config.Tracks, render.SubtitleTrack(cues))
+		}
+	}
+	configJSON, _ := json.Marshal(config)
+
+	project := &entity.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		UserID:      userUUID,
+		Config:      string(configJSON),
+		Status:      "draft",
+		Thumbnail:   imported[0].FilePath,
+		Duration:    int(math.Round(cursor)),
+		Resolution:  resolution,
+	}
+	if err := s.projectRepo.Create(ctx, project); err != nil {
+		cleanup()
+		return nil, err
+	}
+	log.Printf("[Storyboard] 分镜稿已转存为项目: task=%v project=%v panels=%d", storyboard.TaskID, project.ID, len(storyboard.Panels))
+
+	var settings map[string]interface{}
+	_ = json.Unmarshal(configJSON, &settings)
+	return &dto.ProjectResponse{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		UserID:      project.UserID,
+		Status:      project.Status,
+		Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
+		Settings:    settings,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}, nil
+}
+
+// importMaterial 将分镜稿中的对象纳入内容寻址存储并创建素材记录，与其他素材一样按内容去重和引用计数
+// 素材与分镜稿互不影响，之后重绘或重新合成不会改动已转存的项目
+func (s *Service) importMaterial(ctx context.Context, userID uuid.UUID, objectName, name, category, materialType string, duration float64) (*entity.Material, error) {
+	data, err := s.download(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	stored, err := s.blobs.Adopt(ctx, objectName, hex.EncodeToString(sum[:]), int64(len(data)), http.DetectContentType(data))
+	if err != nil {
+		return nil, err
+	}
+	fileName := filepath.Base(objectName)
+	material := &entity.Material{
+		Name:        name,
+		Category:    category,
+		Type:        materialType,
+		FileName:    fileName,
+		FilePath:    stored.ObjectName,
+		ContentHash: stored.Hash,
+		FileSize:    stored.Size,
+		Duration:    duration,
+		Format:      strings.TrimPrefix(filepath.Ext(fileName), "."),
+		Tags:        "ai",
+		IsPublic:    false,
+		OwnerID:     &userID,
+		Status:      entity.MaterialStatusReady,
+	}
+	if category == "image" {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			material.Width, material.Height = cfg.Width, cfg.Height
+		}
+	}
+	if err := s.materialRepo.Create(ctx, material); err != nil {
+		_ = s.blobs.Release(ctx, stored.Hash)
+		return nil, err
+	}
+	return material, nil
+}
@@ -0,0 +1,658 @@
+package storyboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/redis"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/ai"
+	"comic_video/internal/service/blob"
+
+	"github.com/google/uuid"
+)
+
+// Service 分镜稿服务：保存小说转视频任务的分镜与图片，支持逐格修改、重绘、重新合成及转存为项目
+// 同时作为 ai.PanelStore 供生成任务写入分镜
+type Service struct {
+	repo         *postgres.StoryboardRepository
+	materialRepo postgres.MaterialRepository
+	projectRepo  postgres.ProjectRepository
+	storage      storage.BlobStore
+	blobs        *blob.Store
+}
+
+var _ ai.PanelStore = (*Service)(nil)
+
+// ErrForbidden 当前用户不是分镜稿的提交者
+var ErrForbidden = errors.New("无权操作该分镜稿")
+
+func NewService(repo *postgres.StoryboardRepository, materialRepo postgres.MaterialRepository, projectRepo postgres.ProjectRepository, store storage.BlobStore, blobs *blob.Store) *Service {
+	return &Service{repo: repo, materialRepo: materialRepo, projectRepo: projectRepo, storage: store, blobs: blobs}
+}
+
+// PanelImageParams 单格重绘任务参数
+type PanelImageParams struct {
+	TaskID    uuid.UUID       `json:"task_id"`
+	PanelID   uuid.UUID       `json:"panel_id"`
+	Seed      int64           `json:"seed"`
+	Providers json.RawMessage `json:"providers,omitempty"`
+}
+
+// ComposeParams 重新合成任务参数
+type ComposeParams struct {
+	TaskID    uuid.UUID       `json:"task_id"`
+	Voices    *ai.VoiceCast   `json:"voices"`
+	Providers json.RawMessage `json:"providers,omitempty"`
+}
+
+// SavePanels 实现 ai.PanelStore：上传各格图片并保存分镜稿
+func (s *Service) SavePanels(ctx context.Context, task *entity.Task, panels []ai.StoryPanel, seeds []int64, images [][]byte) error {
+	var params struct {
+		Voices    json.RawMessage `json:"voices"`
+		Providers json.RawMessage `json:"providers"`
+		UserID    string          `json:"user_id"`
+	}
+	_ = json.Unmarshal([]byte(task.Params), &params)
+
+	storyboard := &entity.Storyboard{
+		ID:        uuid.New(),
+		TaskID:    task.ID,
+		Voices:    rawJSON(params.Voices),
+		Providers: rawJSON(params.Providers),
+	}
+	if userID, err := uuid.Parse(params.UserID); err == nil {
+		storyboard.UserID = &userID
+	}
+	for i, p := range panels {
+		dialogues, _ := json.Marshal(p.Dialogues)
+		panel := entity.StoryboardPanel{
+			ID:           uuid.New(),
+			StoryboardID: storyboard.ID,
+			Position:     i,
+			Scene:        p.Scene,
+			Narration:    p.Narration,
+			Dialogues:    string(dialogues),
+			Seed:         seeds[i],
+		}
+		path, err := s.uploadImage(ctx, task.ID, panel.ID.String(), images[i])
+		if err != nil {
+			return err
+		}
+		panel.ImagePath = path
+		storyboard.Panels = append(storyboard.Panels, panel)
+	}
+	return s.repo.Create(ctx, storyboard)
+}
+
+// SaveAudio 实现 ai.PanelStore：保存生成任务的配音音轨
+func (s *Service) SaveAudio(ctx context.Context, task *entity.Task, track *ai.AudioTrack) error {
+	storyboard, err := s.repo.GetByTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	return s.saveAudio(ctx, storyboard, track, "audio")
+}
+
+// Get 获取任务的分镜稿
+func (s *Service) Get(ctx context.Context, taskID string) (*dto.StoryboardResponse, error) {
+	storyboard, err := s.get(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toStoryboardResponse(storyboard), nil
+}
+
+// UpdatePanel 修改分镜的画面描述、旁白或台词
+func (s *Service) UpdatePanel(ctx context.Context, taskID, panelID, userID, role string, req dto.UpdatePanelRequest) (*dto.StoryboardPanelResponse, error) {
+	storyboard, panel, err := s.editablePanel(ctx, taskID, panelID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if req.Scene != nil {
+		if *req.Scene == "" {
+			return nil, errors.New("画面描述不能为空")
+		}
+		if *req.Scene != panel.Scene {
+			panel.Scene = *req.Scene
+			panel.Stale = true
+		}
+	}
+	audioChanged := false
+	if req.Narration != nil && *req.Narration != panel.Narration {
+		panel.Narration = *req.Narration
+		audioChanged = true
+	}
+	if req.Dialogues != nil {
+		dialogues, _ := json.Marshal(*req.Dialogues)
+		if string(dialogues) != panel.Dialogues {
+			panel.Dialogues = string(dialogues)
+			audioChanged = true
+		}
+	}
+	err = s.repo.UpdatePanelColumns(ctx, panel.ID, map[string]interface{}{
+		"scene":     panel.Scene,
+		"narration": panel.Narration,
+		"dialogues": panel.Dialogues,
+		"stale":     panel.Stale,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if audioChanged {
+		if err := s.markAudioStale(ctx, storyboard); err != nil {
+			return nil, err
+		}
+	}
+	return s.toPanelResponse(panel), nil
+}
+
+// DeletePanel 删除分镜，至少保留一格
+func (s *Service) DeletePanel(ctx context.Context, taskID, panelID, userID, role string) error {
+	storyboard, panel, err := s.editablePanel(ctx, taskID, panelID, userID, role)
+	if err != nil {
+		return err
+	}
+	if len(storyboard.Panels) <= 1 {
+		return errors.New("分镜稿至少保留一格分镜")
+	}
+	if err := s.repo.DeletePanel(ctx, panel); err != nil {
+		return err
+	}
+	if err := s.markAudioStale(ctx, storyboard); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, panel.ImagePath); err != nil {
+		log.Printf("[Storyboard] 删除分镜图片失败: %v path=%s", err, panel.ImagePath)
+	}
+	return nil
+}
+
+// Reorder 调整分镜顺序，panel_ids 必须恰好包含全部分镜
+func (s *Service) Reorder(ctx context.Context, taskID, userID, role string, req dto.ReorderPanelsRequest) (*dto.StoryboardResponse, error) {
+	storyboard, err := s.editable(ctx, taskID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.PanelIDs) != len(storyboard.Panels) {
+		return nil, errors.New("需提供全部分镜ID")
+	}
+	existing := make(map[uuid.UUID]bool, len(storyboard.Panels))
+	for _, p := range storyboard.Panels {
+		existing[p.ID] = true
+	}
+	for _, id := range req.PanelIDs {
+		if !existing[id] {
+			return nil, errors.New("分镜ID不存在或重复")
+		}
+		delete(existing, id)
+	}
+	if err := s.repo.SetPositions(ctx, storyboard.ID, req.PanelIDs); err != nil {
+		return nil, err
+	}
+	if err := s.markAudioStale(ctx, storyboard); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, taskID)
+}
+
+// NewRegenerateTask 创建单格重绘任务，由调用方写入Redis并入队
+func (s *Service) NewRegenerateTask(ctx context.Context, taskID, panelID, userID, role string, req dto.RegeneratePanelRequest) (*entity.Task, error) {
+	storyboard, panel, err := s.editablePanel(ctx, taskID, panelID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := resolveProviders(storyboard.Providers)
+	if err != nil {
+		return nil, err
+	}
+	if providers.ImageGen == nil {
+		return nil, errors.New("图片生成提供方未配置")
+	}
+	seed := ai.NewSeed()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	params, _ := json.Marshal(PanelImageParams{
+		TaskID:    storyboard.TaskID,
+		PanelID:   panel.ID,
+		Seed:      seed,
+		Providers: json.RawMessage(rawJSON([]byte(storyboard.Providers))),
+	})
+	return newTask(entity.TaskTypePanelImage, params), nil
+}
+
+// NewComposeTask 创建重新合成任务；传入 voices 时替换分镜稿保存的角色音色
+func (s *Service) NewComposeTask(ctx context.Context, taskID, userID, role string, req dto.ComposeStoryboardRequest) (*entity.Task, error) {
+	storyboard, err := s.editable(ctx, taskID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := resolveProviders(storyboard.Providers)
+	if err != nil {
+		return nil, err
+	}
+	if providers.TTS == nil {
+		return nil, errors.New("语音合成提供方未配置")
+	}
+
+	var cast *ai.VoiceCast
+	voices := rawJSON(req.Voices)
+	if voices != "" {
+		if err := json.Unmarshal(req.Voices, &cast); err != nil {
+			return nil, errors.New("音色参数格式错误")
+		}
+		if err := cast.Validate(providers.Names.TTS.Provider); err != nil {
+			return nil, err
+		}
+		storyboard.Voices = voices
+		if err := s.repo.UpdateColumns(ctx, storyboard.ID, map[string]interface{}{"voices": voices}); err != nil {
+			return nil, err
+		}
+	} else if storyboard.Voices != "" {
+		_ = json.Unmarshal([]byte(storyboard.Voices), &cast)
+	}
+
+	params, _ := json.Marshal(ComposeParams{
+		TaskID:    storyboard.TaskID,
+		Voices:    cast,
+		Providers: json.RawMessage(rawJSON([]byte(storyboard.Providers))),
+	})
+	return newTask(entity.TaskTypeStoryboardCompose, params), nil
+}
+
+// ProcessRegenerate 单格重绘任务：按新 seed 出图并替换分镜图片
+// 重绘总是跳过生成缓存，不支持 seed 的提供方也能得到新图
+func (s *Service) ProcessRegenerate(ctx context.Context, task *entity.Task, redisClient *redis.Client, providers *ai.Providers) error {
+	setProgress, fail := taskReporter(ctx, task, redisClient)
+
+	setProgress(5)
+	var params PanelImageParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fail("任务参数错误")
+	}
+	if providers.ImageGen == nil {
+		return fail("图片生成提供方未配置")
+	}
+	_, panel, err := s.getPanel(ctx, params.TaskID.String(), params.PanelID.String())
+	if err != nil {
+		return fail(err.Error())
+	}
+	setProgress(10)
+
+	opts, seed := ai.PanelImageOptions(providers, panel.Scene, params.Seed)
+	img, err := providers.ImageGen.Txt2Img(ai.WithCacheRefresh(ctx), panel.Scene, opts)
+	if err != nil {
+		return fail("图片生成失败: " + err.Error())
+	}
+	setProgress(80)
+
+	path, err := s.uploadImage(ctx, params.TaskID, fmt.Sprintf("%s-%s", panel.ID, task.ID.String()[:8]), img.Data)
+	if err != nil {
+		return fail("上传图片失败: " + err.Error())
+	}
+	oldPath := panel.ImagePath
+	panel.ImagePath = path
+	panel.Seed = seed
+	panel.Stale = false
+	err = s.repo.UpdatePanelColumns(ctx, panel.ID, map[string]interface{}{
+		"image_path": path,
+		"seed":       seed,
+		"stale":      false,
+	})
+	if err != nil {
+		return fail("保存分镜失败: " + err.Error())
+	}
+	if err := s.storage.Delete(ctx, oldPath); err != nil {
+		log.Printf("[Storyboard] 删除旧分镜图片失败: %v path=%s", err, oldPath)
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"panel_id":  panel.ID,
+		"seed":      seed,
+		"image_url": s.storage.GetURL(path),
+	})
+	complete(ctx, task, redisClient, result)
+	log.Printf("[Storyboard] 分镜重绘完成: task=%v panel=%v seed=%d", task.ID, panel.ID, seed)
+	return nil
+}
+
+// ProcessCompose 重新合成任务：按当前分镜顺序配音并合成视频，不重新生成分镜和图片
+// 未修改的台词命中生成缓存，只有改动过的语句会重新配音
+func (s *Service) ProcessCompose(ctx context.Context, task *entity.Task, redisClient *redis.Client, providers *ai.Providers) error {
+	setProgress, fail := taskReporter(ctx, task, redisClient)
+
+	setProgress(5)
+	var params ComposeParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fail("任务参数错误")
+	}
+	if providers.TTS == nil {
+		return fail("语音合成提供方未配置")
+	}
+	storyboard, err := s.get(ctx, params.TaskID.String())
+	if err != nil {
+		return fail(err.Error())
+	}
+	if len(storyboard.Panels) == 0 {
+		return fail("分镜稿为空")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "storyboard_compose_")
+	if err != nil {
+		return fail("创建临时目录失败: " + err.Error())
+	}
+	defer os.RemoveAll(tmpDir)
+
+	panels := make([]ai.StoryPanel, 0, len(storyboard.Panels))
+	images := make([]string, 0, len(storyboard.Panels))
+	for i := range storyboard.Panels {
+		p := &storyboard.Panels[i]
+		data, err := s.download(ctx, p.ImagePath)
+		if err != nil {
+			return fail(fmt.Sprintf("下载第%d格图片失败: %v", i+1, err))
+		}
+		images = append(images, base64.StdEncoding.EncodeToString(data))
+		panels = append(panels, toStoryPanel(p))
+	}
+	setProgress(15)
+
+	track, err := ai.SynthesizeStoryboard(ctx, providers, params.Voices, panels, tmpDir, func(done, total int) {
+		setProgress(15 + int(float64(done)/float64(total)*55))
+	})
+	if err != nil {
+		return fail("配音生成失败: " + err.Error())
+	}
+	setProgress(70)
+
+	videoPath, err := ai.ComposeVideoFromImagesAndAudio(tmpDir, images, track.PanelDurations, track.Path)
+	if err != nil {
+		return fail("视频合成失败: " + err.Error())
+	}
+	setProgress(90)
+
+	video, err := os.ReadFile(videoPath)
+	if err != nil {
+		return fail("读取视频失败: " + err.Error())
+	}
+	objectName := fmt.Sprintf("storyboards/%s/video-%s.mp4", storyboard.TaskID, task.ID)
+	url, err := s.storage.Upload(ctx, objectName, bytes.NewReader(video), int64(len(video)), "video/mp4")
+	if err != nil {
+		return fail("上传视频失败: " + err.Error())
+	}
+	oldPath := storyboard.VideoPath
+	storyboard.VideoPath = objectName
+	if err := s.saveAudio(ctx, storyboard, track, "audio-"+task.ID.String()); err != nil {
+		return fail("保存配音失败: " + err.Error())
+	}
+	if oldPath != "" {
+		if err := s.storage.Delete(ctx, oldPath); err != nil {
+			log.Printf("[Storyboard] 删除旧视频失败: %v path=%s", err, oldPath)
+		}
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"url":      url,
+		"panels":   panels,
+		"segments": track.Segments,
+	})
+	complete(ctx, task, redisClient, result)
+	log.Printf("[Storyboard] 重新合成完成: task=%v storyboard=%v panels=%d", task.ID, storyboard.ID, len(panels))
+	return nil
+}
+
+// saveAudio 上传配音音轨并记录每格时长，同时保存合成的视频地址
+func (s *Service) saveAudio(ctx context.Context, storyboard *entity.Storyboard, track *ai.AudioTrack, name string) error {
+	audio, err := os.ReadFile(track.Path)
+	if err != nil {
+		return err
+	}
+	objectName := fmt.Sprintf("storyboards/%s/%s.wav", storyboard.TaskID, name)
+	if _, err := s.storage.Upload(ctx, objectName, bytes.NewReader(audio), int64(len(audio)), "audio/wav"); err != nil {
+		return err
+	}
+	for i := range storyboard.Panels {
+		if i < len(track.PanelDurations) {
+			storyboard.Panels[i].Duration = track.PanelDurations[i]
+			err := s.repo.UpdatePanelColumns(ctx, storyboard.Panels[i].ID, map[string]interface{}{"duration": track.PanelDurations[i]})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	segments, _ := json.Marshal(track.Segments)
+	oldPath := storyboard.AudioPath
+	storyboard.AudioPath = objectName
+	storyboard.Segments = string(segments)
+	// 合成期间用户修改了台词或顺序时 updated_at 已变化，配音仍标记为过期
+	err = s.repo.SaveComposition(ctx, storyboard.ID, storyboard.UpdatedAt, map[string]interface{}{
+		"video_path": storyboard.VideoPath,
+		"audio_path": storyboard.AudioPath,
+		"segments":   storyboard.Segments,
+	})
+	if err != nil {
+		return err
+	}
+	if oldPath != "" && oldPath != objectName {
+		if err := s.storage.Delete(ctx, oldPath); err != nil {
+			log.Printf("[Storyboard] 删除旧配音失败: %v path=%s", err, oldPath)
+		}
+	}
+	return nil
+}
+
+// markAudioStale 旁白、台词或分镜顺序变更后，已保存的配音与分镜不再对应
+func (s *Service) markAudioStale(ctx context.Context, storyboard *entity.Storyboard) error {
+	// 已过期时也要刷新 updated_at，让进行中的合成知道分镜稿又被修改过
+	storyboard.AudioStale = true
+	return s.repo.UpdateColumns(ctx, storyboard.ID, map[string]interface{}{"audio_stale": true})
+}
+
+func (s *Service) get(ctx context.Context, taskID string) (*entity.Storyboard, error) {
+	taskUUID, err := uuid.Parse(taskID)
+	if err != nil {
+		return nil, errors.New("无效的任务ID")
+	}
+	storyboard, err := s.repo.GetByTask(ctx, taskUUID)
+	if err != nil {
+		return nil, errors.New("分镜稿不存在")
+	}
+	return storyboard, nil
+}
+
+// editable 获取可修改的分镜稿
+func (s *Service) editable(ctx context.Context, taskID, userID, role string) (*entity.Storyboard, error) {
+	storyboard, err := s.get(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEditor(storyboard, userID, role); err != nil {
+		return nil, err
+	}
+	return storyboard, nil
+}
+
+// editablePanel 获取可修改的分镜稿中的单格分镜
+func (s *Service) editablePanel(ctx context.Context, taskID, panelID, userID, role string) (*entity.Storyboard, *entity.StoryboardPanel, error) {
+	storyboard, panel, err := s.getPanel(ctx, taskID, panelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkEditor(storyboard, userID, role); err != nil {
+		return nil, nil, err
+	}
+	return storyboard, panel, nil
+}
+
+// checkEditor 提交者和管理员可以修改，匿名任务的分镜稿只有管理员可以修改
+func checkEditor(storyboard *entity.Storyboard, userID, role string) error {
+	if role == entity.RoleAdmin {
+		return nil
+	}
+	if storyboard.UserID == nil || storyboard.UserID.String() != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *Service) getPanel(ctx context.Context, taskID, panelID string) (*entity.Storyboard, *entity.StoryboardPanel, error) {
+	panelUUID, err := uuid.Parse(panelID)
+	if err != nil {
+		return nil, nil, errors.New("无效的分镜ID")
+	}
+	storyboard, err := s.get(ctx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range storyboard.Panels {
+		if storyboard.Panels[i].ID == panelUUID {
+			return storyboard, &storyboard.Panels[i], nil
+		}
+	}
+	return nil, nil, errors.New("分镜不存在")
+}
+
+// uploadImage 上传分镜图片，对象名为 storyboards/<任务ID>/<name>.<ext>
+func (s *Service) uploadImage(ctx context.Context, taskID uuid.UUID, name string, data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	ext := ".png"
+	switch contentType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	}
+	objectName := fmt.Sprintf("storyboards/%s/%s%s", taskID, name, ext)
+	if _, err := s.storage.Upload(ctx, objectName, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+	return objectName, nil
+}
+
+// download 从存储读取对象
+func (s *Service) download(ctx context.Context, object string) ([]byte, error) {
+	reader, err := s.storage.Open(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// resolveProviders 按分镜稿保存的提供方覆盖解析AI能力
+func resolveProviders(raw string) (*ai.Providers, error) {
+	var overrides ai.ProviderOverrides
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &overrides)
+	}
+	return ai.ResolveProviders(overrides)
+}
+
+func newTask(taskType string, params []byte) *entity.Task {
+	return &entity.Task{
+		ID:        uuid.New(),
+		Type:      taskType,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// taskReporter 任务进度与失败上报
+func taskReporter(ctx context.Context, task *entity.Task, redisClient *redis.Client) (func(int), func(string) error) {
+	setProgress := func(progress int) {
+		task.Status = entity.TaskStatusProcessing
+		task.Progress = progress
+		task.UpdatedAt = time.Now()
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	}
+	fail := func(msg string) error {
+		task.Status = entity.TaskStatusFailed
+		task.Error = msg
+		task.UpdatedAt = time.Now()
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+		log.Printf("[Storyboard] 任务失败: task=%v %s", task.ID, msg)
+		return errors.New(msg)
+	}
+	return setProgress, fail
+}
+
+func complete(ctx context.Context, task *entity.Task, redisClient *redis.Client, result []byte) {
+	task.Status = entity.TaskStatusCompleted
+	task.Progress = 100
+	task.Result = string(result)
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+}
+
+// rawJSON 原始JSON转字符串，null 视为空
+func rawJSON(raw []byte) string {
+	if s := string(bytes.TrimSpace(raw)); s != "null" {
+		return s
+	}
+	return ""
+}
+
+func toStoryPanel(p *entity.StoryboardPanel) ai.StoryPanel {
+	panel := ai.StoryPanel{Scene: p.Scene, Narration: p.Narration}
+	if p.Dialogues != "" {
+		_ = json.Unmarshal([]byte(p.Dialogues), &panel.Dialogues)
+	}
+	return panel
+}
+
+func (s *Service) toPanelResponse(p *entity.StoryboardPanel) *dto.StoryboardPanelResponse {
+	resp := &dto.StoryboardPanelResponse{
+		ID:        p.ID,
+		Position:  p.Position,
+		Scene:     p.Scene,
+		Narration: p.Narration,
+		Dialogues: []dto.PanelDialogue{},
+		Seed:      p.Seed,
+		ImageURL:  s.storage.GetURL(p.ImagePath),
+		Stale:     p.Stale,
+		Duration:  p.Duration,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if p.Dialogues != "" {
+		_ = json.Unmarshal([]byte(p.Dialogues), &resp.Dialogues)
+	}
+	return resp
+}
+
+func (s *Service) toStoryboardResponse(sb *entity.Storyboard) *dto.StoryboardResponse {
+	resp := &dto.StoryboardResponse{
+		ID:        sb.ID,
+		TaskID:    sb.TaskID,
+		Panels:    make([]*dto.StoryboardPanelResponse, 0, len(sb.Panels)),
+		CreatedAt: sb.CreatedAt,
+		UpdatedAt: sb.UpdatedAt,
+	}
+	if sb.Voices != "" {
+		resp.Voices = json.RawMessage(sb.Voices)
+	}
+	if sb.VideoPath != "" {
+		resp.VideoURL = s.storage.GetURL(sb.VideoPath)
+	}
+	if sb.AudioPath != "" {
+		resp.AudioURL = s.storage.GetURL(sb.AudioPath)
+	}
+	resp.AudioStale = sb.AudioStale
+	for i := range sb.Panels {
+		resp.Panels = append(resp.Panels, s.toPanelResponse(&sb.Panels[i]))
+	}
+	return resp
+}
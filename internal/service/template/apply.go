@@ -0,0 +1,186 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidValues 模板取值不合法或目标项目不可用，属于调用方错误
+var ErrInvalidValues = errors.New("模板参数不合法")
+
+// Apply 应用模板：合并用户取值生成项目配置，新建项目或覆盖已有项目的配置
+func (s *Service) Apply(ctx context.Context, id, userID, role string, req dto.ApplyTemplateRequest) (*dto.ApplyTemplateResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	template, err := s.visible(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseConfig(template.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var project *entity.Project
+	if req.ProjectID != nil {
+		project, err = s.projectRepo.GetByID(ctx, *req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 项目不存在", ErrInvalidValues)
+		}
+		if project.UserID != userUUID {
+			return nil, fmt.Errorf("%w: 无权修改该项目", ErrInvalidValues)
+		}
+	}
+
+	values, err := s.resolveValues(ctx, cfg, req.Values, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidValues, err)
+	}
+	config := cfg.fill(values)
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	duration := projectDuration(config.Tracks)
+
+	if project == nil {
+		name := req.Name
+		if name == "" {
+			name = template.Name
+		}
+		project = &entity.Project{
+			Name:        name,
+			Description: req.Description,
+			UserID:      userUUID,
+			Status:      "draft",
+		}
+	} else {
+		if req.Name != "" {
+			project.Name = req.Name
+		}
+		if req.Description != "" {
+			project.Description = req.Description
+		}
+	}
+	project.TemplateID = &template.ID
+	project.Config = string(configJSON)
+	project.Duration = int(math.Round(duration))
+	project.Resolution = config.Resolution
+	// 与模板一样保存封面对象名，返回项目时再转换为访问地址
+	if project.Thumbnail == "" {
+		project.Thumbnail = template.Thumbnail
+	}
+
+	if project.ID == uuid.Nil {
+		err = s.projectRepo.Create(ctx, project)
+	} else {
+		err = s.projectRepo.Update(ctx, project)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RecordApply(ctx, &entity.TemplateApply{
+		TemplateID: template.ID,
+		UserID:     userUUID,
+		ProjectID:  project.ID,
+	}); err != nil {
+		log.Printf("[Template] 记录模板使用失败: %v template=%v", err, template.ID)
+	}
+
+	var settings map[string]interface{}
+	_ = json.Unmarshal(configJSON, &settings)
+	return &dto.ApplyTemplateResponse{
+		Success: true,
+		Message: "模板应用成功",
+		Project: &dto.ProjectResponse{
+			ID:          project.ID,
+			Name:        project.Name,
+			Description: project.Description,
+			UserID:      project.UserID,
+			Status:      project.Status,
+			Thumbnail:   storage.ResolveURL(s.storage, project.Thumbnail),
+			Settings:    settings,
+			CreatedAt:   project.CreatedAt,
+			UpdatedAt:   project.UpdatedAt,
+		},
+	}, nil
+}
+
+// resolveValues 合并默认值并逐项校验，返回占位符到最终取值的映射
+// 媒体素材须为公开素材或 userID 本人的素材，userID 为空时只允许公开素材
+func (s *Service) resolveValues(ctx context.Context, cfg *Config, input map[string]interface{}, userID uuid.UUID) (map[string]string, error) {
+	defined := make(map[string]bool, len(cfg.Placeholders))
+	for _, p := range cfg.Placeholders {
+		defined[p.Key] = true
+	}
+	for key := range input {
+		if !defined[key] {
+			return nil, fmt.Errorf("模板中没有占位符 %s", key)
+		}
+	}
+
+	spans := cfg.clipSpans()
+	values := make(map[string]string, len(cfg.Placeholders))
+	for i := range cfg.Placeholders {
+		p := &cfg.Placeholders[i]
+		raw, ok := input[p.Key]
+		if !ok || raw == nil {
+			raw = p.Default
+		}
+		value, isString := raw.(string)
+		if raw != nil && !isString {
+			return nil, fmt.Errorf("%s 的取值必须为字符串", p.label())
+		}
+		if value == "" {
+			if p.Required {
+				return nil, fmt.Errorf("缺少必填项: %s", p.label())
+			}
+			values[p.Key] = ""
+			continue
+		}
+
+		if _, media := mediaCategories[p.Type]; media {
+			materialID, err := uuid.Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s 的素材ID无效", p.label())
+			}
+			material, err := s.materialRepo.GetByID(ctx, materialID)
+			if err != nil || !material.VisibleTo(userID) {
+				return nil, fmt.Errorf("%s 的素材不存在", p.label())
+			}
+			if !material.Usable() {
+				return nil, fmt.Errorf("%s 的素材不可用", p.label())
+			}
+			if err := validateMaterial(p, material, spans[p.Key]); err != nil {
+				return nil, err
+			}
+		} else if err := validateValue(p, value); err != nil {
+			return nil, err
+		}
+		values[p.Key] = value
+	}
+
+	// 未填写的可选媒体槽位不产生片段，成片时长按剩余片段校验
+	if cfg.Duration != nil {
+		total := filledDuration(cfg, values)
+		if cfg.Duration.Min > 0 && total < cfg.Duration.Min {
+			return nil, fmt.Errorf("成片时长%.1f秒，不能少于%.1f秒", total, cfg.Duration.Min)
+		}
+		if cfg.Duration.Max > 0 && total > cfg.Duration.Max {
+			return nil, fmt.Errorf("成片时长%.1f秒，不能超过%.1f秒", total, cfg.Duration.Max)
+		}
+	}
+	return values, nil
+}
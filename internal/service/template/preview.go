@@ -0,0 +1,140 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// 模板预览渲染状态
+const (
+	PreviewPending    = "pending"
+	PreviewProcessing = "processing"
+	PreviewCompleted  = "completed"
+	PreviewFailed     = "failed"
+)
+
+// previewParams 预览渲染任务参数
+type previewParams struct {
+	TemplateID uuid.UUID `json:"template_id"`
+}
+
+// sampleColor 未设置默认值的颜色占位符在预览中使用的颜色
+const sampleColor = "#ffffff"
+
+// requestPreview 提交模板预览渲染；未配置渲染服务时跳过
+func (s *Service) requestPreview(ctx context.Context, template *entity.Template) {
+	if s.renderer == nil || s.previewQueue == nil {
+		return
+	}
+	params, _ := json.Marshal(previewParams{TemplateID: template.ID})
+	task := &entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeTemplatePreview,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.UpdatePreview(ctx, template.ID, map[string]interface{}{"preview_status": PreviewPending}); err != nil {
+		log.Printf("[Template] 更新预览状态失败: %v template=%v", err, template.ID)
+	}
+	template.PreviewStatus = PreviewPending
+	_ = s.previewQueue.Enqueue(task)
+}
+
+// ProcessPreview 预览渲染任务：用示例素材填充占位符，低分辨率渲染后抽取封面和预览动图写回模板
+func (s *Service) ProcessPreview(ctx context.Context, task *entity.Task) error {
+	var params previewParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fmt.Errorf("任务参数错误: %w", err)
+	}
+	fail := func(err error) error {
+		log.Printf("[Template] 预览渲染失败: %v template=%v", err, params.TemplateID)
+		_ = s.repo.UpdatePreview(ctx, params.TemplateID, map[string]interface{}{"preview_status": PreviewFailed})
+		return err
+	}
+
+	template, err := s.repo.GetByID(ctx, params.TemplateID)
+	if err != nil {
+		return fail(err)
+	}
+	_ = s.repo.UpdatePreview(ctx, template.ID, map[string]interface{}{"preview_status": PreviewProcessing})
+
+	cfg, err := ParseConfig(template.Config)
+	if err != nil {
+		return fail(err)
+	}
+	samples, err := s.sampleValues(ctx, cfg)
+	if err != nil {
+		return fail(err)
+	}
+	values, err := s.resolveValues(ctx, cfg, samples, uuid.Nil)
+	if err != nil {
+		return fail(fmt.Errorf("示例取值不满足模板约束: %w", err))
+	}
+
+	result, err := s.renderer.RenderPreview(ctx, cfg.fill(values), "templates/"+template.ID.String())
+	if err != nil {
+		return fail(err)
+	}
+	if err := s.repo.UpdatePreview(ctx, template.ID, map[string]interface{}{
+		"thumbnail":      result.ThumbnailPath,
+		"preview":        result.PreviewPath,
+		"preview_status": PreviewCompleted,
+	}); err != nil {
+		return fail(err)
+	}
+	log.Printf("[Template] 预览渲染完成: template=%v duration=%.1fs", template.ID, result.Duration)
+	return nil
+}
+
+// sampleValues 预览用的占位符取值：优先使用默认值，媒体槽位没有默认值时选取同类公开素材
+func (s *Service) sampleValues(ctx context.Context, cfg *Config) (map[string]interface{}, error) {
+	spans := cfg.clipSpans()
+	samples := make(map[string]interface{}, len(cfg.Placeholders))
+	for i := range cfg.Placeholders {
+		p := &cfg.Placeholders[i]
+		if v, _ := p.Default.(string); v != "" {
+			continue
+		}
+		switch p.Type {
+		case PlaceholderText:
+			samples[p.Key] = p.label()
+		case PlaceholderColor:
+			samples[p.Key] = sampleColor
+		default:
+			material, err := s.sampleMaterial(ctx, p, spans[p.Key])
+			if err != nil {
+				return nil, err
+			}
+			if material != nil {
+				samples[p.Key] = material.ID.String()
+			}
+		}
+	}
+	return samples, nil
+}
+
+// sampleMaterial 选取满足槽位分类和时长要求的公开素材，没有合适素材时返回 nil
+func (s *Service) sampleMaterial(ctx context.Context, p *Placeholder, span float64) (*entity.Material, error) {
+	materials, _, err := s.materialRepo.List(ctx, 0, 20, map[string]interface{}{
+		"category":  mediaCategories[p.Type],
+		"is_public": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range materials {
+		if m.Usable() && validateMaterial(p, m, span) == nil {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+package template
+
+import (
+	"context"
+	"errors"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Rate 对模板评分，同一用户重复评分覆盖上一次的分数和评价
+func (s *Service) Rate(ctx context.Context, id, userID, role string, req dto.RateTemplateRequest) (*dto.TemplateRatingResponse, error) {
+	templateUUID, userUUID, err := parseRatingIDs(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.visible(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if template.ReviewStatus != entity.TemplateReviewApproved {
+		return nil, errors.New("模板未审核通过，暂不能评分")
+	}
+	err = s.repo.SaveRating(ctx, &entity.TemplateRating{
+		TemplateID: templateUUID,
+		UserID:     userUUID,
+		Score:      req.Score,
+		Review:     req.Review,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rating, err := s.repo.GetRating(ctx, templateUUID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	return toRatingResponse(rating), nil
+}
+
+// GetMyRating 获取当前用户对模板的评分
+func (s *Service) GetMyRating(ctx context.Context, id, userID string) (*dto.TemplateRatingResponse, error) {
+	templateUUID, userUUID, err := parseRatingIDs(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	rating, err := s.repo.GetRating(ctx, templateUUID, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("尚未评分")
+		}
+		return nil, err
+	}
+	return toRatingResponse(rating), nil
+}
+
+// DeleteRating 撤销当前用户对模板的评分
+func (s *Service) DeleteRating(ctx context.Context, id, userID string) error {
+	templateUUID, userUUID, err := parseRatingIDs(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteRating(ctx, templateUUID, userUUID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("尚未评分")
+		}
+		return err
+	}
+	return nil
+}
+
+// ListRatings 获取模板的评价列表，与模板详情一样仅对可见的模板开放
+func (s *Service) ListRatings(ctx context.Context, id, userID, role string, req dto.ListTemplateRatingsRequest) ([]*dto.TemplateRatingResponse, int64, error) {
+	template, err := s.visible(ctx, id, userID, role)
+	if err != nil {
+		return nil, 0, err
+	}
+	ratings, total, err := s.repo.ListRatings(ctx, template.ID, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	responses := make([]*dto.TemplateRatingResponse, 0, len(ratings))
+	for _, r := range ratings {
+		responses = append(responses, toRatingResponse(r))
+	}
+	return responses, total, nil
+}
+
+func parseRatingIDs(id, userID string) (uuid.UUID, uuid.UUID, error) {
+	templateUUID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("无效的模板ID")
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("无效的用户ID")
+	}
+	return templateUUID, userUUID, nil
+}
+
+func toRatingResponse(r *entity.TemplateRating) *dto.TemplateRatingResponse {
+	return &dto.TemplateRatingResponse{
+		ID:         r.ID,
+		TemplateID: r.TemplateID,
+		UserID:     r.UserID,
+		Username:   r.User.Username,
+		Nickname:   r.User.Nickname,
+		Score:      r.Score,
+		Review:     r.Review,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+}
@@ -0,0 +1,206 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrForbidden 当前用户无权查看或修改该模板
+	ErrForbidden = errors.New("无权操作该模板")
+	// ErrNotFound 模板不存在，或对当前用户不可见
+	ErrNotFound = errors.New("模板不存在")
+)
+
+// actor 当前操作用户，匿名访问时 id 为空
+type actor struct {
+	id    uuid.UUID
+	admin bool
+}
+
+// parseActor 解析当前用户，userID 为空时视为匿名
+func parseActor(userID, role string) (actor, error) {
+	if userID == "" {
+		return actor{}, nil
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return actor{}, errors.New("无效的用户ID")
+	}
+	return actor{id: id, admin: role == entity.RoleAdmin}, nil
+}
+
+// owns 作者或管理员
+func (a actor) owns(t *entity.Template) bool {
+	if a.admin {
+		return true
+	}
+	return a.id != uuid.Nil && t.OwnerID != nil && *t.OwnerID == a.id
+}
+
+// canView 审核通过的公开模板所有人可见，其余仅作者和管理员可见
+func (a actor) canView(t *entity.Template) bool {
+	if t.ReviewStatus == entity.TemplateReviewApproved && t.IsPublic {
+		return true
+	}
+	return a.owns(t)
+}
+
+// visible 获取当前用户可见的模板，不可见时按不存在处理，避免暴露未发布模板
+func (s *Service) visible(ctx context.Context, id, userID, role string) (*entity.Template, error) {
+	user, err := parseActor(userID, role)
+	if err != nil {
+		return nil, err
+	}
+	templateUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	template, err := s.repo.GetByID(ctx, templateUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.canView(template) {
+		return nil, ErrNotFound
+	}
+	return template, nil
+}
+
+// editable 获取当前用户可修改的模板
+func (s *Service) editable(ctx context.Context, id, userID, role string) (*entity.Template, actor, error) {
+	user, err := parseActor(userID, role)
+	if err != nil {
+		return nil, actor{}, err
+	}
+	templateUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, actor{}, ErrNotFound
+	}
+	template, err := s.repo.GetByID(ctx, templateUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, actor{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, actor{}, err
+	}
+	if !user.owns(template) {
+		return nil, actor{}, ErrForbidden
+	}
+	return template, user, nil
+}
+
+// ListMine 获取当前用户创建的模板，包含各审核状态
+func (s *Service) ListMine(ctx context.Context, userID string, req dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, 0, errors.New("无效的用户ID")
+	}
+	filter := map[string]interface{}{"owner_id": userUUID}
+	if req.ReviewStatus != "" {
+		filter["review_status"] = req.ReviewStatus
+	}
+	return s.listBy(ctx, filter, req)
+}
+
+// ListForReview 管理员查看待审核等状态的模板，默认列出已提交待审核的模板
+func (s *Service) ListForReview(ctx context.Context, req dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
+	status := req.ReviewStatus
+	if status == "" {
+		status = entity.TemplateReviewSubmitted
+	}
+	return s.listBy(ctx, map[string]interface{}{"review_status": status}, req)
+}
+
+func (s *Service) listBy(ctx context.Context, filter map[string]interface{}, req dto.ListMyTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
+	templates, total, err := s.repo.List(ctx, (req.Page-1)*req.PageSize, req.PageSize, filter, "", "")
+	if err != nil {
+		return nil, 0, err
+	}
+	responses := make([]*dto.TemplateResponse, 0, len(templates))
+	for _, t := range templates {
+		responses = append(responses, s.templateResponse(t))
+	}
+	return responses, total, nil
+}
+
+// Submit 作者提交模板审核，草稿和被驳回的模板可提交
+func (s *Service) Submit(ctx context.Context, id, userID, role string) (*dto.TemplateResponse, error) {
+	template, _, err := s.editable(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	switch template.ReviewStatus {
+	case entity.TemplateReviewDraft, entity.TemplateReviewRejected:
+	case entity.TemplateReviewSubmitted:
+		return nil, errors.New("模板已在审核中")
+	default:
+		return nil, errors.New("模板已审核通过，修改后才能重新提交")
+	}
+	now := time.Now()
+	template.ReviewStatus = entity.TemplateReviewSubmitted
+	template.SubmittedAt = &now
+	template.ReviewNote = ""
+	if err := s.repo.UpdateFields(ctx, template.ID, map[string]interface{}{
+		"review_status": template.ReviewStatus,
+		"submitted_at":  template.SubmittedAt,
+		"review_note":   template.ReviewNote,
+	}); err != nil {
+		return nil, err
+	}
+	return s.templateResponse(template), nil
+}
+
+// Review 管理员审核模板：通过后进入公开列表，驳回需填写原因
+func (s *Service) Review(ctx context.Context, id, reviewerID string, req dto.ReviewTemplateRequest) (*dto.TemplateResponse, error) {
+	reviewer, err := uuid.Parse(reviewerID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	templateUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("无效的模板ID")
+	}
+	template, err := s.repo.GetByID(ctx, templateUUID)
+	if err != nil {
+		return nil, err
+	}
+	if template.ReviewStatus != entity.TemplateReviewSubmitted {
+		return nil, errors.New("模板未提交审核")
+	}
+
+	switch req.Action {
+	case "approve":
+		template.ReviewStatus = entity.TemplateReviewApproved
+		template.ReviewNote = req.Note
+	case "reject":
+		if req.Note == "" {
+			return nil, errors.New("驳回时请填写原因")
+		}
+		template.ReviewStatus = entity.TemplateReviewRejected
+		template.ReviewNote = req.Note
+	}
+	now := time.Now()
+	template.ReviewedBy = &reviewer
+	template.ReviewedAt = &now
+	if err := s.repo.UpdateFields(ctx, template.ID, map[string]interface{}{
+		"review_status": template.ReviewStatus,
+		"review_note":   template.ReviewNote,
+		"reviewed_by":   template.ReviewedBy,
+		"reviewed_at":   template.ReviewedAt,
+	}); err != nil {
+		return nil, err
+	}
+	log.Printf("[Template] 模板审核: template=%v status=%s reviewer=%v", template.ID, template.ReviewStatus, reviewer)
+	return s.templateResponse(template), nil
+}
@@ -0,0 +1,286 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/render"
+)
+
+// 占位符类型
+const (
+	PlaceholderImage = "image" // 图片素材
+	PlaceholderVideo = "video" // 视频素材
+	PlaceholderAudio = "audio" // 音频素材（素材分类 music）
+	PlaceholderText  = "text"  // 文本，如标题、字幕
+	PlaceholderColor = "color" // 颜色变量，#RRGGBB 或 #RGB
+)
+
+// Placeholder 模板中可替换的槽位，在轨道中以 {{key}} 引用
+// 媒体槽位写在 clip.material_id，文本写在 clip.text，颜色写在 effect 参数中
+type Placeholder struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"`
+	Label       string      `json:"label,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`      // 未填写时使用；媒体槽位为示例素材ID
+	MaxLength   int         `json:"max_length,omitempty"`   // 文本最大字数
+	MinDuration float64     `json:"min_duration,omitempty"` // 视频/音频素材最短时长（秒）
+	MaxDuration float64     `json:"max_duration,omitempty"` // 视频/音频素材最长时长（秒）
+}
+
+// DurationRange 成片时长约束（秒），0 表示不限
+type DurationRange struct {
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+}
+
+// Config 模板配置：在 render.ProjectConfig 的轨道基础上增加占位符定义与时长约束
+type Config struct {
+	Resolution   string         `json:"resolution,omitempty"`
+	FrameRate    int            `json:"frame_rate,omitempty"`
+	Duration     *DurationRange `json:"duration,omitempty"`
+	Placeholders []Placeholder  `json:"placeholders"`
+	Tracks       []render.Track `json:"tracks"`
+}
+
+var (
+	placeholderRef = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_\-]+)\s*\}\}`)
+	colorValue     = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+// mediaCategories 媒体槽位对应的素材分类
+var mediaCategories = map[string]string{
+	PlaceholderImage: "image",
+	PlaceholderVideo: "video",
+	PlaceholderAudio: "music",
+}
+
+// ParseConfig 解析并校验模板配置
+func ParseConfig(raw string) (*Config, error) {
+	var cfg Config
+	if raw == "" {
+		return nil, fmt.Errorf("模板配置为空")
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return nil, fmt.Errorf("模板配置格式错误: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate 校验占位符定义及轨道中的引用
+func (c *Config) Validate() error {
+	if len(c.Tracks) == 0 {
+		return fmt.Errorf("模板至少需要一条轨道")
+	}
+	defined := make(map[string]*Placeholder, len(c.Placeholders))
+	for i := range c.Placeholders {
+		p := &c.Placeholders[i]
+		if key, ok := wholeRef("{{" + p.Key + "}}"); !ok || key != p.Key {
+			return fmt.Errorf("占位符名称无效: %q", p.Key)
+		}
+		if _, ok := defined[p.Key]; ok {
+			return fmt.Errorf("占位符重复: %s", p.Key)
+		}
+		switch p.Type {
+		case PlaceholderImage, PlaceholderVideo, PlaceholderAudio, PlaceholderText, PlaceholderColor:
+		default:
+			return fmt.Errorf("占位符 %s 类型无效: %s", p.Key, p.Type)
+		}
+		if p.MaxDuration > 0 && p.MinDuration > p.MaxDuration {
+			return fmt.Errorf("占位符 %s 时长范围无效", p.Key)
+		}
+		if p.Default != nil {
+			if _, ok := p.Default.(string); !ok {
+				return fmt.Errorf("占位符 %s 默认值必须为字符串", p.Key)
+			}
+		}
+		defined[p.Key] = p
+	}
+	if c.Duration != nil && c.Duration.Max > 0 && c.Duration.Min > c.Duration.Max {
+		return fmt.Errorf("模板时长范围无效")
+	}
+
+	for _, track := range c.Tracks {
+		for j, clip := range track.Clips {
+			if track.Type != render.TrackTypeSubtitle && clip.End <= clip.Start {
+				return fmt.Errorf("%s 轨道第%d个片段时间无效", track.Type, j+1)
+			}
+			if key, ok := wholeRef(clip.MaterialID); ok {
+				p, ok := defined[key]
+				if !ok {
+					return fmt.Errorf("引用了未定义的占位符: %s", key)
+				}
+				if _, media := mediaCategories[p.Type]; !media {
+					return fmt.Errorf("占位符 %s 不是媒体类型，不能用作素材", key)
+				}
+			}
+			for _, ref := range textRefs(clip) {
+				if _, ok := defined[ref]; !ok {
+					return fmt.Errorf("引用了未定义的占位符: %s", ref)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TotalDuration 成片时长：各轨道片段结束时间的最大值
+func (c *Config) TotalDuration() float64 {
+	return projectDuration(c.Tracks)
+}
+
+func projectDuration(tracks []render.Track) float64 {
+	var total float64
+	for _, track := range tracks {
+		for _, clip := range track.Clips {
+			if clip.End > total {
+				total = clip.End
+			}
+		}
+	}
+	return total
+}
+
+// filledDuration 按取值填充后的成片时长
+func filledDuration(c *Config, values map[string]string) float64 {
+	cfg := c.fill(values)
+	return projectDuration(cfg.Tracks)
+}
+
+// clipSpans 每个媒体占位符被引用的最长片段时长
+func (c *Config) clipSpans() map[string]float64 {
+	spans := map[string]float64{}
+	for _, track := range c.Tracks {
+		for _, clip := range track.Clips {
+			if key, ok := wholeRef(clip.MaterialID); ok {
+				if d := clip.End - clip.Start; d > spans[key] {
+					spans[key] = d
+				}
+			}
+		}
+	}
+	return spans
+}
+
+// fill 用已校验的取值替换占位符，得到可直接渲染的项目配置
+// 未填写的可选媒体槽位对应的片段会被移除，没有片段的轨道随之移除
+func (c *Config) fill(values map[string]string) render.ProjectConfig {
+	replace := func(s string) string {
+		return placeholderRef.ReplaceAllStringFunc(s, func(m string) string {
+			return values[placeholderRef.FindStringSubmatch(m)[1]]
+		})
+	}
+	out := render.ProjectConfig{
+		Resolution: c.Resolution,
+		FrameRate:  c.FrameRate,
+		Tracks:     make([]render.Track, 0, len(c.Tracks)),
+	}
+	for _, track := range c.Tracks {
+		t := render.Track{Type: track.Type, Clips: make([]render.Clip, 0, len(track.Clips))}
+		for _, clip := range track.Clips {
+			if key, ok := wholeRef(clip.MaterialID); ok && values[key] == "" {
+				continue
+			}
+			clip.MaterialID = replace(clip.MaterialID)
+			clip.Text = replace(clip.Text)
+			effects := make([]render.Effect, 0, len(clip.Effects))
+			for _, eff := range clip.Effects {
+				params := make(map[string]interface{}, len(eff.Params))
+				for k, v := range eff.Params {
+					if s, ok := v.(string); ok {
+						v = replace(s)
+					}
+					params[k] = v
+				}
+				eff.Params = params
+				effects = append(effects, eff)
+			}
+			clip.Effects = effects
+			t.Clips = append(t.Clips, clip)
+		}
+		if len(t.Clips) > 0 {
+			out.Tracks = append(out.Tracks, t)
+		}
+	}
+	return out
+}
+
+// validateValue 校验非媒体占位符的取值，媒体素材由 Service 查库校验
+func validateValue(p *Placeholder, value string) error {
+	switch p.Type {
+	case PlaceholderText:
+		if p.MaxLength > 0 && utf8.RuneCountInString(value) > p.MaxLength {
+			return fmt.Errorf("%s 不能超过%d个字", p.label(), p.MaxLength)
+		}
+	case PlaceholderColor:
+		if !colorValue.MatchString(value) {
+			return fmt.Errorf("%s 颜色格式无效，应为 #RRGGBB", p.label())
+		}
+	}
+	return nil
+}
+
+// validateMaterial 校验媒体素材的分类与时长
+func validateMaterial(p *Placeholder, m *entity.Material, span float64) error {
+	if m.Category != mediaCategories[p.Type] {
+		return fmt.Errorf("%s 需要%s素材，实际为%s", p.label(), mediaCategories[p.Type], m.Category)
+	}
+	if p.Type == PlaceholderImage || m.Duration <= 0 {
+		return nil
+	}
+	min := p.MinDuration
+	if span > min {
+		min = span
+	}
+	if m.Duration < min {
+		return fmt.Errorf("%s 素材时长不足，至少需要%.1f秒", p.label(), min)
+	}
+	if p.MaxDuration > 0 && m.Duration > p.MaxDuration {
+		return fmt.Errorf("%s 素材时长不能超过%.1f秒", p.label(), p.MaxDuration)
+	}
+	return nil
+}
+
+func (p *Placeholder) label() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Key
+}
+
+// wholeRef 判断字符串是否恰好为单个占位符引用
+func wholeRef(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	m := placeholderRef.FindStringSubmatch(s)
+	if m == nil || m[0] != s {
+		return "", false
+	}
+	return m[1], true
+}
+
+// textRefs 片段文本与特效参数中引用的占位符
+func textRefs(clip render.Clip) []string {
+	var refs []string
+	collect := func(s string) {
+		for _, m := range placeholderRef.FindAllStringSubmatch(s, -1) {
+			refs = append(refs, m[1])
+		}
+	}
+	collect(clip.Text)
+	for _, eff := range clip.Effects {
+		for _, v := range eff.Params {
+			if s, ok := v.(string); ok {
+				collect(s)
+			}
+		}
+	}
+	return refs
+}
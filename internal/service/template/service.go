@@ -4,150 +4,199 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
+	"time"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/entity"
 	"comic_video/internal/repository/postgres"
-
-	"github.com/google/uuid"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/render"
 )
 
 type Service struct {
-	repo *postgres.TemplateRepository
+	repo         *postgres.TemplateRepository
+	projectRepo  postgres.ProjectRepository
+	materialRepo postgres.MaterialRepository
+	renderer     render.Service
+	storage      storage.BlobStore
+	previewQueue render.TaskQueue
 }
 
-func NewService(repo *postgres.TemplateRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *postgres.TemplateRepository, projectRepo postgres.ProjectRepository, materialRepo postgres.MaterialRepository, renderer render.Service, store storage.BlobStore, previewQueue render.TaskQueue) *Service {
+	return &Service{repo: repo, projectRepo: projectRepo, materialRepo: materialRepo, renderer: renderer, storage: store, previewQueue: previewQueue}
 }
 
-// Create 创建模板
-func (s *Service) Create(ctx context.Context, req dto.CreateTemplateRequest) (*dto.TemplateResponse, error) {
+// Create 创建模板，作者为当前用户；普通用户创建的模板为草稿，提交审核通过后公开
+func (s *Service) Create(ctx context.Context, userID, role string, req dto.CreateTemplateRequest) (*dto.TemplateResponse, error) {
+	user, err := parseActor(userID, role)
+	if err != nil {
+		return nil, err
+	}
 	configJSON, err := json.Marshal(req.Config)
 	if err != nil {
 		return nil, err
 	}
+	cfg, err := ParseConfig(string(configJSON))
+	if err != nil {
+		return nil, err
+	}
 	template := &entity.Template{
 		Name:        req.Name,
 		Description: req.Description,
 		Category:    req.Category,
 		Tags:        req.Tags,
-		Thumbnail:   req.Thumbnail,
-		Preview:     req.Preview,
 		Config:      string(configJSON),
+		Duration:    int(math.Round(cfg.TotalDuration())),
+		Resolution:  cfg.Resolution,
 		IsPublic:    req.IsPublic,
 		IsPremium:   req.IsPremium,
 		Status:      "active",
+		OwnerID:     &user.id,
+		ReviewStatus: entity.TemplateReviewDraft,
+	}
+	// 管理员创建的模板无需审核
+	if user.admin {
+		now := time.Now()
+		template.ReviewStatus = entity.TemplateReviewApproved
+		template.ReviewedBy = &user.id
+		template.ReviewedAt = &now
 	}
 	err = s.repo.Create(ctx, template)
 	if err != nil {
 		return nil, err
 	}
-	return toTemplateResponse(template), nil
+	s.requestPreview(ctx, template)
+	return s.templateResponse(template), nil
 }
 
-// GetByID 获取模板详情
-func (s *Service) GetByID(ctx context.Context, id string) (*dto.TemplateResponse, error) {
-	templateUUID, err := uuid.Parse(id)
-	if err != nil {
-		return nil, errors.New("无效的模板ID")
-	}
-	template, err := s.repo.GetByID(ctx, templateUUID)
+// GetByID 获取模板详情；未审核通过或非公开的模板仅作者和管理员可见
+func (s *Service) GetByID(ctx context.Context, id, userID, role string) (*dto.TemplateResponse, error) {
+	template, err := s.visible(ctx, id, userID, role)
 	if err != nil {
 		return nil, err
 	}
-	return toTemplateResponse(template), nil
+	return s.templateResponse(template), nil
 }
 
-// List 获取模板列表
+// List 获取公开模板列表，只包含审核通过的公开模板
 func (s *Service) List(ctx context.Context, req dto.ListTemplatesRequest) ([]*dto.TemplateResponse, int64, error) {
 	offset := (req.Page - 1) * req.PageSize
-	filter := make(map[string]interface{})
+	filter := map[string]interface{}{
+		"is_public":     true,
+		"review_status": entity.TemplateReviewApproved,
+	}
 	if req.Category != "" {
 		filter["category"] = req.Category
 	}
-	if req.IsPublic != nil {
-		filter["is_public"] = *req.IsPublic
-	}
 	if req.IsPremium != nil {
 		filter["is_premium"] = *req.IsPremium
 	}
-	// 关键词模糊搜索可后续扩展
-
-	templates, total, err := s.repo.List(ctx, offset, req.PageSize, filter)
+	templates, total, err := s.repo.List(ctx, offset, req.PageSize, filter, req.Keyword, req.Sort)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	var responses []*dto.TemplateResponse
 	for _, t := range templates {
-		responses = append(responses, toTemplateResponse(t))
+		responses = append(responses, s.templateResponse(t))
 	}
 	return responses, total, nil
 }
 
-// Update 更新模板
-func (s *Service) Update(ctx context.Context, id string, req dto.UpdateTemplateRequest) (*dto.TemplateResponse, error) {
-	templateUUID, err := uuid.Parse(id)
-	if err != nil {
-		return nil, errors.New("无效的模板ID")
-	}
-	template, err := s.repo.GetByID(ctx, templateUUID)
+// Update 更新模板，仅作者和管理员可操作
+// 作者修改已提交或已审核的模板后退回草稿，需要重新提交审核
+func (s *Service) Update(ctx context.Context, id, userID, role string, req dto.UpdateTemplateRequest) (*dto.TemplateResponse, error) {
+	template, user, err := s.editable(ctx, id, userID, role)
 	if err != nil {
 		return nil, err
 	}
+	updates := map[string]interface{}{}
 	if req.Name != "" {
 		template.Name = req.Name
+		updates["name"] = template.Name
 	}
 	if req.Description != "" {
 		template.Description = req.Description
+		updates["description"] = template.Description
 	}
 	if req.Category != "" {
 		template.Category = req.Category
+		updates["category"] = template.Category
 	}
 	if req.Tags != "" {
 		template.Tags = req.Tags
-	}
-	if req.Thumbnail != "" {
-		template.Thumbnail = req.Thumbnail
-	}
-	if req.Preview != "" {
-		template.Preview = req.Preview
+		updates["tags"] = template.Tags
 	}
 	if req.Config != nil {
 		b, err := json.Marshal(req.Config)
-		if err == nil {
-			template.Config = string(b)
+		if err != nil {
+			return nil, err
 		}
+		cfg, err := ParseConfig(string(b))
+		if err != nil {
+			return nil, err
+		}
+		template.Config = string(b)
+		template.Duration = int(math.Round(cfg.TotalDuration()))
+		template.Resolution = cfg.Resolution
+		updates["config"] = template.Config
+		updates["duration"] = template.Duration
+		updates["resolution"] = template.Resolution
 	}
 	if req.IsPublic != nil {
 		template.IsPublic = *req.IsPublic
+		updates["is_public"] = template.IsPublic
 	}
 	if req.IsPremium != nil {
 		template.IsPremium = *req.IsPremium
+		updates["is_premium"] = template.IsPremium
+	}
+	if !user.admin && template.ReviewStatus != entity.TemplateReviewDraft {
+		template.ReviewStatus = entity.TemplateReviewDraft
+		template.SubmittedAt = nil
+		updates["review_status"] = template.ReviewStatus
+		updates["submitted_at"] = nil
+	}
+	err = s.repo.UpdateFields(ctx, template.ID, updates)
+	if err != nil {
+		return nil, err
+	}
+	// 配置变化后重新渲染预览
+	if req.Config != nil {
+		s.requestPreview(ctx, template)
+	}
+	return s.templateResponse(template), nil
+}
+
+// RenderPreview 重新渲染模板预览，如示例素材更新后
+func (s *Service) RenderPreview(ctx context.Context, id, userID, role string) (*dto.TemplateResponse, error) {
+	if s.renderer == nil || s.previewQueue == nil {
+		return nil, errors.New("预览渲染未启用")
 	}
-	err = s.repo.Update(ctx, template)
+	template, _, err := s.editable(ctx, id, userID, role)
 	if err != nil {
 		return nil, err
 	}
-	return toTemplateResponse(template), nil
+	s.requestPreview(ctx, template)
+	return s.templateResponse(template), nil
 }
 
-// Delete 删除模板
-func (s *Service) Delete(ctx context.Context, id string) error {
-	templateUUID, err := uuid.Parse(id)
+// Delete 删除模板，仅作者和管理员可操作
+func (s *Service) Delete(ctx context.Context, id, userID, role string) error {
+	template, _, err := s.editable(ctx, id, userID, role)
 	if err != nil {
-		return errors.New("无效的模板ID")
+		return err
 	}
-	return s.repo.Delete(ctx, templateUUID)
+	return s.repo.Delete(ctx, template.ID)
 }
 
-// Apply 应用模板（当前仅返回成功，后续可扩展）
-func (s *Service) Apply(ctx context.Context, id string, req dto.ApplyTemplateRequest) (*dto.ApplyTemplateResponse, error) {
-	// TODO: 实现模板应用到项目的逻辑
-	return &dto.ApplyTemplateResponse{
-		Success: true,
-		Message: "模板应用成功",
-	}, nil
+// templateResponse 转为响应结构体，平台渲染的封面和预览动图保存的是对象名，换成访问地址
+func (s *Service) templateResponse(t *entity.Template) *dto.TemplateResponse {
+	resp := toTemplateResponse(t)
+	resp.Thumbnail = storage.ResolveURL(s.storage, t.Thumbnail)
+	resp.Preview = storage.ResolveURL(s.storage, t.Preview)
+	return resp
 }
 
 // 工具函数：转为响应结构体
@@ -162,12 +211,19 @@ func toTemplateResponse(t *entity.Template) *dto.TemplateResponse {
 		Tags:        t.Tags,
 		Thumbnail:   t.Thumbnail,
 		Preview:     t.Preview,
+		PreviewStatus: t.PreviewStatus,
 		Config:      config,
 		IsPublic:    t.IsPublic,
 		IsPremium:   t.IsPremium,
 		DownloadCount: t.DownloadCount,
 		Rating:      t.Rating,
+		RatingCount: t.RatingCount,
 		Status:      t.Status,
+		OwnerID:     t.OwnerID,
+		ReviewStatus: t.ReviewStatus,
+		ReviewNote:  t.ReviewNote,
+		SubmittedAt: t.SubmittedAt,
+		ReviewedAt:  t.ReviewedAt,
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
 	}
@@ -0,0 +1,625 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"comic_video/internal/config"
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/blob"
+	"comic_video/internal/service/material"
+	"comic_video/internal/service/media"
+	"comic_video/internal/service/render"
+	"comic_video/internal/service/video"
+
+	"github.com/google/uuid"
+)
+
+// 分片上传：发起会话后按服务端给定的分片大小逐片上传，断线后查询已上传的分片补传，
+// 全部上传后合并，由后台任务校验并按内容哈希转存，创建视频或素材记录；长时间无活动的会话由定时任务取消并清理分片
+// 直传：签发限定对象名、类型、大小和 SHA-256 的表单，客户端直接上传到存储，
+// 确认后后台任务核对存储端记录的 SHA-256，一致时通过签名地址探测媒体信息并创建记录，文件不经过 API 服务；
+// 存储端未记录校验和时与分片上传一样下载后在服务端计算，客户端声明的哈希不会未经核实就进入共享的内容寻址存储
+
+var (
+	ErrNotFound         = errors.New("上传会话不存在")
+	ErrInvalidParams    = errors.New("上传参数错误")
+	ErrTooLarge         = errors.New("文件超过大小上限")
+	ErrTypeNotAllowed   = errors.New("不支持的文件类型")
+	ErrInvalidPart      = errors.New("分片编号或大小不正确")
+	ErrIncomplete       = errors.New("分片未全部上传")
+	ErrChecksumMismatch = errors.New("文件校验和不一致")
+	ErrSessionClosed    = errors.New("上传会话已结束")
+)
+
+const (
+	minChunkSize   = 5 << 20 // 存储端要求除最后一片外每片不小于 5MB
+	maxParts       = 10000   // 存储端单次分片上传的分片数上限
+	cleanupBatch   = 100
+	probeURLExpiry = 30 * time.Minute // 直传文件探测用签名地址的有效期
+)
+
+// completeParams 完成上传任务参数
+type completeParams struct {
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+type Service struct {
+	repo            *postgres.UploadRepository
+	storage         storage.BlobStore
+	blobs           *blob.Store
+	videos          *video.Service
+	materials       *material.Service
+	queue           render.TaskQueue
+	maxVideoSize    int64
+	maxMaterialSize int64
+	chunkSize       int64
+	ttl             time.Duration
+}
+
+func NewService(repo *postgres.UploadRepository, store storage.BlobStore, blobs *blob.Store, videos *video.Service, materials *material.Service, queue render.TaskQueue, cfg *config.UploadConfig) *Service {
+	chunkSize := int64(cfg.ChunkSizeMB) << 20
+	if chunkSize < minChunkSize {
+		chunkSize = minChunkSize
+	}
+	return &Service{
+		repo:            repo,
+		storage:         store,
+		blobs:           blobs,
+		videos:          videos,
+		materials:       materials,
+		queue:           queue,
+		maxVideoSize:    int64(cfg.MaxVideoSizeMB) << 20,
+		maxMaterialSize: int64(cfg.MaxMaterialSizeMB) << 20,
+		chunkSize:       chunkSize,
+		ttl:             time.Duration(cfg.SessionTTLHours) * time.Hour,
+	}
+}
+
+// Init 校验大小和类型后发起分片上传
+func (s *Service) Init(ctx context.Context, userID string, req *dto.InitUploadRequest) (*dto.UploadSessionResponse, error) {
+	session, err := s.newSession(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分片数超过上限时按 MB 取整放大分片
+	chunkSize := s.chunkSize
+	if (req.FileSize+chunkSize-1)/chunkSize > maxParts {
+		chunkSize = ((req.FileSize+maxParts-1)/maxParts + 1<<20 - 1) &^ (1<<20 - 1)
+	}
+	session.Mode = entity.UploadModeMultipart
+	session.ChunkSize = chunkSize
+	session.TotalParts = int((req.FileSize + chunkSize - 1) / chunkSize)
+
+	session.UploadID, err = s.storage.NewMultipartUpload(ctx, session.ObjectName, session.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("发起分片上传失败: %w", err)
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		_ = s.storage.AbortMultipartUpload(ctx, session.ObjectName, session.UploadID)
+		return nil, err
+	}
+	return toSessionResponse(session, nil), nil
+}
+
+// InitDirect 校验大小和类型后签发直传表单，表单限定对象名、Content-Type、文件不超过声明的大小且内容与 checksum 一致
+// 客户端上传到存储后调用 Complete 创建记录
+func (s *Service) InitDirect(ctx context.Context, userID string, req *dto.InitUploadRequest) (*dto.UploadSessionResponse, error) {
+	if req.Checksum == "" {
+		return nil, fmt.Errorf("%w: 直传需要提供整个文件的 SHA-256（checksum）", ErrInvalidParams)
+	}
+	session, err := s.newSession(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	session.Mode = entity.UploadModeDirect
+
+	url, fields, err := s.storage.PresignedPostPolicy(ctx, session.ObjectName, session.ContentType, session.FileSize, session.Checksum, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("生成直传地址失败: %w", err)
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	resp := toSessionResponse(session, nil)
+	resp.Direct = &dto.DirectUpload{
+		URL:       url,
+		Method:    "POST",
+		Fields:    fields,
+		MaxSize:   session.FileSize,
+		ExpiresAt: session.ExpiresAt,
+	}
+	return resp, nil
+}
+
+// newSession 校验目标参数、大小上限和文件类型，生成对象名和会话
+func (s *Service) newSession(userID string, req *dto.InitUploadRequest) (*entity.UploadSession, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	fileName := filepath.Base(req.FileName)
+	if fileName == "." || fileName == "/" {
+		return nil, fmt.Errorf("%w: 文件名无效", ErrInvalidParams)
+	}
+
+	var (
+		kind   string
+		limit  int64
+		params interface{}
+	)
+	switch req.Target {
+	case entity.UploadTargetVideo:
+		if req.Type != "video" && req.Type != "audio" && req.Type != "image" {
+			return nil, fmt.Errorf("%w: type 应为 video、audio 或 image", ErrInvalidParams)
+		}
+		kind, limit = req.Type, s.maxVideoSize
+		params = dto.UploadVideoRequest{ProjectID: req.ProjectID, Type: req.Type, Description: req.Description}
+	case entity.UploadTargetMaterial:
+		switch {
+		case req.Name == "" || req.Type == "":
+			return nil, fmt.Errorf("%w: 素材需要 name 和 type", ErrInvalidParams)
+		case req.Category != "music" && req.Category != "image" && req.Category != "video" && req.Category != "effect":
+			return nil, fmt.Errorf("%w: category 应为 music、image、video 或 effect", ErrInvalidParams)
+		}
+		kind, limit = material.CategoryKind(req.Category), s.maxMaterialSize
+		params = dto.UploadMaterialRequest{
+			Name:        req.Name,
+			Description: req.Description,
+			Category:    req.Category,
+			Type:        req.Type,
+			IsPublic:    req.IsPublic,
+			IsPremium:   req.IsPremium,
+			Tags:        req.Tags,
+		}
+	default:
+		return nil, fmt.Errorf("%w: target 应为 video 或 material", ErrInvalidParams)
+	}
+	if req.FileSize > limit {
+		return nil, fmt.Errorf("%w: 不能超过 %dMB", ErrTooLarge, limit>>20)
+	}
+	if kind != "" && media.KindOfFile(fileName) != kind {
+		return nil, fmt.Errorf("%w: 需要%s文件", ErrTypeNotAllowed, kind)
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &entity.UploadSession{
+		UserID:      userUUID,
+		Target:      req.Target,
+		FileName:    fileName,
+		ContentType: contentType,
+		FileSize:    req.FileSize,
+		Checksum:    strings.ToLower(req.Checksum),
+		ObjectName:  stagingObjectName(userUUID, fileName),
+		Params:      string(paramsJSON),
+		Status:      entity.UploadStatusUploading,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}, nil
+}
+
+// Get 获取上传会话及已上传的分片，用于断点续传
+func (s *Service) Get(ctx context.Context, userID, id string) (*dto.UploadSessionResponse, error) {
+	session, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	var parts []storage.Part
+	if session.Mode == entity.UploadModeMultipart && session.Status == entity.UploadStatusUploading {
+		if parts, err = s.storage.ListObjectParts(ctx, session.ObjectName, session.UploadID); err != nil {
+			return nil, fmt.Errorf("查询已上传分片失败: %w", err)
+		}
+	}
+	return toSessionResponse(session, parts), nil
+}
+
+// UploadPart 上传第 partNumber 个分片，重复上传同一分片会覆盖；checksum 为该分片的 SHA-256，由存储端校验
+func (s *Service) UploadPart(ctx context.Context, userID, id string, partNumber int, body io.Reader, size int64, checksum string) (*dto.UploadPart, error) {
+	session, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != entity.UploadStatusUploading {
+		return nil, ErrSessionClosed
+	}
+	if session.Mode != entity.UploadModeMultipart {
+		return nil, fmt.Errorf("%w: 直传会话不支持分片上传", ErrInvalidPart)
+	}
+	if partNumber < 1 || partNumber > session.TotalParts {
+		return nil, fmt.Errorf("%w: 分片编号应为 1-%d", ErrInvalidPart, session.TotalParts)
+	}
+	if expected := session.PartSize(partNumber); size != expected {
+		return nil, fmt.Errorf("%w: 第%d片应为%d字节", ErrInvalidPart, partNumber, expected)
+	}
+
+	part, err := s.storage.PutObjectPart(ctx, session.ObjectName, session.UploadID, partNumber, body, size, strings.ToLower(checksum))
+	if errors.Is(err, storage.ErrChecksumMismatch) {
+		return nil, fmt.Errorf("%w: 第%d片", ErrChecksumMismatch, partNumber)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("上传分片失败: %w", err)
+	}
+	// 有分片上传即顺延过期时间
+	_ = s.repo.UpdateFields(ctx, session.ID, map[string]interface{}{"expires_at": time.Now().Add(s.ttl)})
+	return &dto.UploadPart{PartNumber: part.PartNumber, Size: part.Size, ETag: part.ETag}, nil
+}
+
+// Complete 合并全部分片或确认直传的文件已存在，之后提交后台任务校验整个文件的 SHA-256 并创建视频或素材记录
+// 返回时会话为 completing，完成后为 completed，失败为 failed 并记录原因，通过 Get 查询
+func (s *Service) Complete(ctx context.Context, userID, id string) (*dto.UploadSessionResponse, error) {
+	session, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != entity.UploadStatusUploading {
+		return nil, ErrSessionClosed
+	}
+	if s.queue == nil {
+		return nil, errors.New("上传处理未启用")
+	}
+
+	var parts []storage.Part
+	if session.Mode == entity.UploadModeDirect {
+		object, err := s.storage.StatObject(ctx, session.ObjectName)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: 文件尚未上传到存储", ErrIncomplete)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询直传文件失败: %w", err)
+		}
+		// 表单已限制上限，记录以实际大小为准
+		session.FileSize = object.Size
+	} else {
+		if parts, err = s.storage.ListObjectParts(ctx, session.ObjectName, session.UploadID); err != nil {
+			return nil, fmt.Errorf("查询已上传分片失败: %w", err)
+		}
+		if missing := missingParts(session, parts); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: 缺少第 %v 片", ErrIncomplete, missing)
+		}
+	}
+
+	ok, err := s.repo.Transition(ctx, session.ID, entity.UploadStatusUploading, entity.UploadStatusCompleting)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSessionClosed
+	}
+	if session.Mode == entity.UploadModeMultipart {
+		sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+		if err := s.storage.CompleteMultipartUpload(ctx, session.ObjectName, session.UploadID, parts); err != nil {
+			// 分片仍在，恢复为上传中以便重试
+			_, _ = s.repo.Transition(ctx, session.ID, entity.UploadStatusCompleting, entity.UploadStatusUploading)
+			return nil, fmt.Errorf("合并分片失败: %w", err)
+		}
+	}
+
+	session.Status = entity.UploadStatusCompleting
+	if err := s.repo.UpdateFields(ctx, session.ID, map[string]interface{}{"file_size": session.FileSize}); err != nil {
+		return nil, err
+	}
+	if err := s.enqueueComplete(session); err != nil {
+		return nil, s.fail(ctx, session, err)
+	}
+	return toSessionResponse(session, nil), nil
+}
+
+// ProcessComplete 完成上传任务：校验整个文件后纳入内容寻址存储并创建视频或素材记录。
+// 分片上传的文件下载到本地计算 SHA-256；直传的文件在存储端记录的 SHA-256 与声明一致时不再下载，
+// 媒体信息通过签名地址探测。兼容 S3 的存储可能忽略表单中的校验和条件，未记录时同样下载后计算
+func (s *Service) ProcessComplete(ctx context.Context, task *entity.Task) error {
+	var params completeParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fmt.Errorf("任务参数错误: %w", err)
+	}
+	session, err := s.repo.GetByID(ctx, params.SessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status != entity.UploadStatusCompleting {
+		return nil
+	}
+
+	var (
+		videoReq    dto.UploadVideoRequest
+		materialReq dto.UploadMaterialRequest
+		target      interface{} = &videoReq
+	)
+	if session.Target == entity.UploadTargetMaterial {
+		target = &materialReq
+	}
+	if err := json.Unmarshal([]byte(session.Params), target); err != nil {
+		return s.fail(ctx, session, err)
+	}
+
+	sum, input := session.Checksum, ""
+	verified := false
+	if session.Mode == entity.UploadModeDirect {
+		if verified, err = s.verifyStored(ctx, session); err != nil {
+			return s.fail(ctx, session, err)
+		}
+	}
+	if !verified {
+		localPath, err := s.download(ctx, session)
+		if err != nil {
+			return s.fail(ctx, session, err)
+		}
+		defer os.Remove(localPath)
+		if sum, err = media.FileSHA256(localPath); err != nil {
+			return s.fail(ctx, session, err)
+		}
+		if session.Checksum != "" && sum != session.Checksum {
+			_ = s.storage.Delete(ctx, session.ObjectName)
+			return s.fail(ctx, session, fmt.Errorf("%w: 实际为 %s", ErrChecksumMismatch, sum))
+		}
+		input = localPath
+	}
+
+	// 纳入内容寻址存储，相同内容已存在时直接引用，上传的临时对象随即删除
+	stored, err := s.blobs.Adopt(ctx, session.ObjectName, sum, session.FileSize, session.ContentType)
+	_ = s.storage.Delete(ctx, session.ObjectName)
+	if err != nil {
+		return s.fail(ctx, session, err)
+	}
+	if input == "" {
+		if input, err = s.storage.PresignedURL(ctx, stored.ObjectName, probeURLExpiry); err != nil {
+			_ = s.blobs.Release(ctx, stored.Hash)
+			return s.fail(ctx, session, err)
+		}
+	}
+
+	var resultID uuid.UUID
+	switch session.Target {
+	case entity.UploadTargetVideo:
+		v, err := s.videos.CreateFromBlob(ctx, session.UserID, videoReq, stored, session.FileName, input)
+		if err != nil {
+			return s.fail(ctx, session, err)
+		}
+		resultID = v.ID
+	case entity.UploadTargetMaterial:
+		m, err := s.materials.CreateFromBlob(ctx, session.UserID, materialReq, stored, session.FileName, input)
+		if err != nil {
+			return s.fail(ctx, session, err)
+		}
+		resultID = m.ID
+	}
+
+	err = s.repo.UpdateFields(ctx, session.ID, map[string]interface{}{
+		"status":    entity.UploadStatusCompleted,
+		"result_id": resultID,
+		"error":     "",
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[Upload] 上传完成: session=%v target=%s result=%v", session.ID, session.Target, resultID)
+	return nil
+}
+
+// verifyStored 核对存储端为直传对象记录的 SHA-256，不一致时删除对象；存储端未记录时返回 false，由调用方在服务端计算
+func (s *Service) verifyStored(ctx context.Context, session *entity.UploadSession) (bool, error) {
+	object, err := s.storage.StatObject(ctx, session.ObjectName)
+	if err != nil {
+		return false, fmt.Errorf("查询直传文件失败: %w", err)
+	}
+	if object.ChecksumSHA256 == "" {
+		return false, nil
+	}
+	if object.ChecksumSHA256 != session.Checksum {
+		_ = s.storage.Delete(ctx, session.ObjectName)
+		return false, fmt.Errorf("%w: 存储端记录为 %s", ErrChecksumMismatch, object.ChecksumSHA256)
+	}
+	return true, nil
+}
+
+// ResumeCompleting 重新提交服务重启前未处理完的完成上传任务，任务队列在内存中，重启后丢失。
+// 启动时调用，仅适用于单实例部署
+func (s *Service) ResumeCompleting(ctx context.Context) error {
+	if s.queue == nil {
+		return nil
+	}
+	sessions, err := s.repo.ListByStatus(ctx, entity.UploadStatusCompleting)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.enqueueComplete(session); err != nil {
+			return err
+		}
+	}
+	if len(sessions) > 0 {
+		log.Printf("[Upload] 重新提交未完成的上传处理: %d 个", len(sessions))
+	}
+	return nil
+}
+
+func (s *Service) enqueueComplete(session *entity.UploadSession) error {
+	params, _ := json.Marshal(completeParams{SessionID: session.ID})
+	return s.queue.Enqueue(&entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeUploadComplete,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+}
+
+// fail 会话置为 failed 并记录原因
+func (s *Service) fail(ctx context.Context, session *entity.UploadSession, err error) error {
+	log.Printf("[Upload] 完成上传失败: %v session=%v", err, session.ID)
+	session.Status, session.Error = entity.UploadStatusFailed, err.Error()
+	_ = s.repo.UpdateFields(ctx, session.ID, map[string]interface{}{"status": session.Status, "error": session.Error})
+	return err
+}
+
+// Abort 取消上传并删除已上传的分片或直传的文件
+func (s *Service) Abort(ctx context.Context, userID, id string) error {
+	session, err := s.get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.Transition(ctx, session.ID, entity.UploadStatusUploading, entity.UploadStatusAborted)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionClosed
+	}
+	s.discard(ctx, session)
+	return nil
+}
+
+// CleanupExpired 取消过期的上传会话并清理已上传的分片或直传后未确认的文件，返回清理的会话数
+func (s *Service) CleanupExpired(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		sessions, err := s.repo.ListExpired(ctx, time.Now(), cleanupBatch)
+		if err != nil {
+			return count, err
+		}
+		for _, session := range sessions {
+			ok, err := s.repo.Transition(ctx, session.ID, entity.UploadStatusUploading, entity.UploadStatusExpired)
+			if err != nil {
+				return count, err
+			}
+			if !ok {
+				continue
+			}
+			s.discard(ctx, session)
+			count++
+		}
+		if len(sessions) < cleanupBatch {
+			return count, nil
+		}
+	}
+}
+
+// StartCleanup 启动定时清理过期上传会话
+func (s *Service) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := s.CleanupExpired(context.Background())
+			if err != nil {
+				log.Printf("[Upload] 清理过期上传会话失败: %v", err)
+			}
+			if n > 0 {
+				log.Printf("[Upload] 已清理过期上传会话 %d 个", n)
+			}
+		}
+	}()
+}
+
+// stagingObjectName 上传中文件的临时对象名，完成后按内容哈希转存
+func stagingObjectName(userID uuid.UUID, fileName string) string {
+	return fmt.Sprintf("uploads/%s/%s%s", userID.String(), uuid.New().String(), strings.ToLower(filepath.Ext(fileName)))
+}
+
+// discard 删除未完成上传在存储中留下的数据
+func (s *Service) discard(ctx context.Context, session *entity.UploadSession) {
+	var err error
+	if session.Mode == entity.UploadModeDirect {
+		// 直传的文件可能尚未上传，不存在时忽略
+		if _, serr := s.storage.StatObject(ctx, session.ObjectName); serr == nil {
+			err = s.storage.Delete(ctx, session.ObjectName)
+		}
+	} else {
+		err = s.storage.AbortMultipartUpload(ctx, session.ObjectName, session.UploadID)
+	}
+	if err != nil {
+		log.Printf("[Upload] 清理未完成的上传失败: %v session=%v", err, session.ID)
+	}
+}
+
+func (s *Service) get(ctx context.Context, userID, id string) (*entity.UploadSession, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	session, err := s.repo.GetByIDAndUser(ctx, sessionID, userUUID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return session, nil
+}
+
+// download 将合并后或直传的对象下载到本地，供校验和探测使用
+func (s *Service) download(ctx context.Context, session *entity.UploadSession) (string, error) {
+	file, err := os.CreateTemp("", "upload_*"+filepath.Ext(session.FileName))
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+	if err := media.Download(ctx, s.storage, session.ObjectName, file.Name()); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("读取上传的文件失败: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// missingParts 返回未上传或大小不符的分片编号，最多列出 20 个
+func missingParts(session *entity.UploadSession, parts []storage.Part) []int {
+	sizes := make(map[int]int64, len(parts))
+	for _, p := range parts {
+		sizes[p.PartNumber] = p.Size
+	}
+	var missing []int
+	for n := 1; n <= session.TotalParts && len(missing) < 20; n++ {
+		if size, ok := sizes[n]; !ok || size != session.PartSize(n) {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+func toSessionResponse(session *entity.UploadSession, parts []storage.Part) *dto.UploadSessionResponse {
+	resp := &dto.UploadSessionResponse{
+		ID:         session.ID,
+		Target:     session.Target,
+		Mode:       session.Mode,
+		FileName:   session.FileName,
+		FileSize:   session.FileSize,
+		ChunkSize:  session.ChunkSize,
+		TotalParts: session.TotalParts,
+		Checksum:   session.Checksum,
+		Status:     session.Status,
+		Error:      session.Error,
+		Parts:      []dto.UploadPart{},
+		ResultID:   session.ResultID,
+		ExpiresAt:  session.ExpiresAt,
+		CreatedAt:  session.CreatedAt,
+	}
+	for _, p := range parts {
+		resp.Parts = append(resp.Parts, dto.UploadPart{PartNumber: p.PartNumber, Size: p.Size, ETag: p.ETag})
+	}
+	return resp
+}
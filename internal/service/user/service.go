@@ -3,20 +3,24 @@ package user
 import (
 	"context"
 	"errors"
+	"log"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/redis"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
 	userRepo *postgres.UserRepository
+	redis    *redis.Client
 }
 
-func NewService(userRepo *postgres.UserRepository) *Service {
+func NewService(userRepo *postgres.UserRepository, redis *redis.Client) *Service {
 	return &Service{
 		userRepo: userRepo,
+		redis:    redis,
 	}
 }
 
@@ -89,6 +93,7 @@ func (s *Service) Update(ctx context.Context, userID string, req dto.UpdateUserR
 	if req.Avatar != "" {
 		user.Avatar = req.Avatar
 	}
+	revoke := (req.Role != "" && req.Role != user.Role) || (req.Status != "" && req.Status != user.Status)
 	if req.Role != "" {
 		user.Role = req.Role
 	}
@@ -101,6 +106,13 @@ func (s *Service) Update(ctx context.Context, userID string, req dto.UpdateUserR
 		return nil, err
 	}
 
+	// 角色随令牌下发，变更后吊销现有令牌，重新登录后生效
+	if revoke {
+		if err := s.redis.Del(ctx, "token:"+userID); err != nil {
+			return nil, err
+		}
+	}
+
 	return &dto.UserResponse{
 		ID:        user.ID,
 		Username:  user.Username,
@@ -121,5 +133,28 @@ func (s *Service) Delete(ctx context.Context, userID string) error {
 		return errors.New("无效的用户ID")
 	}
 
-	return s.userRepo.Delete(ctx, userUUID)
-} 
\ No newline at end of file
+	if err := s.userRepo.Delete(ctx, userUUID); err != nil {
+		return err
+	}
+	// 令牌中已带角色，认证时不再查库，删除用户时一并吊销令牌
+	return s.redis.Del(ctx, "token:"+userID)
+}
+
+// PromoteAdmins 将配置的邮箱对应的用户设为管理员并吊销其令牌，重新登录后获得管理员权限
+// 尚未注册的邮箱在注册后下次启动时生效
+func (s *Service) PromoteAdmins(ctx context.Context, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	ids, err := s.userRepo.PromoteAdmins(ctx, emails)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		log.Printf("[User] 设为管理员: user=%v", id)
+		if err := s.redis.Del(ctx, "token:"+id.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
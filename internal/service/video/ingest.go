@@ -0,0 +1,36 @@
+package video
+
+import (
+	"context"
+	"log"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/media"
+)
+
+// ingest 探测已上传文件的实际封装格式与编码并回填媒体信息，状态由 uploaded 变为 ready 或 failed
+// 探测失败的记录保留供用户查看和删除，返回的错误包装 media.ErrUnsupported
+func (s *Service) ingest(ctx context.Context, video *entity.Video, input string) error {
+	info, err := media.Probe(ctx, input)
+	if err == nil {
+		err = info.Expect(video.Type)
+	}
+	if err != nil {
+		log.Printf("[Video] 媒体探测失败: %v video=%v", err, video.ID)
+		video.Status = entity.VideoStatusFailed
+		if uerr := s.repo.Update(ctx, video); uerr != nil {
+			log.Printf("[Video] 更新视频状态失败: %v video=%v", uerr, video.ID)
+		}
+		return err
+	}
+
+	video.Format = info.Container
+	video.Codec = info.Codec()
+	video.Bitrate = info.Bitrate
+	video.FPS = info.FPS
+	video.Duration = info.Duration
+	video.Width = info.Width
+	video.Height = info.Height
+	video.Status = entity.VideoStatusReady
+	return s.repo.Update(ctx, video)
+}
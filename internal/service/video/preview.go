@@ -0,0 +1,126 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/media"
+
+	"github.com/google/uuid"
+)
+
+// previewParams 预览生成任务参数
+type previewParams struct {
+	VideoID uuid.UUID `json:"video_id"`
+}
+
+// previewPrefix 视频预览资源的对象路径前缀
+func previewPrefix(id uuid.UUID) string {
+	return "previews/videos/" + id.String()
+}
+
+// GeneratePreviews 重新生成视频的封面、胶片条和波形，仅限探测成功的视频
+func (s *Service) GeneratePreviews(ctx context.Context, id string, userID string) (*dto.VideoResponse, error) {
+	videoUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("无效的视频ID")
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+	video, err := s.repo.GetByIDAndUser(ctx, videoUUID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if video.Status != entity.VideoStatusReady {
+		return nil, errors.New("视频尚未就绪，无法生成预览")
+	}
+	if s.mediaQueue == nil {
+		return nil, errors.New("预览生成未启用")
+	}
+	s.requestPreviews(ctx, video)
+	return s.videoResponse(video), nil
+}
+
+// requestPreviews 提交预览生成任务；未配置任务队列时跳过
+func (s *Service) requestPreviews(ctx context.Context, video *entity.Video) {
+	if s.mediaQueue == nil {
+		return
+	}
+	params, _ := json.Marshal(previewParams{VideoID: video.ID})
+	task := &entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeVideoPreview,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.UpdateFields(ctx, video.ID, map[string]interface{}{"preview_status": media.PreviewPending}); err != nil {
+		log.Printf("[Video] 更新预览状态失败: %v video=%v", err, video.ID)
+	}
+	video.PreviewStatus = media.PreviewPending
+	_ = s.mediaQueue.Enqueue(task)
+}
+
+// ProcessPreviews 预览生成任务：下载原文件，生成封面、胶片条和波形后写回视频记录
+func (s *Service) ProcessPreviews(ctx context.Context, task *entity.Task) error {
+	var params previewParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fmt.Errorf("任务参数错误: %w", err)
+	}
+	fail := func(err error) error {
+		log.Printf("[Video] 预览生成失败: %v video=%v", err, params.VideoID)
+		_ = s.repo.UpdateFields(ctx, params.VideoID, map[string]interface{}{"preview_status": media.PreviewFailed})
+		return err
+	}
+
+	video, err := s.repo.GetByID(ctx, params.VideoID)
+	if err != nil {
+		return fail(err)
+	}
+	_ = s.repo.UpdateFields(ctx, video.ID, map[string]interface{}{"preview_status": media.PreviewProcessing})
+
+	tempDir, err := os.MkdirTemp("", "video_preview_*")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(tempDir)
+	localPath := filepath.Join(tempDir, "source"+filepath.Ext(video.OriginalName))
+	if err := s.download(ctx, video.FilePath, localPath); err != nil {
+		return fail(fmt.Errorf("下载视频失败: %w", err))
+	}
+	info, err := media.Probe(ctx, localPath)
+	if err != nil {
+		return fail(err)
+	}
+	previews, err := media.GeneratePreviews(ctx, s.storage, localPath, info, previewPrefix(video.ID))
+	if err != nil {
+		return fail(err)
+	}
+
+	updates := map[string]interface{}{
+		"filmstrip":      previews.Filmstrip,
+		"filmstrip_vtt":  previews.FilmstripVTT,
+		"waveform":       previews.Waveform,
+		"preview_status": media.PreviewCompleted,
+	}
+	// 音频没有画面，保留已有封面
+	if previews.Poster != "" {
+		updates["thumbnail"] = previews.Poster
+	}
+	if err := s.repo.UpdateFields(ctx, video.ID, updates); err != nil {
+		return fail(err)
+	}
+	log.Printf("[Video] 预览生成完成: video=%v", video.ID)
+	return nil
+}
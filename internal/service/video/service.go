@@ -3,29 +3,37 @@ package video
 import (
 	"context"
 	"errors"
-	"fmt"
 	"mime/multipart"
+	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"comic_video/internal/domain/dto"
 	"comic_video/internal/domain/entity"
-	"comic_video/internal/repository/minio"
 	"comic_video/internal/repository/postgres"
+	"comic_video/internal/repository/storage"
+	"comic_video/internal/service/blob"
+	"comic_video/internal/service/media"
+	"comic_video/internal/service/render"
 
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo  *postgres.VideoRepository
-	minio minio.MinioClient
+	repo           *postgres.VideoRepository
+	transcriptRepo *postgres.TranscriptRepository
+	storage        storage.BlobStore
+	blobs          *blob.Store      // 上传文件按内容去重存储
+	mediaQueue     render.TaskQueue // 媒体处理任务队列，为 nil 时不生成预览
 }
 
-func NewService(repo *postgres.VideoRepository, minio minio.MinioClient) *Service {
+func NewService(repo *postgres.VideoRepository, transcriptRepo *postgres.TranscriptRepository, store storage.BlobStore, blobs *blob.Store, mediaQueue render.TaskQueue) *Service {
 	return &Service{
-		repo:  repo,
-		minio: minio,
+		repo:           repo,
+		transcriptRepo: transcriptRepo,
+		storage:        store,
+		blobs:          blobs,
+		mediaQueue:     mediaQueue,
 	}
 }
 
@@ -36,43 +44,48 @@ func (s *Service) Upload(ctx context.Context, userID string, req dto.UploadVideo
 		return nil, errors.New("无效的用户ID")
 	}
 
-	file, err := fileHeader.Open()
+	localPath, err := media.Spool(fileHeader)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	defer os.Remove(localPath)
 
-	// 生成唯一文件名
-	ext := filepath.Ext(fileHeader.Filename)
-	objectName := fmt.Sprintf("videos/%s/%d_%s%s", userUUID.String(), time.Now().UnixNano(), uuid.New().String()[:8], ext)
-	
-	// 上传到MinIO
-	url, err := s.minio.Upload(ctx, objectName, file, fileHeader.Size, fileHeader.Header.Get("Content-Type"))
+	// 按内容存入MinIO，相同文件只保存一份
+	stored, err := s.blobs.Put(ctx, localPath, fileHeader.Header.Get("Content-Type"))
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建视频记录
+	return s.CreateFromBlob(ctx, userUUID, req, stored, fileHeader.Filename, localPath)
+}
+
+// CreateFromBlob 为已存入内容寻址存储的文件创建视频记录并探测媒体信息，调用方已持有一个引用，input 为同一文件的本地副本或签名地址，仅用于探测
+// 普通上传、分片上传和直传完成后都经由此处入库
+func (s *Service) CreateFromBlob(ctx context.Context, userID uuid.UUID, req dto.UploadVideoRequest, stored *entity.Blob, originalName string, input string) (*dto.VideoResponse, error) {
 	video := &entity.Video{
-		UserID:       userUUID,
+		UserID:       userID,
 		ProjectID:    req.ProjectID,
-		FileName:     objectName,
-		OriginalName: fileHeader.Filename,
-		FilePath:     objectName,
-		FileSize:     fileHeader.Size,
-		Format:       strings.TrimPrefix(ext, "."),
+		FileName:     stored.ObjectName,
+		OriginalName: originalName,
+		FilePath:     stored.ObjectName,
+		ContentHash:  stored.Hash,
+		FileSize:     stored.Size,
+		Format:       strings.TrimPrefix(filepath.Ext(originalName), "."),
 		Type:         req.Type,
-		Status:       "uploaded",
+		Status:       entity.VideoStatusUploaded,
 	}
 
-	err = s.repo.Create(ctx, video)
-	if err != nil {
-		// 回滚MinIO
-		_ = s.minio.Delete(ctx, objectName)
+	if err := s.repo.Create(ctx, video); err != nil {
+		// 释放引用
+		_ = s.blobs.Release(ctx, stored.Hash)
 		return nil, err
 	}
 
-	return toVideoResponse(video, url), nil
+	if err := s.ingest(ctx, video, input); err != nil {
+		return nil, err
+	}
+	s.requestPreviews(ctx, video)
+	return s.videoResponse(video), nil
 }
 
 // GetByID 获取视频详情
@@ -92,8 +105,7 @@ func (s *Service) GetByID(ctx context.Context, id string, userID string) (*dto.V
 		return nil, err
 	}
 
-	url := s.minio.GetURL(video.FilePath)
-	return toVideoResponse(video, url), nil
+	return s.videoResponse(video), nil
 }
 
 // List 获取视频列表
@@ -105,7 +117,7 @@ func (s *Service) List(ctx context.Context, userID string, req dto.ListVideosReq
 
 	offset := (req.Page - 1) * req.PageSize
 	filter := make(map[string]interface{})
-	
+
 	if req.Type != "" {
 		filter["type"] = req.Type
 	}
@@ -120,8 +132,7 @@ func (s *Service) List(ctx context.Context, userID string, req dto.ListVideosReq
 
 	var responses []*dto.VideoResponse
 	for _, v := range videos {
-		url := s.minio.GetURL(v.FilePath)
-		responses = append(responses, toVideoResponse(v, url))
+		responses = append(responses, s.videoResponse(v))
 	}
 
 	return responses, total, nil
@@ -156,8 +167,7 @@ func (s *Service) Update(ctx context.Context, id string, userID string, req dto.
 		return nil, err
 	}
 
-	url := s.minio.GetURL(video.FilePath)
-	return toVideoResponse(video, url), nil
+	return s.videoResponse(video), nil
 }
 
 // Delete 删除视频
@@ -177,17 +187,20 @@ func (s *Service) Delete(ctx context.Context, id string, userID string) error {
 		return err
 	}
 
-	// 先删除MinIO文件
-	err = s.minio.Delete(ctx, video.FilePath)
+	// 先释放MinIO文件，其他记录仍引用相同内容时保留
+	err = s.blobs.Remove(ctx, video.ContentHash, video.FilePath)
 	if err != nil {
 		return err
 	}
+	media.DeletePreviews(ctx, s.storage, previewPrefix(videoUUID))
+	_ = s.storage.DeletePrefix(ctx, transcodePrefix(videoUUID)+"/")
+	_ = s.transcriptRepo.DeleteByVideo(ctx, videoUUID)
 
 	return s.repo.Delete(ctx, videoUUID)
 }
 
-// Process 处理视频（占位符，后续可扩展转码等功能）
-func (s *Service) Process(ctx context.Context, id string, userID string) error {
+// Process 提交视频转码：生成剪辑代理，按需生成 HLS 多码率版本，进度通过 GetStatus 查询
+func (s *Service) Process(ctx context.Context, id string, userID string, req dto.ProcessVideoRequest) error {
 	videoUUID, err := uuid.Parse(id)
 	if err != nil {
 		return errors.New("无效的视频ID")
@@ -198,56 +211,96 @@ func (s *Service) Process(ctx context.Context, id string, userID string) error {
 		return errors.New("无效的用户ID")
 	}
 
-	_, err = s.repo.GetByIDAndUser(ctx, videoUUID, userUUID)
+	video, err := s.repo.GetByIDAndUser(ctx, videoUUID, userUUID)
 	if err != nil {
 		return err
 	}
-
-	// TODO: 实现视频处理逻辑（转码、生成缩略图等）
-	return s.repo.UpdateStatus(ctx, videoUUID, "processing")
+	if video.Type != "video" {
+		return errors.New("只有视频支持转码")
+	}
+	switch video.Status {
+	case entity.VideoStatusReady, entity.VideoStatusFailed:
+	case entity.VideoStatusProcessing:
+		return errors.New("视频正在转码")
+	default:
+		return errors.New("视频尚未就绪，无法转码")
+	}
+	if s.mediaQueue == nil {
+		return errors.New("视频转码未启用")
+	}
+	return s.requestTranscode(ctx, video, req.HLS)
 }
 
-// GetStatus 获取视频处理状态
-func (s *Service) GetStatus(ctx context.Context, id string, userID string) (string, error) {
+// GetStatus 获取视频处理状态与转码进度
+func (s *Service) GetStatus(ctx context.Context, id string, userID string) (*dto.VideoStatusResponse, error) {
 	videoUUID, err := uuid.Parse(id)
 	if err != nil {
-		return "", errors.New("无效的视频ID")
+		return nil, errors.New("无效的视频ID")
 	}
 
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return "", errors.New("无效的用户ID")
+		return nil, errors.New("无效的用户ID")
 	}
 
 	video, err := s.repo.GetByIDAndUser(ctx, videoUUID, userUUID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return video.Status, nil
+	resp := s.videoResponse(video)
+	return &dto.VideoStatusResponse{
+		Status:   video.Status,
+		Progress: video.Progress,
+		Error:    video.ProcessError,
+		Proxy:    resp.Proxy,
+		HLS:      resp.HLS,
+	}, nil
+}
+
+// videoResponse 转为响应结构体，对象路径换成访问地址
+func (s *Service) videoResponse(v *entity.Video) *dto.VideoResponse {
+	resp := toVideoResponse(v, s.storage.GetURL(v.FilePath))
+	if v.ProxyPath != "" {
+		resp.Proxy = s.storage.GetURL(v.ProxyPath)
+	}
+	if v.HLSPath != "" {
+		resp.HLS = s.storage.GetURL(v.HLSPath)
+	}
+	resp.Thumbnail = storage.ResolveURL(s.storage, v.Thumbnail)
+	resp.Filmstrip = storage.ResolveURL(s.storage, v.Filmstrip)
+	resp.FilmstripVTT = storage.ResolveURL(s.storage, v.FilmstripVTT)
+	resp.Waveform = storage.ResolveURL(s.storage, v.Waveform)
+	return resp
 }
 
 // 工具函数：转为响应结构体
 func toVideoResponse(v *entity.Video, url string) *dto.VideoResponse {
 	return &dto.VideoResponse{
-		ID:           v.ID,
-		UserID:       v.UserID,
-		ProjectID:    v.ProjectID,
-		FileName:     v.FileName,
-		OriginalName: v.OriginalName,
-		FilePath:     url,
-		FileSize:     v.FileSize,
-		Duration:     v.Duration,
-		Width:        v.Width,
-		Height:       v.Height,
-		Format:       v.Format,
-		Codec:        v.Codec,
-		Bitrate:      v.Bitrate,
-		FPS:          v.FPS,
-		Thumbnail:    v.Thumbnail,
-		Status:       v.Status,
-		Type:         v.Type,
-		CreatedAt:    v.CreatedAt,
-		UpdatedAt:    v.UpdatedAt,
-	}
-} 
\ No newline at end of file
+		ID:            v.ID,
+		UserID:        v.UserID,
+		ProjectID:     v.ProjectID,
+		FileName:      v.FileName,
+		OriginalName:  v.OriginalName,
+		FilePath:      url,
+		ContentHash:   v.ContentHash,
+		FileSize:      v.FileSize,
+		Duration:      v.Duration,
+		Width:         v.Width,
+		Height:        v.Height,
+		Format:        v.Format,
+		Codec:         v.Codec,
+		Bitrate:       v.Bitrate,
+		FPS:           v.FPS,
+		Thumbnail:     v.Thumbnail,
+		Filmstrip:     v.Filmstrip,
+		FilmstripVTT:  v.FilmstripVTT,
+		Waveform:      v.Waveform,
+		PreviewStatus: v.PreviewStatus,
+		Progress:      v.Progress,
+		Status:        v.Status,
+		Type:          v.Type,
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+	}
+}
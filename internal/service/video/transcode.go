@@ -0,0 +1,167 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/service/media"
+
+	"github.com/google/uuid"
+)
+
+// transcodeParams 转码任务参数
+type transcodeParams struct {
+	VideoID uuid.UUID `json:"video_id"`
+	HLS     bool      `json:"hls"`
+}
+
+// 生成 HLS 时剪辑代理占总进度的比例
+const proxyProgressShare = 0.3
+
+// transcodePrefix 转码产物的对象路径前缀
+func transcodePrefix(id uuid.UUID) string {
+	return "transcodes/videos/" + id.String()
+}
+
+// requestTranscode 将视频置为 processing 并提交转码任务
+func (s *Service) requestTranscode(ctx context.Context, video *entity.Video, hls bool) error {
+	if err := s.repo.UpdateFields(ctx, video.ID, map[string]interface{}{
+		"status":        entity.VideoStatusProcessing,
+		"progress":      0,
+		"process_error": "",
+		"transcode_hls": hls,
+	}); err != nil {
+		return err
+	}
+	params, _ := json.Marshal(transcodeParams{VideoID: video.ID, HLS: hls})
+	return s.mediaQueue.Enqueue(&entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeVideoTranscode,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+}
+
+// ResumeTranscodes 重新提交服务重启前未完成的转码：任务队列在内存中，重启后任务丢失，
+// 视频会一直停留在 processing 且无法再次提交。启动时调用，仅适用于单实例部署
+func (s *Service) ResumeTranscodes(ctx context.Context) error {
+	if s.mediaQueue == nil {
+		return nil
+	}
+	videos, err := s.repo.ListByStatus(ctx, entity.VideoStatusProcessing)
+	if err != nil {
+		return err
+	}
+	for _, video := range videos {
+		if err := s.requestTranscode(ctx, video, video.TranscodeHLS); err != nil {
+			return err
+		}
+	}
+	if len(videos) > 0 {
+		log.Printf("[Video] 重新提交未完成的转码: %d 个", len(videos))
+	}
+	return nil
+}
+
+// ProcessTranscode 转码任务：生成恒定帧率、每秒一个关键帧的低分辨率 H.264 剪辑代理，
+// 按需生成 HLS 多码率版本，上传后视频状态置为 ready，失败置为 failed 并记录原因
+func (s *Service) ProcessTranscode(ctx context.Context, task *entity.Task) error {
+	var params transcodeParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fmt.Errorf("任务参数错误: %w", err)
+	}
+	fail := func(err error) error {
+		log.Printf("[Video] 转码失败: %v video=%v", err, params.VideoID)
+		_ = s.repo.UpdateFields(ctx, params.VideoID, map[string]interface{}{
+			"status":        entity.VideoStatusFailed,
+			"process_error": err.Error(),
+		})
+		return err
+	}
+
+	video, err := s.repo.GetByID(ctx, params.VideoID)
+	if err != nil {
+		return fail(err)
+	}
+	tempDir, err := os.MkdirTemp("", "video_transcode_*")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(tempDir)
+	localPath := filepath.Join(tempDir, "source"+filepath.Ext(video.OriginalName))
+	if err := s.download(ctx, video.FilePath, localPath); err != nil {
+		return fail(fmt.Errorf("下载视频失败: %w", err))
+	}
+	info, err := media.Probe(ctx, localPath)
+	if err == nil {
+		err = info.Expect(media.KindVideo)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	// 进度按整数百分比变化时才写库
+	last := 0
+	report := func(base, share float64) media.ProgressFunc {
+		return func(p float64) {
+			pct := int((base + p*share) * 100)
+			if pct <= last || pct >= 100 {
+				return
+			}
+			last = pct
+			_ = s.repo.UpdateFields(ctx, video.ID, map[string]interface{}{"progress": pct})
+		}
+	}
+	proxyShare := 1.0
+	if params.HLS {
+		proxyShare = proxyProgressShare
+	}
+
+	proxyDir := filepath.Join(tempDir, "proxy")
+	if err := os.MkdirAll(proxyDir, 0755); err != nil {
+		return fail(err)
+	}
+	if err := media.Proxy(ctx, localPath, info, filepath.Join(proxyDir, "proxy.mp4"), report(0, proxyShare)); err != nil {
+		return fail(err)
+	}
+	hlsDir := filepath.Join(tempDir, "hls")
+	if params.HLS {
+		ladder := media.LadderFor(info.Height, media.DefaultLadder)
+		if err := media.PackageHLS(ctx, localPath, info, hlsDir, ladder, report(proxyShare, 1-proxyShare)); err != nil {
+			return fail(err)
+		}
+	}
+
+	// 清理上一次转码的产物后再上传
+	prefix := transcodePrefix(video.ID)
+	_ = s.storage.DeletePrefix(ctx, prefix+"/")
+	updates := map[string]interface{}{
+		"proxy_path":    prefix + "/proxy.mp4",
+		"hls_path":      "",
+		"progress":      100,
+		"status":        entity.VideoStatusReady,
+		"process_error": "",
+	}
+	if err := media.UploadDir(ctx, s.storage, proxyDir, prefix); err != nil {
+		return fail(fmt.Errorf("上传剪辑代理失败: %w", err))
+	}
+	if params.HLS {
+		if err := media.UploadDir(ctx, s.storage, hlsDir, prefix+"/hls"); err != nil {
+			return fail(fmt.Errorf("上传HLS失败: %w", err))
+		}
+		updates["hls_path"] = prefix + "/hls/master.m3u8"
+	}
+	if err := s.repo.UpdateFields(ctx, video.ID, updates); err != nil {
+		return fail(err)
+	}
+	log.Printf("[Video] 转码完成: video=%v hls=%v", video.ID, params.HLS)
+	return nil
+}
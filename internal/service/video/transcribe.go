@@ -0,0 +1,267 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"comic_video/internal/domain/dto"
+	"comic_video/internal/domain/entity"
+	"comic_video/internal/repository/redis"
+	"comic_video/internal/service/ai"
+	"comic_video/internal/service/media"
+	"comic_video/internal/service/render"
+
+	"github.com/google/uuid"
+)
+
+// TranscribeParams 语音识别任务参数
+type TranscribeParams struct {
+	VideoID        uuid.UUID            `json:"video_id"`
+	UserID         uuid.UUID            `json:"user_id"`
+	Language       string               `json:"language"`
+	WordTimestamps bool                 `json:"word_timestamps"`
+	Providers      ai.ProviderOverrides `json:"providers"`
+}
+
+// NewTranscribeTask 校验视频归属并创建语音识别任务，由调用方写入Redis并入队
+func (s *Service) NewTranscribeTask(ctx context.Context, id string, userID string, req dto.TranscribeVideoRequest) (*entity.Task, error) {
+	videoUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("无效的视频ID")
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("无效的用户ID")
+	}
+
+	video, err := s.repo.GetByIDAndUser(ctx, videoUUID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if video.Type == "image" {
+		return nil, errors.New("图片不支持语音识别")
+	}
+
+	overrides := ai.ProviderOverrides{Audio2Text: ai.ProviderChoice{Provider: req.Provider, Model: req.Model}}
+	providers, err := ai.ResolveProviders(overrides)
+	if err != nil {
+		return nil, err
+	}
+	if providers.Audio2Text == nil {
+		return nil, errors.New("语音识别提供方未配置")
+	}
+
+	params, _ := json.Marshal(TranscribeParams{
+		VideoID:        videoUUID,
+		UserID:         userUUID,
+		Language:       req.Language,
+		WordTimestamps: req.WordTimestamps == nil || *req.WordTimestamps,
+		Providers:      overrides,
+	})
+	return &entity.Task{
+		ID:        uuid.New(),
+		Type:      entity.TaskTypeTranscribe,
+		Status:    entity.TaskStatusPending,
+		Params:    string(params),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// ProcessTranscribe 语音识别任务：下载视频 → 抽取音轨 → 识别 → 保存结果并导出SRT/VTT
+func (s *Service) ProcessTranscribe(ctx context.Context, task *entity.Task, redisClient *redis.Client, providers *ai.Providers) error {
+	setProgress := func(progress int) {
+		task.Status = entity.TaskStatusProcessing
+		task.Progress = progress
+		task.UpdatedAt = time.Now()
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	}
+	fail := func(msg string) error {
+		task.Status = entity.TaskStatusFailed
+		task.Error = msg
+		task.UpdatedAt = time.Now()
+		_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+		log.Printf("[Transcribe] 任务失败: task=%v %s", task.ID, msg)
+		return errors.New(msg)
+	}
+
+	setProgress(5)
+	var params TranscribeParams
+	if err := json.Unmarshal([]byte(task.Params), &params); err != nil {
+		return fail("任务参数错误")
+	}
+	if providers.Audio2Text == nil {
+		return fail("语音识别提供方未配置")
+	}
+	video, err := s.repo.GetByIDAndUser(ctx, params.VideoID, params.UserID)
+	if err != nil {
+		return fail("视频不存在")
+	}
+
+	tempDir, err := os.MkdirTemp("", "transcribe_*")
+	if err != nil {
+		return fail("创建临时目录失败: " + err.Error())
+	}
+	defer os.RemoveAll(tempDir)
+
+	inputPath := filepath.Join(tempDir, "input"+filepath.Ext(video.OriginalName))
+	if err := s.download(ctx, video.FilePath, inputPath); err != nil {
+		return fail("下载视频失败: " + err.Error())
+	}
+	setProgress(15)
+
+	// 抽取 16kHz 单声道音轨，体积小且为识别模型的原生采样率
+	audioPath := filepath.Join(tempDir, "audio.wav")
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-i", inputPath, "-vn", "-ac", "1", "-ar", "16000", "-c:a", "pcm_s16le", audioPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fail(fmt.Sprintf("抽取音轨失败: %v %s", err, tail(string(out), 300)))
+	}
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return fail("读取音轨失败: " + err.Error())
+	}
+	setProgress(25)
+
+	opts := make(map[string]interface{}, len(providers.Audio2TextOpts)+2)
+	for k, v := range providers.Audio2TextOpts {
+		opts[k] = v
+	}
+	if params.Language != "" {
+		opts["language"] = params.Language
+	}
+	if params.WordTimestamps {
+		opts["word_timestamps"] = true
+	}
+	transcript, err := providers.Audio2Text.Transcribe(ctx, audio, opts)
+	if err != nil {
+		return fail("语音识别失败: " + err.Error())
+	}
+	setProgress(85)
+
+	// 导出字幕并上传
+	base := fmt.Sprintf("subtitles/%s/%s/%s", video.UserID, video.ID, task.ID)
+	srt, vtt := transcript.SRT(), transcript.VTT()
+	if _, err := s.storage.Upload(ctx, base+".srt", strings.NewReader(srt), int64(len(srt)), "application/x-subrip"); err != nil {
+		return fail("上传字幕失败: " + err.Error())
+	}
+	if _, err := s.storage.Upload(ctx, base+".vtt", strings.NewReader(vtt), int64(len(vtt)), "text/vtt"); err != nil {
+		return fail("上传字幕失败: " + err.Error())
+	}
+
+	segments, _ := json.Marshal(transcript.Segments)
+	record := &entity.Transcript{
+		VideoID:  video.ID,
+		UserID:   video.UserID,
+		TaskID:   task.ID,
+		Provider: providers.Names.Audio2Text.Provider,
+		Language: transcript.Language,
+		Duration: transcript.Duration,
+		Text:     transcript.Text,
+		Segments: string(segments),
+		SRTPath:  base + ".srt",
+		VTTPath:  base + ".vtt",
+	}
+	if err := s.transcriptRepo.Create(ctx, record); err != nil {
+		return fail("保存识别结果失败: " + err.Error())
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"transcript_id": record.ID,
+		"language":      record.Language,
+		"segments":      len(transcript.Segments),
+		"srt_url":       s.storage.GetURL(record.SRTPath),
+		"vtt_url":       s.storage.GetURL(record.VTTPath),
+	})
+	task.Status = entity.TaskStatusCompleted
+	task.Progress = 100
+	task.Result = string(result)
+	task.UpdatedAt = time.Now()
+	_ = redisClient.SetTaskStatus(ctx, task, 24*time.Hour)
+	log.Printf("[Transcribe] 任务完成: task=%v video=%v language=%s segments=%d", task.ID, video.ID, record.Language, len(transcript.Segments))
+	return nil
+}
+
+// GetTranscript 获取视频最近一次的识别结果
+func (s *Service) GetTranscript(ctx context.Context, id string, userID string) (*dto.TranscriptResponse, error) {
+	record, transcript, err := s.latestTranscript(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TranscriptResponse{
+		ID:            record.ID,
+		VideoID:       record.VideoID,
+		TaskID:        record.TaskID,
+		Provider:      record.Provider,
+		Language:      record.Language,
+		Duration:      record.Duration,
+		Text:          record.Text,
+		Segments:      json.RawMessage(record.Segments),
+		SRTURL:        s.storage.GetURL(record.SRTPath),
+		VTTURL:        s.storage.GetURL(record.VTTPath),
+		SubtitleTrack: render.SubtitleTrack(transcript.Cues()),
+		CreatedAt:     record.CreatedAt,
+	}, nil
+}
+
+// GetSubtitles 导出视频字幕，format 为 srt 或 vtt，返回内容与 Content-Type
+func (s *Service) GetSubtitles(ctx context.Context, id string, userID string, format string) (string, string, error) {
+	_, transcript, err := s.latestTranscript(ctx, id, userID)
+	if err != nil {
+		return "", "", err
+	}
+	switch format {
+	case "", "srt":
+		return transcript.SRT(), "application/x-subrip; charset=utf-8", nil
+	case "vtt":
+		return transcript.VTT(), "text/vtt; charset=utf-8", nil
+	}
+	return "", "", errors.New("不支持的字幕格式")
+}
+
+func (s *Service) latestTranscript(ctx context.Context, id string, userID string) (*entity.Transcript, *ai.Transcript, error) {
+	videoUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, errors.New("无效的视频ID")
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil, errors.New("无效的用户ID")
+	}
+
+	if _, err := s.repo.GetByIDAndUser(ctx, videoUUID, userUUID); err != nil {
+		return nil, nil, err
+	}
+	record, err := s.transcriptRepo.GetLatestByVideo(ctx, videoUUID)
+	if err != nil {
+		return nil, nil, errors.New("视频尚未识别")
+	}
+	transcript := &ai.Transcript{Text: record.Text, Language: record.Language, Duration: record.Duration}
+	if err := json.Unmarshal([]byte(record.Segments), &transcript.Segments); err != nil {
+		return nil, nil, err
+	}
+	return record, transcript, nil
+}
+
+// download 从MinIO下载对象到本地
+func (s *Service) download(ctx context.Context, objectName, localPath string) error {
+	return media.Download(ctx, s.storage, objectName, localPath)
+}
+
+// tail 取输出末尾 n 个字符，便于记录 ffmpeg 错误；按字符截取，避免截断多字节字符
+func tail(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[len(r)-n:])
+}
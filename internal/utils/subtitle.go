@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SubtitleCue 一条字幕，时间单位为秒
+type SubtitleCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// FormatSRT 生成 SRT 字幕
+func FormatSRT(cues []SubtitleCue) string {
+	var sb strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, subtitleTime(cue.Start, ","), subtitleTime(cue.End, ","), strings.TrimSpace(cue.Text))
+	}
+	return sb.String()
+}
+
+// FormatVTT 生成 WebVTT 字幕
+func FormatVTT(cues []SubtitleCue) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&sb, "%s --> %s\n%s\n\n", subtitleTime(cue.Start, "."), subtitleTime(cue.End, "."), strings.TrimSpace(cue.Text))
+	}
+	return sb.String()
+}
+
+// subtitleTime 格式化为 hh:mm:ss,mmm（SRT）或 hh:mm:ss.mmm（VTT）
+func subtitleTime(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}